@@ -0,0 +1,186 @@
+package account
+
+import (
+	"book-store/internal/domain"
+	"book-store/internal/middleware/jwt"
+	"book-store/internal/middleware/validation"
+	"book-store/internal/utilities"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type HttpAccountHandler struct {
+	accountSvc     domain.AccountService
+	authMiddleware jwt.AuthMiddleware
+}
+
+// NewHttpHandler serves the user of the token under /auth/me
+func NewHttpHandler(r fiber.Router, accountSvc domain.AccountService, authMiddleware jwt.AuthMiddleware) {
+	handler := &HttpAccountHandler{accountSvc: accountSvc, authMiddleware: authMiddleware}
+
+	// users who have to change their password can still see that they have to
+	r.Get("/", authMiddleware.RequireUser(), handler.Get)
+	r.Patch("/", authMiddleware.RequireRole("admin", "employee"), validation.New[domain.AccountUpdateRequest](), handler.Update)
+	r.Get("/transactions", authMiddleware.RequireRole("admin", "employee"), handler.FetchTransactions)
+}
+
+// NewVerificationHttpHandler serves the verification of new emails under /auth, it needs no token
+func NewVerificationHttpHandler(r fiber.Router, accountSvc domain.AccountService, authMiddleware jwt.AuthMiddleware) {
+	handler := &HttpAccountHandler{accountSvc: accountSvc, authMiddleware: authMiddleware}
+
+	r.Post("/email-verification", validation.New[domain.EmailVerificationRequest](), handler.VerifyEmail)
+}
+
+// Get used to get the authenticated user
+//
+//	@Summary		Get own account
+//	@Description	Get the user of the token, with the email they are changing to if any
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	domain.Success	"account detail"
+//	@Failure		401	{object}	domain.Error	"Unauthorized"
+//	@Failure		404	{object}	domain.Error	"Not Found"
+//	@Failure		500	{object}	domain.Error	"Internal Server Error"
+//	@Router			/auth/me [get]
+//
+// @Security Bearer
+func (h *HttpAccountHandler) Get(c *fiber.Ctx) error {
+	userId, ok := domain.UserIdFrom(c.UserContext())
+	if !ok {
+		return domain.ErrUnauthorized
+	}
+
+	account, err := h.accountSvc.Get(c.UserContext(), userId)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(domain.Success{
+		Code:    fiber.StatusOK,
+		Message: "success",
+		Data:    account,
+	})
+}
+
+// Update used to update the authenticated user
+//
+//	@Summary		Update own account
+//	@Description	Change the name of the user of the token right away. A new email takes the current password and is only used once verified with the token mailed to it
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			account	body		domain.AccountUpdateRequest	true	"account data"
+//	@Success		200		{object}	domain.Success				"account detail"
+//	@Failure		400		{object}	domain.Error				"Bad Request"
+//	@Failure		401		{object}	domain.Error				"Unauthorized"
+//	@Failure		409		{object}	domain.Error				"Email Taken"
+//	@Failure		429		{object}	domain.Error				"Too Many Failed Logins"
+//	@Failure		500		{object}	domain.Error				"Internal Server Error"
+//	@Router			/auth/me [patch]
+//
+// @Security Bearer
+func (h *HttpAccountHandler) Update(c *fiber.Ctx) error {
+	userId, ok := domain.UserIdFrom(c.UserContext())
+	if !ok {
+		return domain.ErrUnauthorized
+	}
+
+	accountReq := utilities.ExtractStructFromValidator[domain.AccountUpdateRequest](c)
+
+	account, err := h.accountSvc.Update(c.UserContext(), userId, accountReq)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(domain.Success{
+		Code:    fiber.StatusOK,
+		Message: "success",
+		Data:    account,
+	})
+}
+
+// FetchTransactions used to get the transactions of the authenticated user
+//
+//	@Summary		Get own transactions
+//	@Description	Get the transactions recorded by the user of the token
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			page	query		int				false	"Page number (default 1)"
+//	@Param			size	query		int				false	"Size of page (default 10)"
+//	@Header			200		{string}	X-Cursor		"Next page"
+//	@Header			200		{string}	X-Total-Count	"Total item"
+//	@Header			200		{string}	X-Max-Page		"Max page"
+//	@Success		200		{array}		domain.Success	"List of transactions"
+//	@Failure		400		{object}	domain.Error	"Bad Request"
+//	@Failure		401		{object}	domain.Error	"Unauthorized"
+//	@Failure		500		{object}	domain.Error	"Internal Server Error"
+//	@Router			/auth/me/transactions [get]
+//
+// @Security Bearer
+func (h *HttpAccountHandler) FetchTransactions(c *fiber.Ctx) error {
+	userId, ok := domain.UserIdFrom(c.UserContext())
+	if !ok {
+		return domain.ErrUnauthorized
+	}
+
+	page, size := c.QueryInt("page", 1), c.QueryInt("size", 10)
+	if page <= 0 {
+		return domain.NewValidationError("page must be a positive integer")
+	}
+	if size <= 0 {
+		return domain.NewValidationError("size must be a positive integer")
+	}
+
+	transactions, nextPage, err := h.accountSvc.FetchTransactions(c.UserContext(), userId, page, size)
+	if err != nil {
+		return err
+	}
+
+	totalItem, err := h.accountSvc.CountTransactions(c.UserContext(), userId)
+	if err != nil {
+		return err
+	}
+
+	maxPage := int(totalItem) / size
+
+	if nextPage > 0 && nextPage <= maxPage {
+		c.Set("X-Cursor", strconv.Itoa(nextPage))
+	}
+	c.Set("X-Total-Count", strconv.Itoa(int(totalItem)))
+	c.Set("X-Max-Page", strconv.Itoa(maxPage))
+
+	return c.Status(fiber.StatusOK).JSON(domain.Success{
+		Code:    fiber.StatusOK,
+		Message: "success",
+		Data:    transactions,
+	})
+}
+
+// VerifyEmail used to confirm a new email
+//
+//	@Summary		Verify new email
+//	@Description	Change the email of a user to the address the token was mailed to, the token can be used once
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			verification	body		domain.EmailVerificationRequest	true	"token mailed to the new email"
+//	@Success		200				{object}	domain.Success					"email changed"
+//	@Failure		400				{object}	domain.Error					"Bad Request"
+//	@Failure		409				{object}	domain.Error					"Email Taken"
+//	@Failure		500				{object}	domain.Error					"Internal Server Error"
+//	@Router			/auth/email-verification [post]
+func (h *HttpAccountHandler) VerifyEmail(c *fiber.Ctx) error {
+	verificationReq := utilities.ExtractStructFromValidator[domain.EmailVerificationRequest](c)
+
+	if err := h.accountSvc.VerifyEmail(c.UserContext(), verificationReq); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(domain.Success{
+		Code:    fiber.StatusOK,
+		Message: "email changed successfully",
+	})
+}
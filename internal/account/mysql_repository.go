@@ -0,0 +1,80 @@
+package account
+
+import (
+	"book-store/internal/domain"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type mysqlEmailVerificationRepository struct {
+	db *gorm.DB
+}
+
+// Store
+func (m *mysqlEmailVerificationRepository) Store(ctx context.Context, verification *domain.EmailVerification) error {
+	return m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := revoke(tx, verification.UserId, time.Now()); err != nil {
+			return err
+		}
+
+		return tx.Create(verification).Error
+	})
+}
+
+// GetPending
+func (m *mysqlEmailVerificationRepository) GetPending(ctx context.Context, userId uint, now time.Time) (*domain.EmailVerification, error) {
+	var verification *domain.EmailVerification
+
+	if err := m.db.WithContext(ctx).
+		Where("user_id = ? AND used_at IS NULL AND expires_at > ?", userId, now).
+		Order("id DESC").
+		First(&verification).Error; err != nil {
+		return nil, err
+	}
+
+	return verification, nil
+}
+
+// Redeem locks the verification so it can't be used twice at once
+func (m *mysqlEmailVerificationRepository) Redeem(ctx context.Context, tokenHash string, now time.Time) (*domain.EmailVerification, error) {
+	var verification domain.EmailVerification
+
+	err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", tokenHash, now).
+			First(&verification).Error; err != nil {
+			return err
+		}
+
+		result := tx.Model(&domain.User{Model: gorm.Model{ID: verification.UserId}}).Update("email", verification.Email)
+		if result.Error != nil {
+			return result.Error
+		}
+		// the user was deleted since the verification was mailed
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		return revoke(tx, verification.UserId, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	verification.UsedAt = &now
+	return &verification, nil
+}
+
+// revoke uses up the verifications of the user still unused
+func revoke(tx *gorm.DB, userId uint, now time.Time) error {
+	return tx.Model(&domain.EmailVerification{}).
+		Where("user_id = ? AND used_at IS NULL", userId).
+		Update("used_at", now).Error
+}
+
+func NewMysqlEmailVerificationRepository(db *gorm.DB) domain.EmailVerificationRepository {
+	return &mysqlEmailVerificationRepository{db: db}
+}
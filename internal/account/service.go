@@ -0,0 +1,189 @@
+package account
+
+import (
+	"book-store/internal/config"
+	"book-store/internal/domain"
+	"book-store/internal/tracing"
+	"book-store/internal/utilities"
+	"book-store/pkg/xlogger"
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type accountService struct {
+	userRepo         domain.UserRepository
+	verificationRepo domain.EmailVerificationRepository
+	transactionRepo  domain.TransactionRepository
+	passwordSvc      domain.PasswordService
+	mailer           domain.Mailer
+	cfg              config.Account
+}
+
+// Get
+func (a *accountService) Get(ctx context.Context, userId uint) (*domain.Account, error) {
+	ctx, span := tracing.Start(ctx, "AccountService.Get")
+	defer span.End()
+
+	user, err := a.user(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	account := &domain.Account{User: user}
+	verification, err := a.verificationRepo.GetPending(ctx, userId, time.Now())
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if verification != nil {
+		account.PendingEmail = &verification.Email
+	}
+
+	return account, nil
+}
+
+// Update
+func (a *accountService) Update(ctx context.Context, userId uint, req *domain.AccountUpdateRequest) (*domain.Account, error) {
+	ctx, span := tracing.Start(ctx, "AccountService.Update")
+	defer span.End()
+
+	user, err := a.user(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	// checked before anything changes, a wrong password leaves the name as it was too
+	if req.Email != "" {
+		if err := a.passwordSvc.VerifyCurrent(ctx, user, req.CurrentPassword); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.Name != "" && req.Name != user.Name {
+		if err := a.userRepo.Update(ctx, &domain.User{Model: gorm.Model{ID: userId}, Name: req.Name}); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.Email != "" && !strings.EqualFold(req.Email, user.Email) {
+		if err := a.requestEmailChange(ctx, user, req.Email); err != nil {
+			return nil, err
+		}
+	}
+
+	return a.Get(ctx, userId)
+}
+
+// VerifyEmail
+func (a *accountService) VerifyEmail(ctx context.Context, req *domain.EmailVerificationRequest) error {
+	ctx, span := tracing.Start(ctx, "AccountService.VerifyEmail")
+	defer span.End()
+
+	verification, err := a.verificationRepo.Redeem(ctx, utilities.HashToken(req.Token), time.Now())
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrInvalidEmailVerification
+		}
+		// someone else took the email since the verification was mailed
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return domain.ErrEmailTaken
+		}
+		return err
+	}
+
+	xlogger.Ctx(ctx).Info().Uint("user_id", verification.UserId).Msg("email changed")
+	return nil
+}
+
+// FetchTransactions returns the transactions the user recorded
+func (a *accountService) FetchTransactions(ctx context.Context, userId uint, page int, size int) ([]*domain.Transaction, int, error) {
+	ctx, span := tracing.Start(ctx, "AccountService.FetchTransactions")
+	defer span.End()
+
+	if _, err := a.user(ctx, userId); err != nil {
+		return nil, 0, err
+	}
+
+	return a.transactionRepo.Fetch(ctx, page, size, &domain.Transaction{UserId: userId})
+}
+
+// CountTransactions
+func (a *accountService) CountTransactions(ctx context.Context, userId uint) (int64, error) {
+	ctx, span := tracing.Start(ctx, "AccountService.CountTransactions")
+	defer span.End()
+
+	return a.transactionRepo.Count(ctx, &domain.Transaction{UserId: userId})
+}
+
+// requestEmailChange mails a token to the new address and lets the current one know about it
+func (a *accountService) requestEmailChange(ctx context.Context, user *domain.User, email string) error {
+	taken, err := a.userRepo.GetByEmail(ctx, email)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if taken != nil && taken.ID != user.ID {
+		return domain.ErrEmailTaken
+	}
+
+	token, err := utilities.NewToken()
+	if err != nil {
+		return err
+	}
+
+	verification := &domain.EmailVerification{
+		UserId:    user.ID,
+		Email:     email,
+		TokenHash: utilities.HashToken(token),
+		ExpiresAt: time.Now().Add(a.cfg.EmailVerificationTTL),
+	}
+	if err := a.verificationRepo.Store(ctx, verification); err != nil {
+		return err
+	}
+
+	if err := a.mailer.Send(ctx, &domain.Mail{
+		To:      email,
+		Subject: "Verify your new email address",
+		Body: "Confirm this address for your Book Store account by sending the token below to POST /api/auth/email-verification " +
+			"before " + verification.ExpiresAt.Format(time.RFC1123) + ".\n\n" + token,
+	}); err != nil {
+		return err
+	}
+
+	if err := a.mailer.Send(ctx, &domain.Mail{
+		To:      user.Email,
+		Subject: "Your email address is being changed",
+		Body:    "The email address of your Book Store account is being changed to " + email + ". If this wasn't you, ask an admin to reset your password.",
+	}); err != nil {
+		return err
+	}
+
+	xlogger.Ctx(ctx).Info().Uint("user_id", user.ID).Msg("email change requested")
+	return nil
+}
+
+// user is the user of the token, ErrUserNotFound once deleted
+func (a *accountService) user(ctx context.Context, userId uint) (*domain.User, error) {
+	user, err := a.userRepo.GetById(ctx, userId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func NewAccountService(userRepo domain.UserRepository, verificationRepo domain.EmailVerificationRepository, transactionRepo domain.TransactionRepository, passwordSvc domain.PasswordService, mailer domain.Mailer, cfg config.Account) domain.AccountService {
+	return &accountService{
+		userRepo:         userRepo,
+		verificationRepo: verificationRepo,
+		transactionRepo:  transactionRepo,
+		passwordSvc:      passwordSvc,
+		mailer:           mailer,
+		cfg:              cfg,
+	}
+}
@@ -0,0 +1,283 @@
+package audit
+
+import (
+	"book-store/internal/domain"
+	"book-store/pkg/xlogger"
+	"encoding/json"
+	"errors"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const beforeKey = "audit:before"
+
+// audited are the tables whose changes are recorded, the job queue and counters are left out
+var audited = map[string]bool{
+	"books":               true,
+	"customers":           true,
+	"users":               true,
+	"roles":               true,
+	"transactions":        true,
+	"transaction_details": true,
+	"payments":            true,
+	"loyalty_ledgers":     true,
+}
+
+// ignored columns change on every write and would make every update look like a change
+var ignored = map[string]bool{
+	"updated_at": true,
+}
+
+type row = map[string]any
+
+// GormPlugin writes an audit log per changed row in the transaction of the change, so a change that
+// can't be audited is rolled back. Rows are read before and after updates and deletes to diff them
+type GormPlugin struct{}
+
+func (GormPlugin) Name() string {
+	return "audit"
+}
+
+func (GormPlugin) Initialize(db *gorm.DB) error {
+	callback := db.Callback()
+	return errors.Join(
+		callback.Create().Before("gorm:commit_or_rollback_transaction").Register("audit:after_create", afterCreate),
+		callback.Update().Before("gorm:update").Register("audit:before_update", snapshot),
+		callback.Update().Before("gorm:commit_or_rollback_transaction").Register("audit:after_update", after(domain.AuditActionUpdate)),
+		callback.Delete().Before("gorm:delete").Register("audit:before_delete", snapshot),
+		callback.Delete().Before("gorm:commit_or_rollback_transaction").Register("audit:after_delete", after(domain.AuditActionDelete)),
+	)
+}
+
+func isAudited(db *gorm.DB) bool {
+	return db.Error == nil && !db.DryRun && db.Statement.Schema != nil && audited[db.Statement.Table]
+}
+
+func afterCreate(db *gorm.DB) {
+	if !isAudited(db) || db.Statement.RowsAffected == 0 {
+		return
+	}
+
+	stmt := db.Statement
+	var created []row
+	switch value := reflect.Indirect(stmt.ReflectValue); value.Kind() {
+	case reflect.Struct:
+		created = append(created, fieldsOf(db, value))
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			created = append(created, fieldsOf(db, reflect.Indirect(value.Index(i))))
+		}
+	}
+
+	logs := make([]*domain.AuditLog, 0, len(created))
+	for _, values := range created {
+		if log := newLog(db, domain.AuditActionCreate, values, nil, values); log != nil {
+			logs = append(logs, log)
+		}
+	}
+	save(db, logs)
+}
+
+// snapshot reads the rows the statement is about to change
+func snapshot(db *gorm.DB) {
+	if !isAudited(db) {
+		return
+	}
+
+	stmt := db.Statement
+	query := newQuery(db, stmt.Unscoped)
+
+	where, conditioned := stmt.Clauses["WHERE"].Expression.(clause.Where)
+	if conditioned {
+		query = query.Clauses(where)
+	}
+
+	// gorm only adds the primary key of the model to the conditions when it runs the statement
+	if value := reflect.Indirect(stmt.ReflectValue); value.Kind() == reflect.Struct {
+		for _, field := range stmt.Schema.PrimaryFields {
+			if key, zero := field.ValueOf(stmt.Context, value); !zero {
+				query = query.Where(clause.Eq{Column: clause.Column{Table: stmt.Table, Name: field.DBName}, Value: key})
+				conditioned = true
+			}
+		}
+	}
+
+	// gorm refuses updates and deletes without conditions
+	if !conditioned {
+		return
+	}
+
+	var rows []row
+	if err := query.Find(&rows).Error; err != nil {
+		db.AddError(err)
+		return
+	}
+	db.InstanceSet(beforeKey, rows)
+}
+
+// after reads the changed rows again and logs what changed, rows gone for good were purged and rows
+// that got their deleted_at cleared were restored
+func after(action domain.AuditAction) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.InstanceGet(beforeKey)
+		if !ok || !isAudited(db) || db.Statement.RowsAffected == 0 {
+			return
+		}
+		before := value.([]row)
+		if len(before) == 0 {
+			return
+		}
+
+		primaryKey := db.Statement.Schema.PrioritizedPrimaryField.DBName
+		ids := make([]any, 0, len(before))
+		for _, values := range before {
+			ids = append(ids, values[primaryKey])
+		}
+
+		var rows []row
+		if err := newQuery(db, true).Where(clause.IN{Column: clause.Column{Table: db.Statement.Table, Name: primaryKey}, Values: ids}).Find(&rows).Error; err != nil {
+			db.AddError(err)
+			return
+		}
+		afterById := make(map[uint]row, len(rows))
+		for _, values := range rows {
+			afterById[toUint(values[primaryKey])] = values
+		}
+
+		logs := make([]*domain.AuditLog, 0, len(before))
+		for _, values := range before {
+			changed, exists := afterById[toUint(values[primaryKey])]
+
+			rowAction := action
+			switch {
+			case !exists:
+				rowAction = domain.AuditActionPurge
+			case values["deleted_at"] != nil && changed["deleted_at"] == nil:
+				rowAction = domain.AuditActionRestore
+			}
+
+			if log := newLog(db, rowAction, values, values, changed); log != nil {
+				logs = append(logs, log)
+			}
+		}
+		save(db, logs)
+	}
+}
+
+// newQuery reads the table of the statement in its transaction, bypassing the soft delete scope if unscoped
+func newQuery(db *gorm.DB, unscoped bool) *gorm.DB {
+	query := db.Session(&gorm.Session{NewDB: true}).Model(reflect.New(db.Statement.Schema.ModelType).Interface())
+	if unscoped {
+		query = query.Unscoped()
+	}
+	return query
+}
+
+// fieldsOf reads the columns of a model the way they are stored
+func fieldsOf(db *gorm.DB, value reflect.Value) row {
+	values := make(row, len(db.Statement.Schema.DBNames))
+	for _, name := range db.Statement.Schema.DBNames {
+		field := db.Statement.Schema.FieldsByDBName[name]
+		fieldValue, _ := field.ValueOf(db.Statement.Context, value)
+		values[name] = fieldValue
+	}
+	return values
+}
+
+// newLog keeps only the columns that differ, nil when nothing did
+func newLog(db *gorm.DB, action domain.AuditAction, key row, before row, after row) *domain.AuditLog {
+	changedBefore, changedAfter := make(row), make(row)
+	for name := range union(before, after) {
+		if ignored[name] {
+			continue
+		}
+
+		oldValue, hadOld := before[name]
+		newValue, hasNew := after[name]
+		if hadOld && hasNew && reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+
+		if hadOld {
+			changedBefore[name] = mask(name, oldValue)
+		}
+		if hasNew {
+			changedAfter[name] = mask(name, newValue)
+		}
+	}
+	if len(changedBefore) == 0 && len(changedAfter) == 0 {
+		return nil
+	}
+
+	ctx := db.Statement.Context
+	var actorId *uint
+	if userId, ok := domain.UserIdFrom(ctx); ok {
+		actorId = &userId
+	}
+
+	return &domain.AuditLog{
+		EntityType: db.Statement.Table,
+		EntityId:   toUint(key[db.Statement.Schema.PrioritizedPrimaryField.DBName]),
+		Action:     action,
+		ActorId:    actorId,
+		Actor:      domain.ActorFrom(ctx),
+		RequestId:  domain.RequestIdFrom(ctx),
+		Before:     encode(changedBefore),
+		After:      encode(changedAfter),
+	}
+}
+
+func save(db *gorm.DB, logs []*domain.AuditLog) {
+	if len(logs) == 0 {
+		return
+	}
+
+	if err := db.Session(&gorm.Session{NewDB: true}).Create(&logs).Error; err != nil {
+		db.AddError(err)
+	}
+}
+
+// mask keeps secrets such as password hashes out of the log, only the fact they changed is recorded
+func mask(name string, value any) any {
+	if xlogger.IsSensitive(name) {
+		return xlogger.Redacted
+	}
+	return value
+}
+
+func union(a row, b row) row {
+	all := make(row, len(a)+len(b))
+	for name := range a {
+		all[name] = nil
+	}
+	for name := range b {
+		all[name] = nil
+	}
+	return all
+}
+
+// encode is null for a side with no columns, e.g. before of a create
+func encode(values row) json.RawMessage {
+	if len(values) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(values)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+func toUint(value any) uint {
+	v := reflect.Indirect(reflect.ValueOf(value))
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return uint(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return uint(v.Uint())
+	}
+	return 0
+}
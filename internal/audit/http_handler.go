@@ -0,0 +1,142 @@
+package audit
+
+import (
+	"book-store/internal/domain"
+	"book-store/internal/middleware/jwt"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type HttpAuditHandler struct {
+	auditSvc       domain.AuditService
+	authMiddleware jwt.AuthMiddleware
+}
+
+func NewHttpHandler(r fiber.Router, auditSvc domain.AuditService, authMiddleware jwt.AuthMiddleware) {
+	handler := &HttpAuditHandler{
+		auditSvc:       auditSvc,
+		authMiddleware: authMiddleware,
+	}
+
+	r.Get("/", authMiddleware.RequireRole("admin"), handler.Fetch)
+}
+
+// Fetch used to get the audit log
+//
+//	@Summary		Get audit log
+//	@Description	Get the changes made to books, customers, users, roles, transactions and their details, newest first
+//	@Tags			audit
+//	@Accept			json
+//	@Produce		json
+//	@Param			page		query		int				false	"Page number (default 1)"
+//	@Param			size		query		int				false	"Size of page (default 10)"
+//	@Param			entity_type	query		string			false	"Table of the changed entity, e.g. books"
+//	@Param			entity_id	query		int				false	"ID of the changed entity"
+//	@Param			action		query		string			false	"Action (create, update, delete, restore, purge)"
+//	@Param			actor_id	query		int				false	"ID of the user who made the change"
+//	@Param			actor		query		string			false	"Name of the user who made the change, system for background jobs"
+//	@Param			request_id	query		string			false	"Request ID of the change"
+//	@Param			from		query		string			false	"Changes at or after, RFC 3339"
+//	@Param			to			query		string			false	"Changes before, RFC 3339"
+//	@Header			200			{string}	X-Cursor		"Next page"
+//	@Header			200			{string}	X-Total-Count	"Total item"
+//	@Header			200			{string}	X-Max-Page		"Max page"
+//	@Success		200			{array}		domain.Success	"List of audit logs"
+//	@Failure		400			{object}	domain.Error	"Bad Request"
+//	@Failure		404			{object}	domain.Error	"Not Found"
+//	@Failure		500			{object}	domain.Error	"Internal Server Error"
+//	@Router			/audit [get]
+//
+// @Security Bearer
+func (h *HttpAuditHandler) Fetch(c *fiber.Ctx) error {
+	page, size := c.QueryInt("page", 1), c.QueryInt("size", 10)
+	if page <= 0 {
+		return domain.NewValidationError("page must be a positive integer")
+	}
+	if size <= 0 {
+		return domain.NewValidationError("size must be a positive integer")
+	}
+
+	filter, err := parseFilter(c)
+	if err != nil {
+		return err
+	}
+
+	logs, nextPage, err := h.auditSvc.Fetch(c.UserContext(), page, size, filter)
+	if err != nil {
+		return err
+	}
+
+	if logs == nil {
+		return domain.NewNotFoundError("audit logs")
+	}
+
+	totalItem, err := h.auditSvc.Count(c.UserContext(), filter)
+	if err != nil {
+		return err
+	}
+
+	maxPage := int(totalItem) / size
+
+	if nextPage > 0 && nextPage <= maxPage {
+		c.Set("X-Cursor", strconv.Itoa(nextPage))
+	}
+	c.Set("X-Total-Count", strconv.Itoa(int(totalItem)))
+	c.Set("X-Max-Page", strconv.Itoa(maxPage))
+	return c.Status(fiber.StatusOK).JSON(domain.Success{
+		Code:    fiber.StatusOK,
+		Message: "success",
+		Data:    logs,
+	})
+}
+
+func parseFilter(c *fiber.Ctx) (*domain.AuditLogFilter, error) {
+	filter := &domain.AuditLogFilter{
+		EntityType: c.Query("entity_type"),
+		Action:     domain.AuditAction(c.Query("action")),
+		Actor:      c.Query("actor"),
+		RequestId:  c.Query("request_id"),
+	}
+
+	switch filter.Action {
+	case "", domain.AuditActionCreate, domain.AuditActionUpdate, domain.AuditActionDelete, domain.AuditActionRestore, domain.AuditActionPurge:
+	default:
+		return nil, domain.NewValidationError("action should be create, update, delete, restore or purge")
+	}
+
+	if value := c.Query("entity_id"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 0)
+		if err != nil {
+			return nil, domain.NewValidationError("entity_id must be a positive integer")
+		}
+		filter.EntityId = uint(id)
+	}
+
+	if value := c.Query("actor_id"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 0)
+		if err != nil {
+			return nil, domain.NewValidationError("actor_id must be a positive integer")
+		}
+		filter.ActorId = uint(id)
+	}
+
+	if value := c.Query("from"); value != "" {
+		from, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, domain.NewValidationError("invalid from format, should be RFC 3339")
+		}
+		filter.From = from
+	}
+
+	if value := c.Query("to"); value != "" {
+		to, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, domain.NewValidationError("invalid to format, should be RFC 3339")
+		}
+		filter.To = to
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,82 @@
+package audit
+
+import (
+	"book-store/internal/domain"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type mysqlAuditRepository struct {
+	db *gorm.DB
+}
+
+// Count
+func (m *mysqlAuditRepository) Count(ctx context.Context, filter *domain.AuditLogFilter) (int64, error) {
+	var count int64
+
+	if err := applyFilter(m.db.WithContext(ctx).Model(&domain.AuditLog{}), filter).Count(&count).Error; err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// Fetch
+func (m *mysqlAuditRepository) Fetch(ctx context.Context, page int, size int, filter *domain.AuditLogFilter) ([]*domain.AuditLog, int, error) {
+	var logs []*domain.AuditLog
+
+	offset := (page - 1) * size
+
+	if err := applyFilter(m.db.WithContext(ctx), filter).Order("created_at DESC, id DESC").Offset(offset).Limit(size).Find(&logs).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var nextCursor int
+	if len(logs) > 0 {
+		nextCursor = page + 1 // Next page
+	}
+
+	return logs, nextCursor, nil
+}
+
+// applyFilter can filter by entity/action/actor id/actor/request id/time range
+func applyFilter(query *gorm.DB, filter *domain.AuditLogFilter) *gorm.DB {
+	if filter.EntityType != "" {
+		query = query.Where("entity_type = ?", filter.EntityType)
+	}
+
+	if filter.EntityId > 0 {
+		query = query.Where("entity_id = ?", filter.EntityId)
+	}
+
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+
+	if filter.ActorId > 0 {
+		query = query.Where("actor_id = ?", filter.ActorId)
+	}
+
+	if filter.Actor != "" {
+		query = query.Where("actor = ?", filter.Actor)
+	}
+
+	if filter.RequestId != "" {
+		query = query.Where("request_id = ?", filter.RequestId)
+	}
+
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+
+	if !filter.To.IsZero() {
+		query = query.Where("created_at < ?", filter.To)
+	}
+
+	return query
+}
+
+func NewMysqlAuditRepository(db *gorm.DB) domain.AuditRepository {
+	return &mysqlAuditRepository{db: db}
+}
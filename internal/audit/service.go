@@ -0,0 +1,42 @@
+package audit
+
+import (
+	"book-store/internal/domain"
+	"book-store/internal/tracing"
+	"context"
+)
+
+type auditService struct {
+	auditRepo domain.AuditRepository
+}
+
+// Count
+func (a *auditService) Count(ctx context.Context, filter *domain.AuditLogFilter) (int64, error) {
+	ctx, span := tracing.Start(ctx, "AuditService.Count")
+	defer span.End()
+
+	count, err := a.auditRepo.Count(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// Fetch
+func (a *auditService) Fetch(ctx context.Context, page int, size int, filter *domain.AuditLogFilter) ([]*domain.AuditLog, int, error) {
+	ctx, span := tracing.Start(ctx, "AuditService.Fetch")
+	defer span.End()
+
+	logs, nextCursor, err := a.auditRepo.Fetch(ctx, page, size, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return logs, nextCursor, nil
+}
+
+func NewAuditService(auditRepo domain.AuditRepository) domain.AuditService {
+	return &auditService{
+		auditRepo: auditRepo,
+	}
+}
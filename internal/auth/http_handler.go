@@ -1,54 +1,66 @@
 package auth
 
 import (
-	"errors"
 	"book-store/internal/domain"
+	"book-store/internal/middleware/jwt"
 	"book-store/internal/middleware/validation"
 	"book-store/internal/utilities"
+	"fmt"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"golang.org/x/crypto/bcrypt"
 )
 
+// keySetMaxAge is how long verifiers may cache the keys
+const keySetMaxAge = 5 * time.Minute
+
 type HttpAuthHandler struct {
-	authSvc domain.AuthService
+	authSvc        domain.AuthService
+	authMiddleware jwt.AuthMiddleware
 }
 
-func NewHttpHandler(r fiber.Router, authSvc domain.AuthService) {
-	handler := &HttpAuthHandler{authSvc: authSvc}
+func NewHttpHandler(r fiber.Router, authSvc domain.AuthService, authMiddleware jwt.AuthMiddleware) {
+	handler := &HttpAuthHandler{authSvc: authSvc, authMiddleware: authMiddleware}
 
 	r.Post("/token", validation.New[domain.AuthRequest](), handler.GetToken)
+	r.Post("/token/2fa", validation.New[domain.TwoFactorAuthRequest](), handler.GetTwoFactorToken)
+}
+
+// NewLockoutHttpHandler serves the login lockouts of users under /users
+func NewLockoutHttpHandler(r fiber.Router, authSvc domain.AuthService, authMiddleware jwt.AuthMiddleware) {
+	handler := &HttpAuthHandler{authSvc: authSvc, authMiddleware: authMiddleware}
+
+	r.Get("/:id/lockout", authMiddleware.RequireRole("admin"), handler.GetLockout)
+	r.Post("/:id/unlock", authMiddleware.RequireRole("admin"), handler.Unlock)
+}
+
+// NewKeySetHttpHandler serves the keys tokens are verified with at /.well-known/jwks.json, it needs no token
+func NewKeySetHttpHandler(r fiber.Router, authSvc domain.AuthService) {
+	handler := &HttpAuthHandler{authSvc: authSvc}
+
+	r.Get("/.well-known/jwks.json", handler.GetKeySet)
 }
 
 // GetToken used to get JWT Token
 //
 //	@Summary		Get JWT Token
-//	@Description	Get JWT Token
+//	@Description	Get JWT Token. Users with two-factor authentication get a challenge to exchange for the token at /auth/token/2fa instead. Repeated failures for an email or from an ip are slowed down and then locked out for a while
 //	@Tags			auth
 //	@Accept			json
 //	@Produce		json
 //	@Param			auth	body		domain.AuthRequest	true	"user credential"
 //	@Success		200	{object}	domain.Success	"token detail"
 //	@Failure		400	{object}	domain.Error	"Bad Request"
-//	@Failure		404	{object}	domain.Error	"Not Found"
+//	@Failure		401	{object}	domain.Error	"Unauthorized"
+//	@Failure		429	{object}	domain.Error	"Too Many Failed Logins"
 //	@Failure		500	{object}	domain.Error	"Internal Server Error"
 //	@Router			/auth/token [post]
 func (h *HttpAuthHandler) GetToken(c *fiber.Ctx) error {
 	authReq := utilities.ExtractStructFromValidator[domain.AuthRequest](c)
 
-	token, err := h.authSvc.GetToken(authReq)
+	token, err := h.authSvc.GetToken(c.UserContext(), authReq, c.IP())
 	if err != nil {
-		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
-			return c.Status(fiber.StatusUnauthorized).JSON(domain.Error{
-				Code:    fiber.StatusUnauthorized,
-				Message: "invalid email or password",
-			})
-		}
-
-		return c.Status(fiber.StatusInternalServerError).JSON(domain.Error{
-			Code:    fiber.StatusInternalServerError,
-			Message: err.Error(),
-		})
+		return err
 	}
 
 	return c.Status(fiber.StatusOK).JSON(domain.Success{
@@ -57,3 +69,105 @@ func (h *HttpAuthHandler) GetToken(c *fiber.Ctx) error {
 		Data:    token,
 	})
 }
+
+// GetTwoFactorToken used to get JWT Token with a two-factor code
+//
+//	@Summary		Get JWT Token with two-factor code
+//	@Description	Exchange the challenge /auth/token answered with and a code of the authenticator app, or an unused recovery code, for the token. Failed codes count as failed logins
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			auth	body		domain.TwoFactorAuthRequest	true	"challenge and code"
+//	@Success		200		{object}	domain.Success				"token detail"
+//	@Failure		400		{object}	domain.Error				"Bad Request"
+//	@Failure		401		{object}	domain.Error				"Unauthorized"
+//	@Failure		429		{object}	domain.Error				"Too Many Failed Logins"
+//	@Failure		500		{object}	domain.Error				"Internal Server Error"
+//	@Router			/auth/token/2fa [post]
+func (h *HttpAuthHandler) GetTwoFactorToken(c *fiber.Ctx) error {
+	authReq := utilities.ExtractStructFromValidator[domain.TwoFactorAuthRequest](c)
+
+	token, err := h.authSvc.GetTwoFactorToken(c.UserContext(), authReq, c.IP())
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(domain.Success{
+		Code:    fiber.StatusOK,
+		Message: "success",
+		Data:    token,
+	})
+}
+
+// GetLockout used to get the failed logins of a user
+//
+//	@Summary		Get login lockout of user
+//	@Description	Get the recent failed logins of a user and until when they are locked out
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		int				true	"user ID"
+//	@Success		200	{object}	domain.Success	"login lockout"
+//	@Failure		400	{object}	domain.Error	"Bad Request"
+//	@Failure		404	{object}	domain.Error	"Not Found"
+//	@Failure		500	{object}	domain.Error	"Internal Server Error"
+//	@Router			/users/{id}/lockout [get]
+//
+// @Security Bearer
+func (h *HttpAuthHandler) GetLockout(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return domain.NewValidationError("invalid user id")
+	}
+
+	lockout, err := h.authSvc.GetLockout(c.UserContext(), uint(id))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(domain.Success{
+		Code:    fiber.StatusOK,
+		Message: "success",
+		Data:    lockout,
+	})
+}
+
+// Unlock used to lift the login lockout of a user
+//
+//	@Summary		Unlock user
+//	@Description	Clear the failed logins of a user so they can log in again right away
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		int				true	"user ID"
+//	@Success		200	{object}	domain.Success	"user unlocked"
+//	@Failure		400	{object}	domain.Error	"Bad Request"
+//	@Failure		404	{object}	domain.Error	"Not Found"
+//	@Failure		500	{object}	domain.Error	"Internal Server Error"
+//	@Router			/users/{id}/unlock [post]
+//
+// @Security Bearer
+func (h *HttpAuthHandler) Unlock(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return domain.NewValidationError("invalid user id")
+	}
+
+	if err := h.authSvc.Unlock(c.UserContext(), uint(id)); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(domain.Success{
+		Code:    fiber.StatusOK,
+		Message: "user unlocked successfully",
+	})
+}
+
+// GetKeySet used by other services to verify tokens, answered as a bare JWK Set (RFC 7517) as they expect it.
+// It lives outside /api so it is left out of the swagger docs
+func (h *HttpAuthHandler) GetKeySet(c *fiber.Ctx) error {
+	// verifiers cache the keys, a new key is listed in JWT_VERIFICATION_KEYS for longer than this before it signs
+	c.Set(fiber.HeaderCacheControl, fmt.Sprintf("public, max-age=%d", int(keySetMaxAge.Seconds())))
+
+	return c.Status(fiber.StatusOK).JSON(h.authSvc.GetKeySet(c.UserContext()))
+}
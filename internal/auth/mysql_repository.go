@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"book-store/internal/domain"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type mysqlLoginThrottleRepository struct {
+	db *gorm.DB
+}
+
+// Get
+func (m *mysqlLoginThrottleRepository) Get(ctx context.Context, key string) (*domain.LoginThrottle, error) {
+	var throttle *domain.LoginThrottle
+
+	if err := m.db.WithContext(ctx).Where(clause.Eq{Column: "key", Value: key}).First(&throttle).Error; err != nil {
+		return nil, err
+	}
+
+	return throttle, nil
+}
+
+// Update serializes concurrent failures of the same key on the row lock so none is lost
+func (m *mysqlLoginThrottleRepository) Update(ctx context.Context, key string, fn func(throttle *domain.LoginThrottle)) (*domain.LoginThrottle, error) {
+	var throttle domain.LoginThrottle
+
+	err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&domain.LoginThrottle{Key: key}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where(clause.Eq{Column: "key", Value: key}).First(&throttle).Error; err != nil {
+			return err
+		}
+
+		fn(&throttle)
+		return tx.Save(&throttle).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &throttle, nil
+}
+
+// Delete
+func (m *mysqlLoginThrottleRepository) Delete(ctx context.Context, key string) error {
+	return m.db.WithContext(ctx).Where(clause.Eq{Column: "key", Value: key}).Delete(&domain.LoginThrottle{}).Error
+}
+
+// Purge
+func (m *mysqlLoginThrottleRepository) Purge(ctx context.Context, lastFailureBefore time.Time, now time.Time) (int64, error) {
+	result := m.db.WithContext(ctx).
+		Where("last_failure_at IS NULL OR last_failure_at < ?", lastFailureBefore).
+		Where("locked_until IS NULL OR locked_until < ?", now).
+		Delete(&domain.LoginThrottle{})
+	return result.RowsAffected, result.Error
+}
+
+func NewMysqlLoginThrottleRepository(db *gorm.DB) domain.LoginThrottleRepository {
+	return &mysqlLoginThrottleRepository{db: db}
+}
@@ -1,45 +1,294 @@
 package auth
 
 import (
-	"errors"
+	"book-store/internal/config"
 	"book-store/internal/domain"
+	"book-store/internal/metrics"
+	"book-store/internal/tracing"
 	"book-store/internal/utilities"
+	"book-store/pkg/xlogger"
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
 )
 
 type authService struct {
-	userRepo   domain.UserRepository
-	jwtService utilities.JwtTokenService
+	userRepo      domain.UserRepository
+	throttleRepo  domain.LoginThrottleRepository
+	twoFactorRepo domain.TwoFactorRepository
+	twoFactorSvc  domain.TwoFactorService
+	jwtService    utilities.JwtTokenService
+	cfg           config.Login
+	twoFactorCfg  config.TwoFactor
+	// dummyHash is compared against for unknown emails so they take as long as a wrong password
+	dummyHash string
 }
 
 // GetToken
-func (a *authService) GetToken(userCredential *domain.AuthRequest) (domain.Token, error) {
-	user, err := a.userRepo.GetByEmail(userCredential.Email)
+func (a *authService) GetToken(ctx context.Context, userCredential *domain.AuthRequest, ip string) (domain.Token, error) {
+	ctx, span := tracing.Start(ctx, "AuthService.GetToken")
+	defer span.End()
+
+	accountKey, ipKey := domain.AccountThrottleKey(userCredential.Email), "ip:"+ip
+	if err := a.hold(ctx, accountKey, ipKey, ip); err != nil {
+		return domain.Token{}, err
+	}
+
+	user, err := a.userRepo.GetByEmail(ctx, userCredential.Email)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.Token{}, err
+	}
+
+	// Check password, against the dummy hash for unknown emails
+	hash, reason := a.dummyHash, "unknown_user"
+	if user != nil {
+		hash, reason = user.Password, "invalid_password"
+	}
+	isMatch, _ := utilities.ComparePassword(userCredential.Password, hash)
+	if !isMatch || user == nil {
+		metrics.FailedLogins.WithLabelValues(reason).Inc()
+		xlogger.Ctx(ctx).Warn().Str("ip", ip).Str("reason", reason).Msg("login failed")
+		if err := a.recordFailure(ctx, accountKey, ipKey); err != nil {
+			return domain.Token{}, err
+		}
+		return domain.Token{}, domain.ErrInvalidCredentials
+	}
+
+	// the failures of the email are only forgiven once the code checks out too
+	if user.TwoFactorEnabled {
+		return a.challenge(ctx, user)
+	}
+
+	return a.login(ctx, user, accountKey)
+}
+
+// GetTwoFactorToken
+func (a *authService) GetTwoFactorToken(ctx context.Context, req *domain.TwoFactorAuthRequest, ip string) (domain.Token, error) {
+	ctx, span := tracing.Start(ctx, "AuthService.GetTwoFactorToken")
+	defer span.End()
+
+	challenge, err := a.twoFactorRepo.AttemptChallenge(ctx, utilities.HashToken(req.Challenge), time.Now(), a.twoFactorCfg.MaxAttempts)
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.Token{}, domain.ErrInvalidTwoFactorChallenge
+		}
 		return domain.Token{}, err
 	}
 
-	// Check password
-	isMatch, err := utilities.ComparePassword(userCredential.Password, user.Password)
+	user, err := a.userRepo.GetById(ctx, challenge.UserId)
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.Token{}, domain.ErrInvalidTwoFactorChallenge
+		}
+		return domain.Token{}, err
+	}
+
+	accountKey, ipKey := domain.AccountThrottleKey(user.Email), "ip:"+ip
+	if err := a.hold(ctx, accountKey, ipKey, ip); err != nil {
+		return domain.Token{}, err
+	}
+
+	if err := a.twoFactorSvc.Verify(ctx, user.ID, req.Code); err != nil {
+		if !errors.Is(err, domain.ErrInvalidTwoFactorCode) {
+			return domain.Token{}, err
+		}
+
+		metrics.FailedLogins.WithLabelValues("invalid_two_factor_code").Inc()
+		xlogger.Ctx(ctx).Warn().Str("ip", ip).Str("reason", "invalid_two_factor_code").Msg("login failed")
+		if err := a.recordFailure(ctx, accountKey, ipKey); err != nil {
+			return domain.Token{}, err
+		}
+		return domain.Token{}, domain.ErrInvalidTwoFactorCode
+	}
+
+	if err := a.twoFactorRepo.CompleteChallenge(ctx, challenge.ID, time.Now()); err != nil {
 		return domain.Token{}, err
 	}
 
-	if !isMatch {
-		return domain.Token{}, errors.New("invalid password")
+	return a.login(ctx, user, accountKey)
+}
+
+// GetLockout
+func (a *authService) GetLockout(ctx context.Context, userId uint) (*domain.LoginThrottle, error) {
+	ctx, span := tracing.Start(ctx, "AuthService.GetLockout")
+	defer span.End()
+
+	user, err := a.userRepo.GetById(ctx, userId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	return a.throttle(ctx, domain.AccountThrottleKey(user.Email))
+}
+
+// Unlock clears the failed logins of the user, failures of the ips they came from still count
+func (a *authService) Unlock(ctx context.Context, userId uint) error {
+	ctx, span := tracing.Start(ctx, "AuthService.Unlock")
+	defer span.End()
+
+	user, err := a.userRepo.GetById(ctx, userId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrUserNotFound
+		}
+		return err
+	}
+
+	if err := a.throttleRepo.Delete(ctx, domain.AccountThrottleKey(user.Email)); err != nil {
+		return err
+	}
+
+	xlogger.Ctx(ctx).Info().Uint("user_id", user.ID).Msg("user unlocked")
+	return nil
+}
+
+// GetKeySet
+func (a *authService) GetKeySet(ctx context.Context) domain.JsonWebKeySet {
+	_, span := tracing.Start(ctx, "AuthService.GetKeySet")
+	defer span.End()
+
+	return a.jwtService.KeySet()
+}
+
+// hold refuses logins locked out for the email or the ip, and delays the ones that failed recently
+func (a *authService) hold(ctx context.Context, accountKey string, ipKey string, ip string) error {
+	account, err := a.throttle(ctx, accountKey)
+	if err != nil {
+		return err
+	}
+	client, err := a.throttle(ctx, ipKey)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if account.Locked(now) || client.Locked(now) {
+		metrics.FailedLogins.WithLabelValues("locked").Inc()
+		xlogger.Ctx(ctx).Warn().Str("ip", ip).Str("reason", "locked").Msg("login failed")
+		return domain.ErrLoginLocked
+	}
+
+	return a.wait(ctx, max(a.delay(account.Failures), a.delay(client.Failures)))
+}
+
+// challenge lets the user exchange a code for the token, its attempts are limited on top of the lockout
+func (a *authService) challenge(ctx context.Context, user *domain.User) (domain.Token, error) {
+	token, err := utilities.NewToken()
+	if err != nil {
+		return domain.Token{}, err
+	}
+
+	challenge := &domain.TwoFactorChallenge{
+		UserId:    user.ID,
+		TokenHash: utilities.HashToken(token),
+		ExpiresAt: time.Now().Add(a.twoFactorCfg.ChallengeTTL),
+	}
+	if err := a.twoFactorRepo.StoreChallenge(ctx, challenge); err != nil {
+		return domain.Token{}, err
+	}
+
+	xlogger.Ctx(ctx).Info().Uint("user_id", user.ID).Msg("two-factor challenge issued")
+	return domain.Token{TwoFactorRequired: true, Challenge: token, ChallengeExpiresAt: &challenge.ExpiresAt}, nil
+}
+
+// login forgives the failures of the email and issues the token
+func (a *authService) login(ctx context.Context, user *domain.User, accountKey string) (domain.Token, error) {
+	if err := a.throttleRepo.Delete(ctx, accountKey); err != nil {
+		return domain.Token{}, err
 	}
 
-	// Generate token
 	token, err := a.jwtService.GenerateToken(user)
 	if err != nil {
 		return domain.Token{}, err
 	}
 
+	xlogger.Ctx(ctx).Info().Uint("user_id", user.ID).Msg("user logged in")
 	return token, nil
 }
 
-func NewAuthService(userRepo domain.UserRepository, jwtService utilities.JwtTokenService) domain.AuthService {
+// throttle is a blank throttle for keys without failures
+func (a *authService) throttle(ctx context.Context, key string) (*domain.LoginThrottle, error) {
+	throttle, err := a.throttleRepo.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &domain.LoginThrottle{Key: key}, nil
+		}
+		return nil, err
+	}
+
+	return throttle, nil
+}
+
+// recordFailure counts the failure for the email and the ip, locking them out once they reach their limit
+func (a *authService) recordFailure(ctx context.Context, accountKey string, ipKey string) error {
+	limits := map[string]int{accountKey: a.cfg.MaxFailures, ipKey: a.cfg.IpMaxFailures}
+
+	for key, limit := range limits {
+		throttle, err := a.throttleRepo.Update(ctx, key, func(throttle *domain.LoginThrottle) {
+			throttle.Fail(time.Now(), a.cfg.FailureWindow, limit, a.cfg.Lockout)
+		})
+		if err != nil {
+			return err
+		}
+
+		// locked out throttles aren't updated, so this failure is the one locking it
+		if limit > 0 && throttle.Failures >= limit {
+			xlogger.Ctx(ctx).Warn().Str("key", key).Time("locked_until", *throttle.LockedUntil).Msg("login locked out")
+		}
+	}
+
+	return nil
+}
+
+// delay doubles the configured delay with every failure after the first
+func (a *authService) delay(failures int) time.Duration {
+	if failures == 0 {
+		return 0
+	}
+
+	delay := a.cfg.Delay
+	for i := 1; i < failures && delay < a.cfg.MaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, a.cfg.MaxDelay)
+}
+
+// wait sleeps for the delay unless the client gives up first
+func (a *authService) wait(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func NewAuthService(userRepo domain.UserRepository, throttleRepo domain.LoginThrottleRepository, twoFactorRepo domain.TwoFactorRepository, twoFactorSvc domain.TwoFactorService, jwtService utilities.JwtTokenService, cfg config.Login, twoFactorCfg config.TwoFactor) domain.AuthService {
+	dummyHash, err := utilities.HashPassword("dummy password of unknown emails")
+	if err != nil {
+		panic(err)
+	}
+
 	return &authService{
-		userRepo:   userRepo,
-		jwtService: jwtService,
+		userRepo:      userRepo,
+		throttleRepo:  throttleRepo,
+		twoFactorRepo: twoFactorRepo,
+		twoFactorSvc:  twoFactorSvc,
+		jwtService:    jwtService,
+		cfg:           cfg,
+		twoFactorCfg:  twoFactorCfg,
+		dummyHash:     dummyHash,
 	}
 }
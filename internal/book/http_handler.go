@@ -1,9 +1,9 @@
 package book
 
 import (
-	"errors"
 	"book-store/internal/domain"
 	"book-store/internal/middleware/jwt"
+	"book-store/internal/middleware/softdelete"
 	"book-store/internal/middleware/validation"
 	"book-store/internal/utilities"
 	"strconv"
@@ -24,11 +24,13 @@ func NewHttpHandler(r fiber.Router, bookService domain.BookService, authMiddlewa
 		authMiddleware: authMiddleware,
 	}
 
-	r.Get("/", handler.Fetch)
-	r.Get("/:id", handler.GetById)
+	r.Get("/", softdelete.New(authMiddleware), handler.Fetch)
+	r.Get("/export", authMiddleware.RequireRole("admin", "employee"), handler.Export)
+	r.Get("/:id", softdelete.New(authMiddleware), handler.GetById)
 	r.Post("/", authMiddleware.RequireRole("admin"), validation.New[domain.BookStoreRequest](), handler.Store)
 	r.Put("/:id", authMiddleware.RequireRole("admin"), validation.New[domain.BookUpdateRequest](), handler.Update)
 	r.Delete("/:id", authMiddleware.RequireRole("admin"), handler.Delete)
+	r.Post("/:id/restore", authMiddleware.RequireRole("admin"), handler.Restore)
 }
 
 // Fetch used to get list of book
@@ -42,6 +44,7 @@ func NewHttpHandler(r fiber.Router, bookService domain.BookService, authMiddlewa
 //	@Param			size	query		int				false	"Size of page (default 10)"
 //	@Param			q		query		string			false	"Search query"
 //	@Param			filterBy		query		string			false	"Filter by (title, author, price)"
+//	@Param			include_deleted	query		bool			false	"Include soft-deleted records, admin only"
 //	@Header			200		{string}	X-Cursor		"Next page"
 //	@Header			200		{string}	X-Total-Count	"Total item"
 //	@Header			200		{string}	X-Max-Page		"Max page"
@@ -52,58 +55,29 @@ func NewHttpHandler(r fiber.Router, bookService domain.BookService, authMiddlewa
 func (h *HttpBookHandler) Fetch(c *fiber.Ctx) error {
 	page, size, query, filterBy := c.QueryInt("page", 1), c.QueryInt("size", 10), c.Query("q"), c.Query("filterBy")
 	if page <= 0 {
-		return c.Status(fiber.StatusBadRequest).JSON(domain.Error{
-			Code:    fiber.StatusBadRequest,
-			Message: "page must be a positive integer",
-		})
+		return domain.NewValidationError("page must be a positive integer")
 	}
 	if size <= 0 {
-		return c.Status(fiber.StatusBadRequest).JSON(domain.Error{
-			Code:    fiber.StatusBadRequest,
-			Message: "size must be a positive integer",
-		})
+		return domain.NewValidationError("size must be a positive integer")
 	}
 
-	// read filterBy
-	var filter domain.Book
-
-	switch filterBy {
-	case "title":
-		filter.Title = query
-	case "author":
-		filter.Author = query
-	case "price":
-		price, err := strconv.Atoi(query)
-		if err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(domain.Error{
-				Code:    fiber.StatusBadRequest,
-				Message: "price must be an integer",
-			})
-		}
-		filter.Price = price
+	filter, err := parseFilter(query, filterBy)
+	if err != nil {
+		return err
 	}
 
-	books, nextPage, err := h.bookService.Fetch(page, size, &filter)
+	books, nextPage, err := h.bookService.Fetch(c.UserContext(), page, size, filter)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(domain.Error{
-			Code:    fiber.StatusInternalServerError,
-			Message: err.Error(),
-		})
+		return err
 	}
 
 	if books == nil {
-		return c.Status(fiber.StatusNotFound).JSON(domain.Error{
-			Code:    fiber.StatusNotFound,
-			Message: "books not found",
-		})
+		return domain.NewNotFoundError("books")
 	}
 
-	totalItem, err := h.bookService.Count(&filter)
+	totalItem, err := h.bookService.Count(c.UserContext(), filter)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(domain.Error{
-			Code:    fiber.StatusInternalServerError,
-			Message: err.Error(),
-		})
+		return err
 	}
 
 	maxPage := int(totalItem) / size
@@ -120,6 +94,38 @@ func (h *HttpBookHandler) Fetch(c *fiber.Ctx) error {
 	})
 }
 
+// Export used to download books as csv or xlsx
+//
+//	@Summary		Export books
+//	@Description	Stream the books matching the list filters as csv or xlsx
+//	@Tags			books
+//	@Produce		text/csv
+//	@Produce		application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+//	@Param			format		query		string			false	"Export format (csv, xlsx), default csv"
+//	@Param			q			query		string			false	"Search query"
+//	@Param			filterBy	query		string			false	"Filter by (title, author, price)"
+//	@Success		200			{file}		file			"books export"
+//	@Failure		400			{object}	domain.Error	"Bad Request"
+//	@Router			/books/export [get]
+//
+// @Security Bearer
+func (h *HttpBookHandler) Export(c *fiber.Ctx) error {
+	format, query, filterBy := domain.ExportFormat(c.Query("format", string(domain.ExportFormatCSV))), c.Query("q"), c.Query("filterBy")
+
+	filter, err := parseFilter(query, filterBy)
+	if err != nil {
+		return err
+	}
+
+	header := []string{"id", "title", "author", "isbn", "language", "pages", "price", "stock", "published_at", "created_at"}
+	ctx := c.UserContext()
+	return utilities.StreamExport(c, format, "books", header, func(write func(values ...any) error) error {
+		return h.bookService.Each(ctx, filter, func(book *domain.Book) error {
+			return write(book.ID, book.Title, book.Author, book.Isbn, book.Language, book.Pages, book.Price, book.Stock, book.PublishedAt, book.CreatedAt)
+		})
+	})
+}
+
 // GetByID used to get book by id
 //
 //	@Summary		Get book by id
@@ -128,6 +134,7 @@ func (h *HttpBookHandler) Fetch(c *fiber.Ctx) error {
 //	@Accept			json
 //	@Produce		json
 //	@Param			id	path		int				true	"book ID"
+//	@Param			include_deleted	query		bool			false	"Include soft-deleted records, admin only"
 //	@Success		200	{object}	domain.Success	"book detail"
 //	@Failure		400	{object}	domain.Error	"Bad Request"
 //	@Failure		404	{object}	domain.Error	"Not Found"
@@ -136,25 +143,12 @@ func (h *HttpBookHandler) Fetch(c *fiber.Ctx) error {
 func (h *HttpBookHandler) GetById(c *fiber.Ctx) error {
 	id, err := c.ParamsInt("id")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(domain.Error{
-			Code:    fiber.StatusBadRequest,
-			Message: err.Error(),
-		})
+		return domain.NewValidationError("invalid book id")
 	}
 
-	book, err := h.bookService.GetById(uint(id))
+	book, err := h.bookService.GetById(c.UserContext(), uint(id))
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(domain.Error{
-			Code:    fiber.StatusInternalServerError,
-			Message: err.Error(),
-		})
-	}
-
-	if book == nil {
-		return c.Status(fiber.StatusNotFound).JSON(domain.Error{
-			Code:    fiber.StatusNotFound,
-			Message: "book not found",
-		})
+		return err
 	}
 
 	return c.Status(fiber.StatusOK).JSON(domain.Success{
@@ -184,10 +178,7 @@ func (h *HttpBookHandler) Store(c *fiber.Ctx) error {
 	// Convert PublishedAt from string (dd-mm-yyyy) to time.Time
 	publishedAt, err := time.Parse("02-01-2006", bookReq.PublishedAt)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(domain.Error{
-			Code:    fiber.StatusBadRequest,
-			Message: "invalid published at format, should be dd-mm-yyyy",
-		})
+		return domain.NewValidationError("invalid published at format, should be dd-mm-yyyy")
 	}
 
 	book := &domain.Book{
@@ -202,11 +193,8 @@ func (h *HttpBookHandler) Store(c *fiber.Ctx) error {
 		PublishedAt: publishedAt,
 	}
 
-	if err := h.bookService.Store(book); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(domain.Error{
-			Code:    fiber.StatusInternalServerError,
-			Message: err.Error(),
-		})
+	if err := h.bookService.Store(c.UserContext(), book); err != nil {
+		return err
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(domain.Success{
@@ -235,10 +223,7 @@ func (h *HttpBookHandler) Store(c *fiber.Ctx) error {
 func (h *HttpBookHandler) Update(c *fiber.Ctx) error {
 	id, err := c.ParamsInt("id")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(domain.Error{
-			Code:    fiber.StatusBadRequest,
-			Message: "invalid book id",
-		})
+		return domain.NewValidationError("invalid book id")
 	}
 
 	bookReq := utilities.ExtractStructFromValidator[domain.BookUpdateRequest](c)
@@ -246,10 +231,7 @@ func (h *HttpBookHandler) Update(c *fiber.Ctx) error {
 	// Convert PublishedAt from string (dd-mm-yyyy) to time.Time
 	publishedAt, err := time.Parse("02-01-2006", bookReq.PublishedAt)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(domain.Error{
-			Code:    fiber.StatusBadRequest,
-			Message: "invalid published at format, should be dd-mm-yyyy",
-		})
+		return domain.NewValidationError("invalid published at format, should be dd-mm-yyyy")
 	}
 
 	book := &domain.Book{
@@ -265,18 +247,8 @@ func (h *HttpBookHandler) Update(c *fiber.Ctx) error {
 		PublishedAt: publishedAt,
 	}
 
-	if err := h.bookService.Update(book); err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return c.Status(fiber.StatusNotFound).JSON(domain.Error{
-				Code:    fiber.StatusNotFound,
-				Message: err.Error(),
-			})
-		}
-
-		return c.Status(fiber.StatusInternalServerError).JSON(domain.Error{
-			Code:    fiber.StatusInternalServerError,
-			Message: err.Error(),
-		})
+	if err := h.bookService.Update(c.UserContext(), book); err != nil {
+		return err
 	}
 
 	return c.Status(fiber.StatusOK).JSON(domain.Success{
@@ -296,6 +268,7 @@ func (h *HttpBookHandler) Update(c *fiber.Ctx) error {
 //	@Param			id	path		int				true	"Book ID"
 //	@Success		200	{object}	domain.Success	"Success delete book"
 //	@Failure		400	{object}	domain.Error	"Bad Request"
+//	@Failure		404	{object}	domain.Error	"Not Found"
 //	@Failure		500	{object}	domain.Error	"Internal Server Error"
 //	@Router			/books/{id} [delete]
 //
@@ -303,17 +276,11 @@ func (h *HttpBookHandler) Update(c *fiber.Ctx) error {
 func (h *HttpBookHandler) Delete(c *fiber.Ctx) error {
 	id, err := c.ParamsInt("id")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(domain.Error{
-			Code:    fiber.StatusBadRequest,
-			Message: "invalid book id",
-		})
+		return domain.NewValidationError("invalid book id")
 	}
 
-	if err := h.bookService.Delete(uint(id)); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(domain.Error{
-			Code:    fiber.StatusInternalServerError,
-			Message: err.Error(),
-		})
+	if err := h.bookService.Delete(c.UserContext(), uint(id)); err != nil {
+		return err
 	}
 
 	return c.Status(fiber.StatusOK).JSON(domain.Success{
@@ -321,3 +288,57 @@ func (h *HttpBookHandler) Delete(c *fiber.Ctx) error {
 		Message: "book deleted successfully",
 	})
 }
+
+// Restore used to restore a deleted book
+//
+//	@Summary		Restore book
+//	@Description	Restore a soft-deleted book
+//	@Tags			books
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		int				true	"Book ID"
+//	@Success		200	{object}	domain.Success	"book detail"
+//	@Failure		400	{object}	domain.Error	"Bad Request"
+//	@Failure		404	{object}	domain.Error	"Not Found"
+//	@Failure		409	{object}	domain.Error	"Not Deleted"
+//	@Failure		500	{object}	domain.Error	"Internal Server Error"
+//	@Router			/books/{id}/restore [post]
+//
+// @Security Bearer
+func (h *HttpBookHandler) Restore(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return domain.NewValidationError("invalid book id")
+	}
+
+	book, err := h.bookService.Restore(c.UserContext(), uint(id))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(domain.Success{
+		Code:    fiber.StatusOK,
+		Message: "book restored successfully",
+		Data:    book,
+	})
+}
+
+// parseFilter reads the search query of the field selected by filterBy
+func parseFilter(query string, filterBy string) (*domain.Book, error) {
+	var filter domain.Book
+
+	switch filterBy {
+	case "title":
+		filter.Title = query
+	case "author":
+		filter.Author = query
+	case "price":
+		price, err := strconv.Atoi(query)
+		if err != nil {
+			return nil, domain.NewValidationError("price must be an integer")
+		}
+		filter.Price = price
+	}
+
+	return &filter, nil
+}
@@ -2,6 +2,9 @@ package book
 
 import (
 	"book-store/internal/domain"
+	"book-store/internal/utilities"
+	"context"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -11,21 +14,9 @@ type mysqlBookRepository struct {
 }
 
 // Count
-func (m *mysqlBookRepository) Count(filter *domain.Book) (int64, error) {
+func (m *mysqlBookRepository) Count(ctx context.Context, filter *domain.Book) (int64, error) {
 	var count int64
-	query := m.db.Model(&domain.Book{})
-
-	if filter.Title != "" {
-		query = query.Where("title LIKE ?", "%"+filter.Title+"%")
-	}
-
-	if filter.Author != "" {
-		query = query.Where("author LIKE ?", "%"+filter.Author+"%")
-	}
-
-	if filter.Price > 0 {
-		query = query.Where("price = ?", filter.Price)
-	}
+	query := applyFilter(utilities.Scoped(ctx, m.db).Model(&domain.Book{}), filter)
 
 	if err := query.Count(&count).Error; err != nil {
 		return 0, err
@@ -34,30 +25,18 @@ func (m *mysqlBookRepository) Count(filter *domain.Book) (int64, error) {
 	return count, nil
 }
 
-// Delete
-func (m *mysqlBookRepository) Delete(id uint) error {
-	return m.db.Delete(&domain.Book{}, id).Error
+// Delete returns gorm.ErrRecordNotFound when there is no such book
+func (m *mysqlBookRepository) Delete(ctx context.Context, id uint) error {
+	db := m.db.WithContext(ctx)
+	return utilities.RequireRows(db, db.Delete(&domain.Book{}, id), &domain.Book{}, id)
 }
 
 // Fetch
-func (m *mysqlBookRepository) Fetch(page int, size int, filter *domain.Book) ([]*domain.Book, int, error) {
+func (m *mysqlBookRepository) Fetch(ctx context.Context, page int, size int, filter *domain.Book) ([]*domain.Book, int, error) {
 	var books []*domain.Book
 
 	offset := (page - 1) * size
-	query := m.db
-
-	// Can filter by title/author/price
-	if filter.Title != "" {
-		query = query.Where("title LIKE ?", "%"+filter.Title+"%")
-	}
-
-	if filter.Author != "" {
-		query = query.Where("author LIKE ?", "%"+filter.Author+"%")
-	}
-
-	if filter.Price > 0 {
-		query = query.Where("price = ?", filter.Price)
-	}
+	query := applyFilter(utilities.Scoped(ctx, m.db), filter)
 
 	if err := query.Order("created_at DESC").Offset(offset).Limit(size).Find(&books).Error; err != nil {
 		return nil, 0, err
@@ -72,10 +51,21 @@ func (m *mysqlBookRepository) Fetch(page int, size int, filter *domain.Book) ([]
 }
 
 // GetById
-func (m *mysqlBookRepository) GetById(id uint) (*domain.Book, error) {
+func (m *mysqlBookRepository) GetById(ctx context.Context, id uint) (*domain.Book, error) {
 	var book *domain.Book
 
-	if err := m.db.First(&book, id).Error; err != nil {
+	if err := utilities.Scoped(ctx, m.db).First(&book, id).Error; err != nil {
+		return nil, err
+	}
+
+	return book, nil
+}
+
+// GetByIsbn finds deleted books too, a book that isn't deleted comes first
+func (m *mysqlBookRepository) GetByIsbn(ctx context.Context, isbn string) (*domain.Book, error) {
+	var book *domain.Book
+
+	if err := m.db.WithContext(ctx).Unscoped().Where("isbn = ?", isbn).Order("deleted_at IS NOT NULL, id").First(&book).Error; err != nil {
 		return nil, err
 	}
 
@@ -83,13 +73,60 @@ func (m *mysqlBookRepository) GetById(id uint) (*domain.Book, error) {
 }
 
 // Store
-func (m *mysqlBookRepository) Store(book *domain.Book) error {
-	return m.db.Create(book).Error
+func (m *mysqlBookRepository) Store(ctx context.Context, book *domain.Book) error {
+	return m.db.WithContext(ctx).Create(book).Error
+}
+
+// Update returns gorm.ErrRecordNotFound when there is no such book
+func (m *mysqlBookRepository) Update(ctx context.Context, book *domain.Book) error {
+	db := m.db.WithContext(ctx)
+	return utilities.RequireRows(db, db.Updates(book), &domain.Book{}, book.ID)
+}
+
+// Restore
+func (m *mysqlBookRepository) Restore(ctx context.Context, id uint) error {
+	return utilities.Restore(m.db.WithContext(ctx), &domain.Book{}, id)
+}
+
+// Purge keeps books still on a transaction so its details can show them
+func (m *mysqlBookRepository) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	referenced := m.db.Unscoped().Model(&domain.TransactionDetail{}).Select("1").Where("transaction_details.book_id = books.id")
+
+	result := m.db.WithContext(ctx).Unscoped().
+		Where("deleted_at < ? AND NOT EXISTS (?)", deletedBefore, referenced).
+		Delete(&domain.Book{})
+	return result.RowsAffected, result.Error
 }
 
-// Update
-func (m *mysqlBookRepository) Update(book *domain.Book) error {
-	return m.db.Updates(book).Error
+// Each walks every book matching the filter in batches
+func (m *mysqlBookRepository) Each(ctx context.Context, filter *domain.Book, fn func(book *domain.Book) error) error {
+	var books []*domain.Book
+
+	return applyFilter(m.db.WithContext(ctx), filter).FindInBatches(&books, 500, func(tx *gorm.DB, batch int) error {
+		for _, book := range books {
+			if err := fn(book); err != nil {
+				return err
+			}
+		}
+		return nil
+	}).Error
+}
+
+// applyFilter can filter by title/author/price
+func applyFilter(query *gorm.DB, filter *domain.Book) *gorm.DB {
+	if filter.Title != "" {
+		query = query.Where("title LIKE ?", "%"+filter.Title+"%")
+	}
+
+	if filter.Author != "" {
+		query = query.Where("author LIKE ?", "%"+filter.Author+"%")
+	}
+
+	if filter.Price > 0 {
+		query = query.Where("price = ?", filter.Price)
+	}
+
+	return query
 }
 
 func NewMysqlBookRepository(db *gorm.DB) domain.BookRepository {
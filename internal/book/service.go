@@ -1,10 +1,11 @@
 package book
 
 import (
-	"errors"
 	"book-store/internal/domain"
+	"book-store/internal/tracing"
+	"context"
+	"errors"
 
-	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 )
 
@@ -13,8 +14,11 @@ type bookService struct {
 }
 
 // Count
-func (b *bookService) Count(filter *domain.Book) (int64, error) {
-	count, err := b.bookRepo.Count(filter)
+func (b *bookService) Count(ctx context.Context, filter *domain.Book) (int64, error) {
+	ctx, span := tracing.Start(ctx, "BookService.Count")
+	defer span.End()
+
+	count, err := b.bookRepo.Count(ctx, filter)
 	if err != nil {
 		return 0, err
 	}
@@ -22,13 +26,25 @@ func (b *bookService) Count(filter *domain.Book) (int64, error) {
 }
 
 // Delete
-func (b *bookService) Delete(id uint) error {
-	return b.bookRepo.Delete(id)
+func (b *bookService) Delete(ctx context.Context, id uint) error {
+	ctx, span := tracing.Start(ctx, "BookService.Delete")
+	defer span.End()
+
+	if err := b.bookRepo.Delete(ctx, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrBookNotFound
+		}
+		return err
+	}
+	return nil
 }
 
 // Fetch
-func (b *bookService) Fetch(page int, size int, filter *domain.Book) ([]*domain.Book, int, error) {
-	books, nextCursor, err := b.bookRepo.Fetch(page, size, filter)
+func (b *bookService) Fetch(ctx context.Context, page int, size int, filter *domain.Book) ([]*domain.Book, int, error) {
+	ctx, span := tracing.Start(ctx, "BookService.Fetch")
+	defer span.End()
+
+	books, nextCursor, err := b.bookRepo.Fetch(ctx, page, size, filter)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -37,11 +53,14 @@ func (b *bookService) Fetch(page int, size int, filter *domain.Book) ([]*domain.
 }
 
 // GetById
-func (b *bookService) GetById(id uint) (*domain.Book, error) {
-	book, err := b.bookRepo.GetById(id)
+func (b *bookService) GetById(ctx context.Context, id uint) (*domain.Book, error) {
+	ctx, span := tracing.Start(ctx, "BookService.GetById")
+	defer span.End()
+
+	book, err := b.bookRepo.GetById(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, fiber.ErrNotFound
+			return nil, domain.ErrBookNotFound
 		}
 		return nil, err
 	}
@@ -50,13 +69,48 @@ func (b *bookService) GetById(id uint) (*domain.Book, error) {
 }
 
 // Store
-func (b *bookService) Store(book *domain.Book) error {
-	return b.bookRepo.Store(book)
+func (b *bookService) Store(ctx context.Context, book *domain.Book) error {
+	ctx, span := tracing.Start(ctx, "BookService.Store")
+	defer span.End()
+
+	return b.bookRepo.Store(ctx, book)
 }
 
 // Update
-func (b *bookService) Update(book *domain.Book) error {
-	return b.bookRepo.Update(book)
+func (b *bookService) Update(ctx context.Context, book *domain.Book) error {
+	ctx, span := tracing.Start(ctx, "BookService.Update")
+	defer span.End()
+
+	if err := b.bookRepo.Update(ctx, book); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrBookNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// Restore
+func (b *bookService) Restore(ctx context.Context, id uint) (*domain.Book, error) {
+	ctx, span := tracing.Start(ctx, "BookService.Restore")
+	defer span.End()
+
+	if err := b.bookRepo.Restore(ctx, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrBookNotFound
+		}
+		return nil, err
+	}
+
+	return b.GetById(ctx, id)
+}
+
+// Each
+func (b *bookService) Each(ctx context.Context, filter *domain.Book, fn func(book *domain.Book) error) error {
+	ctx, span := tracing.Start(ctx, "BookService.Each")
+	defer span.End()
+
+	return b.bookRepo.Each(ctx, filter, fn)
 }
 
 func NewBookService(bookRepo domain.BookRepository) domain.BookService {
@@ -0,0 +1,59 @@
+package book
+
+import (
+	"book-store/internal/domain"
+	"book-store/internal/testdb"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func TestBookServiceNotFound(t *testing.T) {
+	db := testdb.Open(t, &domain.Book{})
+	svc := NewBookService(NewMysqlBookRepository(db))
+	ctx := context.Background()
+
+	deleted := &domain.Book{Title: "Dune", Author: "Frank Herbert", Price: 100, Isbn: "9780441172719", Stock: 1, PublishedAt: time.Now()}
+	if err := db.Create(deleted).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Delete(deleted).Error; err != nil {
+		t.Fatal(err)
+	}
+	const missing = 404
+
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{name: "update missing", call: func() error {
+			return svc.Update(ctx, &domain.Book{Model: gorm.Model{ID: missing}, Title: "Dune Messiah"})
+		}},
+		{name: "update deleted", call: func() error {
+			return svc.Update(ctx, &domain.Book{Model: gorm.Model{ID: deleted.ID}, Title: "Dune Messiah"})
+		}},
+		{name: "delete missing", call: func() error { return svc.Delete(ctx, missing) }},
+		{name: "delete deleted", call: func() error { return svc.Delete(ctx, deleted.ID) }},
+		{name: "restore missing", call: func() error {
+			_, err := svc.Restore(ctx, missing)
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			if !errors.Is(err, domain.ErrBookNotFound) {
+				t.Fatalf("err = %v, want %v", err, domain.ErrBookNotFound)
+			}
+
+			var appErr *domain.AppError
+			if !errors.As(err, &appErr) || appErr.Status() != 404 {
+				t.Errorf("err = %v, want a 404", err)
+			}
+		})
+	}
+}
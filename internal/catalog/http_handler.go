@@ -0,0 +1,158 @@
+package catalog
+
+import (
+	"book-store/internal/config"
+	"book-store/internal/domain"
+	"book-store/internal/middleware/jwt"
+	"book-store/pkg/xlogger"
+	"bufio"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type HttpCatalogHandler struct {
+	catalogSvc     domain.CatalogService
+	authMiddleware jwt.AuthMiddleware
+	cfg            config.Import
+}
+
+func NewHttpHandler(r fiber.Router, catalogSvc domain.CatalogService, authMiddleware jwt.AuthMiddleware, cfg config.Import) {
+	handler := &HttpCatalogHandler{
+		catalogSvc:     catalogSvc,
+		authMiddleware: authMiddleware,
+		cfg:            cfg,
+	}
+
+	r.Post("/import", authMiddleware.RequireRole("admin"), handler.Import)
+	r.Get("/import/:jobId", authMiddleware.RequireRole("admin"), handler.GetImportJob)
+	r.Get("/onix/export", authMiddleware.RequireRole("admin", "employee"), handler.ExportONIX)
+}
+
+// Import used to add or update books in bulk
+//
+//	@Summary		Import books
+//	@Description	Import books from a csv, json lines or ONIX 3.0 (reference tags) file, rows are validated like POST /books and upserted by ISBN, a deleted book with the ISBN is restored.
+//	@Description	ONIX products without stock keep the stock of the existing book.
+//	@Description	Files larger than the background threshold are imported as a job, poll it with GET /books/import/{jobId}.
+//	@Tags			books
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Param			file	formData	file			true	"csv, jsonl or onix file"
+//	@Param			format	query		string			false	"File format (csv, jsonl, onix), default from the file extension"
+//	@Param			dry_run	query		bool			false	"Validate without saving"
+//	@Success		200		{object}	domain.Success	"import report"
+//	@Success		202		{object}	domain.Success	"import job"
+//	@Failure		400		{object}	domain.Error	"Bad Request"
+//	@Failure		500		{object}	domain.Error	"Internal Server Error"
+//	@Router			/books/import [post]
+//
+// @Security Bearer
+func (h *HttpCatalogHandler) Import(c *fiber.Ctx) error {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return domain.NewValidationError("file is required")
+	}
+
+	format := domain.ImportFormat(c.Query("format", strings.TrimPrefix(strings.ToLower(filepath.Ext(fileHeader.Filename)), ".")))
+	switch format {
+	case "ndjson":
+		format = domain.ImportFormatJSONL
+	case "xml":
+		format = domain.ImportFormatONIX
+	}
+	if format != domain.ImportFormatCSV && format != domain.ImportFormatJSONL && format != domain.ImportFormatONIX {
+		return domain.ErrInvalidImportFormat
+	}
+
+	dryRun := c.QueryBool("dry_run")
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// large files outlive the request, the background job reads its copy from the database
+	if fileHeader.Size > h.cfg.BackgroundThreshold {
+		job, err := h.catalogSvc.ImportAsync(c.UserContext(), file, format, dryRun)
+		if err != nil {
+			return err
+		}
+
+		return c.Status(fiber.StatusAccepted).JSON(domain.Success{
+			Code:    fiber.StatusAccepted,
+			Message: "import started",
+			Data:    job,
+		})
+	}
+
+	report, err := h.catalogSvc.Import(c.UserContext(), file, format, dryRun)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(domain.Success{
+		Code:    fiber.StatusOK,
+		Message: "success",
+		Data:    report,
+	})
+}
+
+// GetImportJob used to get the report of a background import
+//
+//	@Summary		Get book import job
+//	@Description	Get status and report of a background book import
+//	@Tags			books
+//	@Accept			json
+//	@Produce		json
+//	@Param			jobId	path		string			true	"import job ID"
+//	@Success		200		{object}	domain.Success	"import report"
+//	@Failure		404		{object}	domain.Error	"Not Found"
+//	@Router			/books/import/{jobId} [get]
+//
+// @Security Bearer
+func (h *HttpCatalogHandler) GetImportJob(c *fiber.Ctx) error {
+	job, err := h.catalogSvc.GetImportJob(c.UserContext(), c.Params("jobId"))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(domain.Success{
+		Code:    fiber.StatusOK,
+		Message: "success",
+		Data:    job,
+	})
+}
+
+// ExportONIX used to send the catalog to downstream retailers
+//
+//	@Summary		Export books as ONIX
+//	@Description	Download the whole catalog as an ONIX 3.0 message, prices are in STORE_CURRENCY
+//	@Tags			books
+//	@Produce		xml
+//	@Success		200	{file}		file			"onix file"
+//	@Failure		401	{object}	domain.Error	"Unauthorized"
+//	@Router			/books/onix/export [get]
+//
+// @Security Bearer
+func (h *HttpCatalogHandler) ExportONIX(c *fiber.Ctx) error {
+	filename := fmt.Sprintf("books-%s.xml", time.Now().Format("20060102-150405"))
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationXMLCharsetUTF8)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+
+	// the body is streamed after the handler returns, c must not be used from the writer
+	ctx := c.UserContext()
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		// the status is already sent at this point, failures can only be logged
+		if err := h.catalogSvc.ExportONIX(ctx, w); err != nil {
+			xlogger.Ctx(ctx).Error().Err(err).Str("export", "onix").Msg("export failed")
+		}
+		w.Flush()
+	})
+
+	return nil
+}
@@ -0,0 +1,37 @@
+package catalog
+
+import (
+	"book-store/internal/domain"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type mysqlImportUploadRepository struct {
+	db *gorm.DB
+}
+
+// Store
+func (m *mysqlImportUploadRepository) Store(ctx context.Context, upload *domain.ImportUpload) error {
+	return m.db.WithContext(ctx).Create(upload).Error
+}
+
+// GetById
+func (m *mysqlImportUploadRepository) GetById(ctx context.Context, id uint) (*domain.ImportUpload, error) {
+	var upload *domain.ImportUpload
+
+	if err := m.db.WithContext(ctx).First(&upload, id).Error; err != nil {
+		return nil, err
+	}
+
+	return upload, nil
+}
+
+// Delete
+func (m *mysqlImportUploadRepository) Delete(ctx context.Context, id uint) error {
+	return m.db.WithContext(ctx).Delete(&domain.ImportUpload{}, id).Error
+}
+
+func NewMysqlImportUploadRepository(db *gorm.DB) domain.ImportUploadRepository {
+	return &mysqlImportUploadRepository{db: db}
+}
@@ -0,0 +1,603 @@
+package catalog
+
+import (
+	"book-store/internal/config"
+	"book-store/internal/domain"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ONIX 3.0 reference tag names and the code list values we read and write,
+// see https://www.editeur.org/93/Release-3.0-Downloads/
+const (
+	onixNamespace = "http://ns.editeur.org/onix/3.0/reference"
+
+	onixNotificationConfirmed = "03"
+	onixNotificationDelete    = "05"
+
+	onixIdIsbn10 = "02"
+	onixIdGtin13 = "03"
+	onixIdIsbn13 = "15"
+
+	onixTitleDistinctive  = "01"
+	onixTitleLevelProduct = "01"
+
+	onixRoleAuthor = "A01"
+
+	onixLanguageOfText = "01"
+
+	onixExtentMainContent  = "00"
+	onixExtentContentPages = "11"
+	onixExtentUnitPages    = "03"
+
+	onixTextDescription      = "03"
+	onixTextShortDescription = "02"
+	onixTextFormatHTML       = "02"
+	onixTextFormatText       = "06"
+
+	onixDatePublication = "01"
+
+	onixAvailabilityInStock     = "21"
+	onixAvailabilityOutOfStock  = "31"
+	onixPriceRetailIncludingTax = "02"
+)
+
+type onixHeader struct {
+	XMLName      xml.Name   `xml:"Header"`
+	Sender       onixSender `xml:"Sender"`
+	SentDateTime string     `xml:"SentDateTime"`
+}
+
+type onixSender struct {
+	SenderName string `xml:"SenderName"`
+}
+
+type onixProduct struct {
+	XMLName            xml.Name                `xml:"Product"`
+	RecordReference    string                  `xml:"RecordReference"`
+	NotificationType   string                  `xml:"NotificationType"`
+	ProductIdentifiers []onixProductIdentifier `xml:"ProductIdentifier"`
+	DescriptiveDetail  onixDescriptiveDetail   `xml:"DescriptiveDetail"`
+	CollateralDetail   *onixCollateralDetail   `xml:"CollateralDetail,omitempty"`
+	PublishingDetail   *onixPublishingDetail   `xml:"PublishingDetail,omitempty"`
+	ProductSupply      []onixProductSupply     `xml:"ProductSupply"`
+}
+
+type onixProductIdentifier struct {
+	ProductIDType string `xml:"ProductIDType"`
+	IDValue       string `xml:"IDValue"`
+}
+
+type onixDescriptiveDetail struct {
+	ProductComposition string            `xml:"ProductComposition"`
+	ProductForm        string            `xml:"ProductForm"`
+	TitleDetails       []onixTitleDetail `xml:"TitleDetail"`
+	Contributors       []onixContributor `xml:"Contributor"`
+	Languages          []onixLanguage    `xml:"Language"`
+	Extents            []onixExtent      `xml:"Extent"`
+}
+
+type onixTitleDetail struct {
+	TitleType     string             `xml:"TitleType"`
+	TitleElements []onixTitleElement `xml:"TitleElement"`
+}
+
+type onixTitleElement struct {
+	TitleElementLevel  string `xml:"TitleElementLevel"`
+	TitlePrefix        string `xml:"TitlePrefix,omitempty"`
+	TitleWithoutPrefix string `xml:"TitleWithoutPrefix,omitempty"`
+	TitleText          string `xml:"TitleText,omitempty"`
+	Subtitle           string `xml:"Subtitle,omitempty"`
+}
+
+type onixContributor struct {
+	SequenceNumber  string   `xml:"SequenceNumber,omitempty"`
+	ContributorRole []string `xml:"ContributorRole"`
+	PersonName      string   `xml:"PersonName,omitempty"`
+	NamesBeforeKey  string   `xml:"NamesBeforeKey,omitempty"`
+	KeyNames        string   `xml:"KeyNames,omitempty"`
+	CorporateName   string   `xml:"CorporateName,omitempty"`
+}
+
+type onixLanguage struct {
+	LanguageRole string `xml:"LanguageRole"`
+	LanguageCode string `xml:"LanguageCode"`
+}
+
+type onixExtent struct {
+	ExtentType  string `xml:"ExtentType"`
+	ExtentValue string `xml:"ExtentValue"`
+	ExtentUnit  string `xml:"ExtentUnit"`
+}
+
+type onixCollateralDetail struct {
+	TextContents []onixTextContent `xml:"TextContent"`
+}
+
+type onixTextContent struct {
+	TextType        string     `xml:"TextType"`
+	ContentAudience []string   `xml:"ContentAudience"`
+	Texts           []onixText `xml:"Text"`
+}
+
+// onixText keeps the raw content on decode since descriptions are often sent as XHTML
+type onixText struct {
+	Format string `xml:"textformat,attr,omitempty"`
+	Value  string `xml:",chardata"`
+	Raw    string `xml:",innerxml"`
+}
+
+type onixPublishingDetail struct {
+	PublishingDates []onixPublishingDate `xml:"PublishingDate"`
+}
+
+type onixPublishingDate struct {
+	PublishingDateRole string   `xml:"PublishingDateRole"`
+	Date               onixDate `xml:"Date"`
+}
+
+type onixDate struct {
+	Format string `xml:"dateformat,attr,omitempty"`
+	Value  string `xml:",chardata"`
+}
+
+type onixProductSupply struct {
+	SupplyDetails []onixSupplyDetail `xml:"SupplyDetail"`
+}
+
+type onixSupplyDetail struct {
+	Supplier            onixSupplier `xml:"Supplier"`
+	ProductAvailability string       `xml:"ProductAvailability"`
+	Stocks              []onixStock  `xml:"Stock"`
+	Prices              []onixPrice  `xml:"Price"`
+}
+
+type onixSupplier struct {
+	SupplierRole string `xml:"SupplierRole"`
+	SupplierName string `xml:"SupplierName"`
+}
+
+type onixStock struct {
+	OnHand string `xml:"OnHand"`
+}
+
+type onixPrice struct {
+	PriceType    string `xml:"PriceType"`
+	PriceAmount  string `xml:"PriceAmount"`
+	CurrencyCode string `xml:"CurrencyCode,omitempty"`
+}
+
+// onixLanguages maps ISO 639-2/B codes to the language names stored on books
+var onixLanguages = map[string]string{
+	"ara": "Arabic",
+	"chi": "Chinese",
+	"dut": "Dutch",
+	"eng": "English",
+	"fre": "French",
+	"ger": "German",
+	"ind": "Indonesian",
+	"ita": "Italian",
+	"jav": "Javanese",
+	"jpn": "Japanese",
+	"kor": "Korean",
+	"may": "Malay",
+	"por": "Portuguese",
+	"rus": "Russian",
+	"spa": "Spanish",
+	"sun": "Sundanese",
+}
+
+var htmlTag = regexp.MustCompile(`<[^>]*>`)
+
+// parseONIX walks the Product records of an ONIX 3.0 message in reference tag form,
+// rows are numbered by product
+func parseONIX(r io.Reader, currency string, fn rowFunc) error {
+	decoder := xml.NewDecoder(r)
+
+	row := 0
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			if row == 0 {
+				return errors.New("onix message has no products")
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "ONIXmessage":
+			return errors.New("onix short tags are not supported, send reference tags")
+		case "Product":
+			row++
+
+			var product onixProduct
+			if err := decoder.DecodeElement(&product, &start); err != nil {
+				return err
+			}
+
+			req, err := product.bookRequest(currency)
+			if err := fn(row, req, err); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// bookRequest maps the product to the fields POST /books takes, the request is returned
+// with whatever could be read so the row error still carries the isbn
+func (p *onixProduct) bookRequest(currency string) (*domain.BookStoreRequest, error) {
+	req := &domain.BookStoreRequest{
+		Isbn:        p.isbn(),
+		Title:       p.title(),
+		Author:      p.authors(),
+		Description: p.description(),
+		Language:    p.language(),
+		Pages:       p.pages(),
+	}
+
+	if p.NotificationType == onixNotificationDelete {
+		return req, errors.New("delete notifications are not supported")
+	}
+
+	var errs []error
+	var err error
+	if req.PublishedAt, err = p.publishedAt(); err != nil {
+		errs = append(errs, err)
+	}
+	if req.Price, err = p.price(currency); err != nil {
+		errs = append(errs, err)
+	}
+	req.Stock = p.stock()
+
+	return req, errors.Join(errs...)
+}
+
+func (p *onixProduct) isbn() string {
+	for _, idType := range []string{onixIdIsbn13, onixIdGtin13, onixIdIsbn10} {
+		for _, id := range p.ProductIdentifiers {
+			if id.ProductIDType == idType {
+				return strings.ReplaceAll(strings.TrimSpace(id.IDValue), "-", "")
+			}
+		}
+	}
+	return ""
+}
+
+func (p *onixProduct) title() string {
+	for _, detail := range p.DescriptiveDetail.TitleDetails {
+		if detail.TitleType != onixTitleDistinctive {
+			continue
+		}
+		for _, element := range detail.TitleElements {
+			if element.TitleElementLevel != onixTitleLevelProduct {
+				continue
+			}
+
+			title := strings.TrimSpace(element.TitleText)
+			if title == "" {
+				title = strings.TrimSpace(element.TitlePrefix + " " + element.TitleWithoutPrefix)
+			}
+			if subtitle := strings.TrimSpace(element.Subtitle); subtitle != "" {
+				title += ": " + subtitle
+			}
+			return title
+		}
+	}
+	return ""
+}
+
+// authors joins the A01 contributors in sequence order, anyone credited is used when there are none
+func (p *onixProduct) authors() string {
+	contributors := make([]onixContributor, len(p.DescriptiveDetail.Contributors))
+	copy(contributors, p.DescriptiveDetail.Contributors)
+	sort.SliceStable(contributors, func(i, j int) bool {
+		a, _ := strconv.Atoi(contributors[i].SequenceNumber)
+		b, _ := strconv.Atoi(contributors[j].SequenceNumber)
+		return a < b
+	})
+
+	var authors, others []string
+	for _, contributor := range contributors {
+		name := contributor.name()
+		if name == "" {
+			continue
+		}
+
+		isAuthor := false
+		for _, role := range contributor.ContributorRole {
+			if role == onixRoleAuthor {
+				isAuthor = true
+			}
+		}
+
+		if isAuthor {
+			authors = append(authors, name)
+		} else {
+			others = append(others, name)
+		}
+	}
+
+	if len(authors) == 0 {
+		authors = others
+	}
+	return strings.Join(authors, ", ")
+}
+
+func (c *onixContributor) name() string {
+	if name := strings.TrimSpace(c.PersonName); name != "" {
+		return name
+	}
+	if name := strings.TrimSpace(c.NamesBeforeKey + " " + c.KeyNames); name != "" {
+		return name
+	}
+	return strings.TrimSpace(c.CorporateName)
+}
+
+// description prefers the long description and strips any markup from it
+func (p *onixProduct) description() string {
+	if p.CollateralDetail == nil {
+		return ""
+	}
+
+	for _, textType := range []string{onixTextDescription, onixTextShortDescription} {
+		for _, content := range p.CollateralDetail.TextContents {
+			if content.TextType != textType || len(content.Texts) == 0 {
+				continue
+			}
+
+			text := content.Texts[0].Raw
+			text = strings.NewReplacer("<![CDATA[", "", "]]>", "").Replace(text)
+			text = htmlTag.ReplaceAllString(text, " ")
+			text = html.UnescapeString(text)
+			// escaped html only turns into tags once unescaped
+			if content.Texts[0].Format == onixTextFormatHTML {
+				text = htmlTag.ReplaceAllString(text, " ")
+			}
+			return strings.Join(strings.Fields(text), " ")
+		}
+	}
+	return ""
+}
+
+func (p *onixProduct) language() string {
+	for _, language := range p.DescriptiveDetail.Languages {
+		if language.LanguageRole != onixLanguageOfText {
+			continue
+		}
+
+		code := strings.ToLower(strings.TrimSpace(language.LanguageCode))
+		if name, ok := onixLanguages[code]; ok {
+			return name
+		}
+		return code
+	}
+	return ""
+}
+
+func (p *onixProduct) pages() int {
+	for _, extentType := range []string{onixExtentMainContent, onixExtentContentPages} {
+		for _, extent := range p.DescriptiveDetail.Extents {
+			if extent.ExtentType != extentType || extent.ExtentUnit != onixExtentUnitPages {
+				continue
+			}
+			if pages, err := strconv.Atoi(strings.TrimSpace(extent.ExtentValue)); err == nil {
+				return pages
+			}
+		}
+	}
+	return 0
+}
+
+// publishedAt reads the publication date as dd-mm-yyyy, partial dates fall on the first day
+func (p *onixProduct) publishedAt() (string, error) {
+	if p.PublishingDetail == nil {
+		return "", nil
+	}
+
+	for _, date := range p.PublishingDetail.PublishingDates {
+		if date.PublishingDateRole != onixDatePublication {
+			continue
+		}
+
+		value := strings.TrimSpace(date.Date.Value)
+		var layout string
+		switch {
+		case len(value) >= 8:
+			value, layout = value[:8], "20060102"
+		case len(value) == 6:
+			layout = "200601"
+		case len(value) == 4:
+			layout = "2006"
+		}
+
+		publishedAt, err := time.Parse(layout, value)
+		if layout == "" || err != nil {
+			return "", fmt.Errorf("invalid publication date %q", date.Date.Value)
+		}
+		return publishedAt.Format("02-01-2006"), nil
+	}
+	return "", nil
+}
+
+// price takes the first price in the store currency, prices without a currency are assumed to be in it
+func (p *onixProduct) price(currency string) (int, error) {
+	found := false
+	for _, supply := range p.ProductSupply {
+		for _, detail := range supply.SupplyDetails {
+			for _, price := range detail.Prices {
+				found = true
+				if price.CurrencyCode != "" && !strings.EqualFold(price.CurrencyCode, currency) {
+					continue
+				}
+
+				amount, err := strconv.ParseFloat(strings.TrimSpace(price.PriceAmount), 64)
+				if err != nil {
+					return 0, fmt.Errorf("invalid price amount %q", price.PriceAmount)
+				}
+				return int(amount + 0.5), nil
+			}
+		}
+	}
+
+	if found {
+		return 0, fmt.Errorf("no price in %s", currency)
+	}
+	return 0, nil
+}
+
+func (p *onixProduct) stock() int {
+	for _, supply := range p.ProductSupply {
+		for _, detail := range supply.SupplyDetails {
+			for _, stock := range detail.Stocks {
+				if onHand, err := strconv.Atoi(strings.TrimSpace(stock.OnHand)); err == nil {
+					return onHand
+				}
+			}
+		}
+	}
+	return 0
+}
+
+// newOnixProduct describes the book as a confirmed record supplied by the store
+func newOnixProduct(book *domain.Book, store config.Store) *onixProduct {
+	product := &onixProduct{
+		RecordReference:  fmt.Sprintf("%s-%d", store.Code, book.ID),
+		NotificationType: onixNotificationConfirmed,
+		ProductIdentifiers: []onixProductIdentifier{
+			{ProductIDType: onixIdIsbn13, IDValue: book.Isbn},
+		},
+		DescriptiveDetail: onixDescriptiveDetail{
+			ProductComposition: "00",
+			ProductForm:        "00",
+			TitleDetails: []onixTitleDetail{{
+				TitleType: onixTitleDistinctive,
+				TitleElements: []onixTitleElement{{
+					TitleElementLevel: onixTitleLevelProduct,
+					TitleText:         book.Title,
+				}},
+			}},
+			Contributors: []onixContributor{{
+				SequenceNumber:  "1",
+				ContributorRole: []string{onixRoleAuthor},
+				PersonName:      book.Author,
+			}},
+		},
+		PublishingDetail: &onixPublishingDetail{
+			PublishingDates: []onixPublishingDate{{
+				PublishingDateRole: onixDatePublication,
+				Date:               onixDate{Format: "00", Value: book.PublishedAt.Format("20060102")},
+			}},
+		},
+	}
+
+	if code := onixLanguageCode(book.Language); code != "" {
+		product.DescriptiveDetail.Languages = []onixLanguage{{LanguageRole: onixLanguageOfText, LanguageCode: code}}
+	}
+
+	if book.Pages > 0 {
+		product.DescriptiveDetail.Extents = []onixExtent{{
+			ExtentType:  onixExtentMainContent,
+			ExtentValue: strconv.Itoa(book.Pages),
+			ExtentUnit:  onixExtentUnitPages,
+		}}
+	}
+
+	if book.Description != "" {
+		product.CollateralDetail = &onixCollateralDetail{
+			TextContents: []onixTextContent{{
+				TextType:        onixTextDescription,
+				ContentAudience: []string{"00"},
+				Texts:           []onixText{{Format: onixTextFormatText, Value: book.Description}},
+			}},
+		}
+	}
+
+	availability := onixAvailabilityInStock
+	if book.Stock <= 0 {
+		availability = onixAvailabilityOutOfStock
+	}
+	product.ProductSupply = []onixProductSupply{{
+		SupplyDetails: []onixSupplyDetail{{
+			Supplier:            onixSupplier{SupplierRole: "00", SupplierName: store.Name},
+			ProductAvailability: availability,
+			Stocks:              []onixStock{{OnHand: strconv.Itoa(max(book.Stock, 0))}},
+			Prices: []onixPrice{{
+				PriceType:    onixPriceRetailIncludingTax,
+				PriceAmount:  strconv.Itoa(book.Price),
+				CurrencyCode: store.Currency,
+			}},
+		}},
+	}}
+
+	return product
+}
+
+// onixLanguageCode accepts either a language name from onixLanguages or a three letter code
+func onixLanguageCode(language string) string {
+	language = strings.TrimSpace(language)
+	for code, name := range onixLanguages {
+		if strings.EqualFold(name, language) {
+			return code
+		}
+	}
+	if len(language) == 3 {
+		return strings.ToLower(language)
+	}
+	return ""
+}
+
+// writeONIX writes an ONIX 3.0 message with one product per book yielded by each
+func writeONIX(w io.Writer, store config.Store, each func(fn func(book *domain.Book) error) error) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+
+	root := xml.StartElement{
+		Name: xml.Name{Local: "ONIXMessage"},
+		Attr: []xml.Attr{
+			{Name: xml.Name{Local: "release"}, Value: "3.0"},
+			{Name: xml.Name{Local: "xmlns"}, Value: onixNamespace},
+		},
+	}
+	if err := encoder.EncodeToken(root); err != nil {
+		return err
+	}
+
+	header := onixHeader{
+		Sender:       onixSender{SenderName: store.Name},
+		SentDateTime: time.Now().UTC().Format("20060102T1504Z"),
+	}
+	if err := encoder.Encode(header); err != nil {
+		return err
+	}
+
+	if err := each(func(book *domain.Book) error {
+		return encoder.Encode(newOnixProduct(book, store))
+	}); err != nil {
+		return err
+	}
+
+	if err := encoder.EncodeToken(root.End()); err != nil {
+		return err
+	}
+	return encoder.Flush()
+}
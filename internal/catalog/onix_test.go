@@ -0,0 +1,252 @@
+package catalog
+
+import (
+	"book-store/internal/config"
+	"book-store/internal/domain"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+const onixProductDune = `<Product>
+	<RecordReference>dune</RecordReference>
+	<NotificationType>03</NotificationType>
+	<ProductIdentifier><ProductIDType>02</ProductIDType><IDValue>0441013597</IDValue></ProductIdentifier>
+	<ProductIdentifier><ProductIDType>15</ProductIDType><IDValue>978-0-441-01359-3</IDValue></ProductIdentifier>
+	<DescriptiveDetail>
+		<TitleDetail>
+			<TitleType>01</TitleType>
+			<TitleElement>
+				<TitleElementLevel>01</TitleElementLevel>
+				<TitleWithoutPrefix>Dune</TitleWithoutPrefix>
+				<Subtitle>Deluxe Edition</Subtitle>
+			</TitleElement>
+		</TitleDetail>
+		<Contributor><SequenceNumber>2</SequenceNumber><ContributorRole>B01</ContributorRole><PersonName>An Editor</PersonName></Contributor>
+		<Contributor><SequenceNumber>1</SequenceNumber><ContributorRole>A01</ContributorRole><NamesBeforeKey>Frank</NamesBeforeKey><KeyNames>Herbert</KeyNames></Contributor>
+		<Language><LanguageRole>01</LanguageRole><LanguageCode>eng</LanguageCode></Language>
+		<Extent><ExtentType>00</ExtentType><ExtentValue>617</ExtentValue><ExtentUnit>03</ExtentUnit></Extent>
+	</DescriptiveDetail>
+	<CollateralDetail>
+		<TextContent>
+			<TextType>02</TextType>
+			<ContentAudience>00</ContentAudience>
+			<Text>Short one</Text>
+		</TextContent>
+		<TextContent>
+			<TextType>03</TextType>
+			<ContentAudience>00</ContentAudience>
+			<Text textformat="02">&lt;p&gt;Set on the desert planet &lt;b&gt;Arrakis&lt;/b&gt;&lt;/p&gt;</Text>
+		</TextContent>
+	</CollateralDetail>
+	<PublishingDetail>
+		<PublishingDate><PublishingDateRole>01</PublishingDateRole><Date>20050801</Date></PublishingDate>
+	</PublishingDetail>
+	<ProductSupply>
+		<SupplyDetail>
+			<Supplier><SupplierRole>01</SupplierRole><SupplierName>Ace</SupplierName></Supplier>
+			<ProductAvailability>21</ProductAvailability>
+			<Stock><OnHand>12</OnHand></Stock>
+			<Price><PriceType>02</PriceType><PriceAmount>9.99</PriceAmount><CurrencyCode>USD</CurrencyCode></Price>
+			<Price><PriceType>02</PriceType><PriceAmount>150000.50</PriceAmount><CurrencyCode>IDR</CurrencyCode></Price>
+		</SupplyDetail>
+	</ProductSupply>
+</Product>`
+
+func onixMessage(products ...string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?><ONIXMessage release="3.0" xmlns="http://ns.editeur.org/onix/3.0/reference">` +
+		`<Header><Sender><SenderName>Test</SenderName></Sender></Header>` + strings.Join(products, "") + `</ONIXMessage>`
+}
+
+type onixRow struct {
+	req *domain.BookStoreRequest
+	err string
+}
+
+func TestParseONIX(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    []onixRow
+		wantErr string
+	}{
+		{
+			name:    "full product",
+			message: onixMessage(onixProductDune),
+			want: []onixRow{{req: &domain.BookStoreRequest{
+				Isbn:        "9780441013593",
+				Title:       "Dune: Deluxe Edition",
+				Author:      "Frank Herbert",
+				Description: "Set on the desert planet Arrakis",
+				Language:    "English",
+				Pages:       617,
+				PublishedAt: "01-08-2005",
+				Price:       150001,
+				Stock:       12,
+			}}},
+		},
+		{
+			name: "partial date and no price",
+			message: onixMessage(`<Product><NotificationType>03</NotificationType>
+				<ProductIdentifier><ProductIDType>03</ProductIDType><IDValue>9780000000002</IDValue></ProductIdentifier>
+				<DescriptiveDetail>
+					<TitleDetail><TitleType>01</TitleType><TitleElement><TitleElementLevel>01</TitleElementLevel><TitleText>Untitled</TitleText></TitleElement></TitleDetail>
+					<Contributor><ContributorRole>B01</ContributorRole><CorporateName>Some Press</CorporateName></Contributor>
+				</DescriptiveDetail>
+				<PublishingDetail><PublishingDate><PublishingDateRole>01</PublishingDateRole><Date>200508</Date></PublishingDate></PublishingDetail>
+			</Product>`),
+			want: []onixRow{{req: &domain.BookStoreRequest{
+				Isbn:        "9780000000002",
+				Title:       "Untitled",
+				Author:      "Some Press",
+				PublishedAt: "01-08-2005",
+			}}},
+		},
+		{
+			name: "no price in the store currency",
+			message: onixMessage(`<Product><NotificationType>03</NotificationType>
+				<ProductIdentifier><ProductIDType>15</ProductIDType><IDValue>9780000000019</IDValue></ProductIdentifier>
+				<ProductSupply><SupplyDetail><Price><PriceAmount>9.99</PriceAmount><CurrencyCode>USD</CurrencyCode></Price></SupplyDetail></ProductSupply>
+			</Product>`),
+			want: []onixRow{{req: &domain.BookStoreRequest{Isbn: "9780000000019"}, err: "no price in IDR"}},
+		},
+		{
+			name: "invalid date",
+			message: onixMessage(`<Product><NotificationType>03</NotificationType>
+				<ProductIdentifier><ProductIDType>15</ProductIDType><IDValue>9780000000026</IDValue></ProductIdentifier>
+				<PublishingDetail><PublishingDate><PublishingDateRole>01</PublishingDateRole><Date>2005-13</Date></PublishingDate></PublishingDetail>
+			</Product>`),
+			want: []onixRow{{req: &domain.BookStoreRequest{Isbn: "9780000000026"}, err: `invalid publication date "2005-13"`}},
+		},
+		{
+			name: "delete notification",
+			message: onixMessage(`<Product><NotificationType>05</NotificationType>
+				<ProductIdentifier><ProductIDType>15</ProductIDType><IDValue>9780000000033</IDValue></ProductIdentifier>
+			</Product>`),
+			want: []onixRow{{req: &domain.BookStoreRequest{Isbn: "9780000000033"}, err: "delete notifications are not supported"}},
+		},
+		{
+			name:    "rows are numbered by product",
+			message: onixMessage(onixProductDune, onixProductDune),
+			want:    []onixRow{{}, {}},
+		},
+		{
+			name:    "no products",
+			message: onixMessage(),
+			wantErr: "onix message has no products",
+		},
+		{
+			name:    "short tags",
+			message: `<ONIXmessage release="3.0"><header/></ONIXmessage>`,
+			wantErr: "onix short tags are not supported, send reference tags",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []onixRow
+			err := parseONIX(strings.NewReader(tt.message), "IDR", func(row int, req *domain.BookStoreRequest, err error) error {
+				if row != len(got)+1 {
+					t.Errorf("row = %d, want %d", row, len(got)+1)
+				}
+				r := onixRow{req: req}
+				if err != nil {
+					r.err = err.Error()
+				}
+				got = append(got, r)
+				return nil
+			})
+
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("parseONIX() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseONIX() error = %v", err)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d rows, want %d", len(got), len(tt.want))
+			}
+			for i, want := range tt.want {
+				if got[i].err != want.err {
+					t.Errorf("row %d error = %q, want %q", i+1, got[i].err, want.err)
+				}
+				if want.req != nil && *got[i].req != *want.req {
+					t.Errorf("row %d = %+v, want %+v", i+1, *got[i].req, *want.req)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteONIXParsesBack(t *testing.T) {
+	store := config.Store{Code: "MAIN", Name: "Book Store", Currency: "IDR"}
+	books := []*domain.Book{
+		{
+			Title:       "Laskar Pelangi",
+			Author:      "Andrea Hirata",
+			Price:       89000,
+			Description: "Ten children & their <school>",
+			Pages:       529,
+			Isbn:        "9789793062792",
+			Language:    "Indonesian",
+			Stock:       4,
+			PublishedAt: time.Date(2005, 9, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			Title:       "Out of stock",
+			Author:      "Nobody",
+			Price:       1000,
+			Isbn:        "9780000000040",
+			Stock:       -1,
+			PublishedAt: time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := writeONIX(&buf, store, func(fn func(book *domain.Book) error) error {
+		for _, book := range books {
+			if err := fn(book); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []*domain.BookStoreRequest
+	if err := parseONIX(&buf, store.Currency, func(row int, req *domain.BookStoreRequest, err error) error {
+		if err != nil {
+			t.Errorf("row %d: %v", row, err)
+		}
+		got = append(got, req)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != len(books) {
+		t.Fatalf("got %d rows, want %d", len(got), len(books))
+	}
+	for i, book := range books {
+		want := domain.BookStoreRequest{
+			Title:       book.Title,
+			Author:      book.Author,
+			Price:       book.Price,
+			Description: book.Description,
+			Pages:       book.Pages,
+			Isbn:        book.Isbn,
+			Language:    book.Language,
+			Stock:       max(book.Stock, 0),
+			PublishedAt: book.PublishedAt.Format("02-01-2006"),
+		}
+		if *got[i] != want {
+			t.Errorf("row %d = %+v, want %+v", i+1, *got[i], want)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package catalog
+
+import (
+	"book-store/internal/domain"
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// rowFunc receives every parsed row, err is set when the row itself couldn't be read
+type rowFunc func(row int, req *domain.BookStoreRequest, err error) error
+
+// parseRows reads book rows one at a time so large files are never held in memory
+func parseRows(r io.Reader, format domain.ImportFormat, currency string, fn rowFunc) error {
+	switch format {
+	case domain.ImportFormatCSV:
+		return parseCSV(r, fn)
+	case domain.ImportFormatJSONL:
+		return parseJSONL(r, fn)
+	case domain.ImportFormatONIX:
+		return parseONIX(r, currency, fn)
+	default:
+		return domain.ErrInvalidImportFormat
+	}
+}
+
+// parseCSV expects a header row naming the columns like the json fields of BookStoreRequest
+func parseCSV(r io.Reader, fn rowFunc) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return errors.New("csv file is empty")
+		}
+		return err
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return err
+			}
+			if err := fn(row, nil, err); err != nil {
+				return err
+			}
+			continue
+		}
+
+		req, err := csvRecordToRequest(columns, record)
+		if err := fn(row, req, err); err != nil {
+			return err
+		}
+	}
+}
+
+func csvRecordToRequest(columns map[string]int, record []string) (*domain.BookStoreRequest, error) {
+	value := func(name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	number := func(name string) (int, error) {
+		v := value(name)
+		if v == "" {
+			return 0, nil
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, fmt.Errorf("%s must be an integer", name)
+		}
+		return n, nil
+	}
+
+	req := &domain.BookStoreRequest{
+		Title:       value("title"),
+		Author:      value("author"),
+		Description: value("description"),
+		Isbn:        value("isbn"),
+		Language:    value("language"),
+		PublishedAt: value("published_at"),
+	}
+
+	var errs []error
+	var err error
+	if req.Price, err = number("price"); err != nil {
+		errs = append(errs, err)
+	}
+	if req.Pages, err = number("pages"); err != nil {
+		errs = append(errs, err)
+	}
+	if req.Stock, err = number("stock"); err != nil {
+		errs = append(errs, err)
+	}
+
+	return req, errors.Join(errs...)
+}
+
+// parseJSONL expects one BookStoreRequest object per line, blank lines are skipped
+func parseJSONL(r io.Reader, fn rowFunc) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	row := 0
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		row++
+
+		var req domain.BookStoreRequest
+		if err := json.Unmarshal([]byte(line), &req); err != nil {
+			if err := fn(row, nil, fmt.Errorf("invalid json: %w", err)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := fn(row, &req, nil); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
@@ -0,0 +1,299 @@
+package catalog
+
+import (
+	"book-store/internal/config"
+	"book-store/internal/domain"
+	"book-store/internal/middleware/validation"
+	"book-store/internal/tracing"
+	"book-store/pkg/xlogger"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// maxReportedErrors caps the row errors kept in a report, failures past it are only counted
+const maxReportedErrors = 1000
+
+const importJobType = "book_import"
+
+type importJobPayload struct {
+	UploadId uint                `json:"upload_id"`
+	Format   domain.ImportFormat `json:"format"`
+	DryRun   bool                `json:"dry_run"`
+}
+
+type catalogService struct {
+	bookRepo   domain.BookRepository
+	uploadRepo domain.ImportUploadRepository
+	jobSvc     domain.JobService
+	cfg        config.Store
+}
+
+// Import validates every row like POST /books does and upserts the books by ISBN,
+// nothing is written in dry run mode
+func (s *catalogService) Import(ctx context.Context, r io.Reader, format domain.ImportFormat, dryRun bool) (*domain.BookImportReport, error) {
+	ctx, span := tracing.Start(ctx, "CatalogService.Import")
+	defer span.End()
+
+	report := &domain.BookImportReport{
+		Status:    domain.ImportStatusRunning,
+		DryRun:    dryRun,
+		Errors:    []*domain.BookImportRowError{},
+		StartedAt: time.Now(),
+	}
+
+	// isbn seen earlier in the same file, a dry run would have created them by now
+	seen := make(map[string]bool)
+
+	err := parseRows(r, format, s.cfg.Currency, func(row int, req *domain.BookStoreRequest, err error) error {
+		report.Total++
+
+		if err != nil {
+			addRowError(report, row, req, err.Error())
+			return nil
+		}
+
+		// onix feeds rarely carry stock, existing books keep theirs and new ones start empty
+		validate := validation.Struct
+		if format == domain.ImportFormatONIX {
+			validate = func(v any) []string { return validation.StructExcept(v, "Stock") }
+		}
+
+		if errs := validate(req); errs != nil {
+			addRowError(report, row, req, errs...)
+			return nil
+		}
+
+		publishedAt, err := time.Parse("02-01-2006", req.PublishedAt)
+		if err != nil {
+			addRowError(report, row, req, "invalid published at format, should be dd-mm-yyyy")
+			return nil
+		}
+
+		book := &domain.Book{
+			Title:       req.Title,
+			Author:      req.Author,
+			Price:       req.Price,
+			Description: req.Description,
+			Pages:       req.Pages,
+			Isbn:        req.Isbn,
+			Language:    req.Language,
+			Stock:       req.Stock,
+			PublishedAt: publishedAt,
+		}
+
+		created, err := s.upsert(ctx, book, dryRun)
+		if err != nil {
+			addRowError(report, row, req, err.Error())
+			return nil
+		}
+
+		if created && !seen[book.Isbn] {
+			report.Created++
+		} else {
+			report.Updated++
+		}
+		seen[book.Isbn] = true
+
+		return nil
+	})
+
+	finishedAt := time.Now()
+	report.FinishedAt = &finishedAt
+
+	if err != nil {
+		report.Status = domain.ImportStatusFailed
+		report.Message = err.Error()
+		xlogger.Ctx(ctx).Error().Err(err).Str("format", string(format)).Int("rows", report.Total).Msg("book import failed")
+		return report, err
+	}
+
+	report.Status = domain.ImportStatusDone
+	xlogger.Ctx(ctx).Info().
+		Str("format", string(format)).
+		Bool("dry_run", dryRun).
+		Int("created", report.Created).
+		Int("updated", report.Updated).
+		Int("failed", report.Failed).
+		Msg("book import finished")
+	return report, nil
+}
+
+// ImportAsync stores the file and queues its import as a background job that removes the file once the import
+// finished, the returned report only carries the job id to poll
+func (s *catalogService) ImportAsync(ctx context.Context, r io.Reader, format domain.ImportFormat, dryRun bool) (*domain.BookImportReport, error) {
+	ctx, span := tracing.Start(ctx, "CatalogService.ImportAsync")
+	defer span.End()
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	upload := &domain.ImportUpload{Data: data}
+	if err := s.uploadRepo.Store(ctx, upload); err != nil {
+		return nil, err
+	}
+
+	// a file that failed to parse won't parse on retry, it is reported rather than failing the job
+	job, err := s.jobSvc.Enqueue(ctx, importJobType, &importJobPayload{
+		UploadId: upload.ID,
+		Format:   format,
+		DryRun:   dryRun,
+	}, &domain.JobOptions{MaxAttempts: 1})
+	if err != nil {
+		return nil, errors.Join(err, s.uploadRepo.Delete(ctx, upload.ID))
+	}
+
+	return &domain.BookImportReport{
+		JobId:     strconv.FormatUint(uint64(job.ID), 10),
+		Status:    domain.ImportStatusRunning,
+		DryRun:    dryRun,
+		Errors:    []*domain.BookImportRowError{},
+		StartedAt: job.CreatedAt,
+	}, nil
+}
+
+// GetImportJob reads the report of an import job, it is only complete once the job is done
+func (s *catalogService) GetImportJob(ctx context.Context, jobId string) (*domain.BookImportReport, error) {
+	ctx, span := tracing.Start(ctx, "CatalogService.GetImportJob")
+	defer span.End()
+
+	id, err := strconv.ParseUint(jobId, 10, 64)
+	if err != nil {
+		return nil, domain.ErrImportJobNotFound
+	}
+
+	job, err := s.jobSvc.GetById(ctx, uint(id))
+	if err != nil {
+		if errors.Is(err, domain.ErrJobNotFound) {
+			return nil, domain.ErrImportJobNotFound
+		}
+		return nil, err
+	}
+
+	if job.Type != importJobType {
+		return nil, domain.ErrImportJobNotFound
+	}
+
+	var payload importJobPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return nil, err
+	}
+
+	report := &domain.BookImportReport{
+		JobId:     jobId,
+		Status:    domain.ImportStatusRunning,
+		DryRun:    payload.DryRun,
+		Errors:    []*domain.BookImportRowError{},
+		StartedAt: job.CreatedAt,
+	}
+
+	switch job.Status {
+	case domain.JobStatusDone:
+		if err := json.Unmarshal(job.Result, report); err != nil {
+			return nil, err
+		}
+		report.JobId = jobId
+	case domain.JobStatusDead:
+		report.Status = domain.ImportStatusFailed
+		report.Message = job.LastError
+		report.FinishedAt = job.FinishedAt
+	}
+
+	return report, nil
+}
+
+// runImportJob reports a file that can't be parsed as a failed import rather than a failed job. The file is kept
+// when the job fails, so retrying the dead job imports it
+func (s *catalogService) runImportJob(ctx context.Context, job *domain.Job) (any, error) {
+	var payload importJobPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return nil, err
+	}
+
+	upload, err := s.uploadRepo.GetById(ctx, payload.UploadId)
+	if err != nil {
+		return nil, err
+	}
+
+	report, _ := s.Import(ctx, bytes.NewReader(upload.Data), payload.Format, payload.DryRun)
+	report.StartedAt = job.CreatedAt
+
+	if err := s.uploadRepo.Delete(ctx, upload.ID); err != nil {
+		xlogger.Ctx(ctx).Warn().Err(err).Uint("upload_id", upload.ID).Msg("failed to remove import upload")
+	}
+
+	return report, nil
+}
+
+// ExportONIX
+func (s *catalogService) ExportONIX(ctx context.Context, w io.Writer) error {
+	ctx, span := tracing.Start(ctx, "CatalogService.ExportONIX")
+	defer span.End()
+
+	return writeONIX(w, s.cfg, func(fn func(book *domain.Book) error) error {
+		return s.bookRepo.Each(ctx, &domain.Book{}, fn)
+	})
+}
+
+// upsert stores the book or updates the one with the same ISBN, restoring it when it was deleted. It only looks
+// the book up when dryRun is set
+func (s *catalogService) upsert(ctx context.Context, book *domain.Book, dryRun bool) (bool, error) {
+	existing, err := s.bookRepo.GetByIsbn(ctx, book.Isbn)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, err
+	}
+
+	if existing == nil {
+		if dryRun {
+			return true, nil
+		}
+		return true, s.bookRepo.Store(ctx, book)
+	}
+
+	if dryRun {
+		return false, nil
+	}
+
+	if existing.DeletedAt.Valid {
+		if err := s.bookRepo.Restore(ctx, existing.ID); err != nil {
+			return false, err
+		}
+	}
+
+	book.ID = existing.ID
+	return false, s.bookRepo.Update(ctx, book)
+}
+
+func addRowError(report *domain.BookImportReport, row int, req *domain.BookStoreRequest, errs ...string) {
+	report.Failed++
+	if len(report.Errors) >= maxReportedErrors {
+		return
+	}
+
+	rowError := &domain.BookImportRowError{Row: row, Errors: errs}
+	if req != nil {
+		rowError.Isbn = req.Isbn
+	}
+	report.Errors = append(report.Errors, rowError)
+}
+
+func NewCatalogService(bookRepo domain.BookRepository, uploadRepo domain.ImportUploadRepository, jobSvc domain.JobService, jobRunner domain.JobRunner, cfg config.Store) domain.CatalogService {
+	s := &catalogService{
+		bookRepo:   bookRepo,
+		uploadRepo: uploadRepo,
+		jobSvc:     jobSvc,
+		cfg:        cfg,
+	}
+
+	jobRunner.Register(importJobType, 1, s.runImportJob)
+
+	return s
+}
@@ -1,24 +1,237 @@
 package config
 
-import "time"
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
 
 type Config struct {
-	Host          string   `env:"HOST,notEmpty"`
-	Port          int      `env:"PORT" envDefault:"8080"`
-	IsDevelopment bool     `env:"IS_DEVELOPMENT,notEmpty" envDefault:"true"`
-	ProxyHeader   string   `env:"PROXY_HEADER" envDefault:"X-Real-IP"`
-	LogFields     []string `env:"LOG_FIELDS" envSeparator:","`
-	Database      Database
-	JwtConfig     JwtConfig
+	Host          string `env:"HOST,notEmpty"`
+	Port          int    `env:"PORT" envDefault:"8080"`
+	IsDevelopment bool   `env:"IS_DEVELOPMENT,notEmpty" envDefault:"true"`
+	ProxyHeader   string `env:"PROXY_HEADER" envDefault:"X-Real-IP"`
+	// TrustedProxies are the ips or CIDR ranges whose ProxyHeader is believed, the client ip of requests from
+	// anywhere else is the remote address
+	TrustedProxies []string `env:"TRUSTED_PROXIES" envSeparator:","`
+	LogFields      []string `env:"LOG_FIELDS" envSeparator:","`
+	// LogLevel and LogFormat default to debug on the console in development, info as json otherwise
+	LogLevel  string `env:"LOG_LEVEL"`
+	LogFormat string `env:"LOG_FORMAT"`
+	Database  Database
+	JwtConfig JwtConfig
+	Store     Store
+	Loyalty   Loyalty
+	Report    Report
+	Import    Import
+	Job       Job
+	Shutdown  Shutdown
+	Health    Health
+	Metrics   Metrics
+	Tracing   Tracing
+	Purge     Purge
+	Login     Login
+	RateLimit RateLimit
+	Password  Password
+	Account   Account
+	TwoFactor TwoFactor
+}
+
+// Validate checks what the env tags can't, so a misconfigured instance fails at startup
+func (c Config) Validate() error {
+	return errors.Join(
+		c.Loyalty.Validate(),
+		c.TwoFactor.Validate(),
+	)
+}
+
+type Store struct {
+	Code    string `env:"STORE_CODE" envDefault:"MAIN"`
+	Name    string `env:"STORE_NAME" envDefault:"Book Store"`
+	Address string `env:"STORE_ADDRESS"`
+	// Currency is the ISO 4217 code book prices are in
+	Currency string `env:"STORE_CURRENCY" envDefault:"IDR"`
 }
 
 type Database struct {
 	Driver string `env:"DB_DRIVER" envDefault:"sqlite"`
 	DSN    string `env:"DB_DSN" envDefault:"file::memory:?cache=shared"`
+	// SlowQueryThreshold logs slower queries as warnings, 0 disables it
+	SlowQueryThreshold time.Duration `env:"DB_SLOW_QUERY_THRESHOLD" envDefault:"200ms"`
 }
 
 type JwtConfig struct {
-	PrivateKey string        `env:"JWT_PRIVATE_KEY,notEmpty" envDefault:""`
-	PublicKey  string        `env:"JWT_PUBLIC_KEY,notEmpty" envDefault:""`
-	ExpiresIn  time.Duration `env:"JWT_EXPIRES_IN,notEmpty" envDefault:"24h"`
+	// PrivateKey signs every token, a base64 encoded PEM RSA, ECDSA (P-256, P-384, P-521) or Ed25519 key
+	PrivateKey string `env:"JWT_PRIVATE_KEY,notEmpty" envDefault:""`
+	// VerificationKeys are base64 encoded PEM public keys accepted besides the private key, e.g. the next key
+	// before it signs and the previous one until its tokens expire
+	VerificationKeys []string      `env:"JWT_VERIFICATION_KEYS" envSeparator:","`
+	Issuer           string        `env:"JWT_ISSUER" envDefault:"book-store"`
+	Audience         string        `env:"JWT_AUDIENCE" envDefault:"book-store"`
+	ExpiresIn        time.Duration `env:"JWT_EXPIRES_IN,notEmpty" envDefault:"24h"`
+}
+
+type Loyalty struct {
+	// AmountPerPoint is how much a customer spends to earn one base point
+	AmountPerPoint int `env:"LOYALTY_AMOUNT_PER_POINT" envDefault:"10000"`
+	// PointValue is the money value of one point when redeemed
+	PointValue int `env:"LOYALTY_POINT_VALUE" envDefault:"100"`
+}
+
+// Validate refuses values points are divided by, they would fail every sale
+func (l Loyalty) Validate() error {
+	if l.AmountPerPoint <= 0 {
+		return fmt.Errorf("LOYALTY_AMOUNT_PER_POINT should be greater than 0, got %d", l.AmountPerPoint)
+	}
+	if l.PointValue <= 0 {
+		return fmt.Errorf("LOYALTY_POINT_VALUE should be greater than 0, got %d", l.PointValue)
+	}
+
+	return nil
+}
+
+type Report struct {
+	Timezone string        `env:"REPORT_TIMEZONE" envDefault:"UTC"`
+	CacheTTL time.Duration `env:"REPORT_CACHE_TTL" envDefault:"1h"`
+	// CacheMaxEntries bounds the reports kept in memory, 0 turns the cache off
+	CacheMaxEntries int `env:"REPORT_CACHE_MAX_ENTRIES" envDefault:"1000"`
+}
+
+type Import struct {
+	// BackgroundThreshold is the upload size in bytes above which imports run as a background job
+	BackgroundThreshold int64 `env:"IMPORT_BACKGROUND_THRESHOLD" envDefault:"1048576"`
+}
+
+type Job struct {
+	Workers      int           `env:"JOB_WORKERS" envDefault:"4"`
+	PollInterval time.Duration `env:"JOB_POLL_INTERVAL" envDefault:"1s"`
+	// Timeout cancels a job running longer, a job locked for twice as long is assumed abandoned
+	Timeout      time.Duration `env:"JOB_TIMEOUT" envDefault:"10m"`
+	MaxAttempts  int           `env:"JOB_MAX_ATTEMPTS" envDefault:"5"`
+	RetryBackoff time.Duration `env:"JOB_RETRY_BACKOFF" envDefault:"30s"`
+}
+
+type Shutdown struct {
+	// Delay keeps serving with readiness failing before the server stops accepting connections
+	Delay time.Duration `env:"SHUTDOWN_DELAY" envDefault:"0s"`
+	// Timeout bounds the whole drain of requests, jobs and the database pool
+	Timeout time.Duration `env:"SHUTDOWN_TIMEOUT" envDefault:"30s"`
+}
+
+type Health struct {
+	// Timeout bounds every readiness check
+	Timeout time.Duration `env:"HEALTH_CHECK_TIMEOUT" envDefault:"2s"`
+}
+
+type Metrics struct {
+	// Enabled serves /metrics and records http, database and business metrics
+	Enabled bool `env:"METRICS_ENABLED" envDefault:"true"`
+}
+
+type Tracing struct {
+	// Enabled exports spans over OTLP/HTTP, trace context is propagated either way
+	Enabled  bool   `env:"TRACING_ENABLED" envDefault:"false"`
+	Endpoint string `env:"TRACING_ENDPOINT" envDefault:"localhost:4318"`
+	Insecure bool   `env:"TRACING_INSECURE" envDefault:"true"`
+	// SampleRatio is the share of new traces kept, requests carrying a parent follow its decision
+	SampleRatio float64 `env:"TRACING_SAMPLE_RATIO" envDefault:"1"`
+	ServiceName string  `env:"TRACING_SERVICE_NAME" envDefault:"book-store"`
+}
+
+type Purge struct {
+	// Retention is how long soft-deleted records are kept before they are removed for good, 0 keeps them forever
+	Retention time.Duration `env:"PURGE_RETENTION" envDefault:"720h"`
+	Interval  time.Duration `env:"PURGE_INTERVAL" envDefault:"24h"`
+}
+
+type Login struct {
+	// MaxFailures locks an email out after as many failed logins within FailureWindow, IpMaxFailures an ip
+	MaxFailures   int           `env:"LOGIN_MAX_FAILURES" envDefault:"5"`
+	IpMaxFailures int           `env:"LOGIN_IP_MAX_FAILURES" envDefault:"20"`
+	FailureWindow time.Duration `env:"LOGIN_FAILURE_WINDOW" envDefault:"15m"`
+	Lockout       time.Duration `env:"LOGIN_LOCKOUT" envDefault:"15m"`
+	// Delay is waited before checking a password after one failure, it doubles with every failure up to MaxDelay
+	Delay    time.Duration `env:"LOGIN_DELAY" envDefault:"250ms"`
+	MaxDelay time.Duration `env:"LOGIN_MAX_DELAY" envDefault:"5s"`
+}
+
+type Password struct {
+	// MinLength counts characters, passwords longer than 72 bytes are refused whatever the policy as bcrypt ignores the rest
+	MinLength     int  `env:"PASSWORD_MIN_LENGTH" envDefault:"10"`
+	RequireUpper  bool `env:"PASSWORD_REQUIRE_UPPER" envDefault:"true"`
+	RequireLower  bool `env:"PASSWORD_REQUIRE_LOWER" envDefault:"true"`
+	RequireDigit  bool `env:"PASSWORD_REQUIRE_DIGIT" envDefault:"true"`
+	RequireSymbol bool `env:"PASSWORD_REQUIRE_SYMBOL" envDefault:"false"`
+	// ResetTokenTTL is how long a reset token handed out by an admin can be used
+	ResetTokenTTL time.Duration `env:"PASSWORD_RESET_TOKEN_TTL" envDefault:"1h"`
+}
+
+type Account struct {
+	// EmailVerificationTTL is how long the link sent to a new email address can be used
+	EmailVerificationTTL time.Duration `env:"ACCOUNT_EMAIL_VERIFICATION_TTL" envDefault:"24h"`
+}
+
+type TwoFactor struct {
+	// Issuer names the account in authenticator apps
+	Issuer string `env:"TWO_FACTOR_ISSUER" envDefault:"Book Store"`
+	// RequiredRoles can't use the api before they set up two-factor authentication, e.g. admin
+	RequiredRoles []string `env:"TWO_FACTOR_REQUIRED_ROLES" envSeparator:","`
+	// ChallengeTTL is how long the code can be entered after the password, MaxAttempts how many times
+	ChallengeTTL time.Duration `env:"TWO_FACTOR_CHALLENGE_TTL" envDefault:"5m"`
+	MaxAttempts  int           `env:"TWO_FACTOR_MAX_ATTEMPTS" envDefault:"5"`
+	// SecretKey encrypts the TOTP secrets at rest, a base64 encoded 32 byte AES-256 key
+	SecretKey string `env:"TWO_FACTOR_SECRET_KEY,notEmpty"`
+}
+
+// Validate refuses secret keys that aren't AES-256 keys, no secret could be read back
+func (t TwoFactor) Validate() error {
+	key, err := base64.StdEncoding.DecodeString(t.SecretKey)
+	if err != nil {
+		return fmt.Errorf("TWO_FACTOR_SECRET_KEY should be base64 encoded: %w", err)
+	}
+	if len(key) != 32 {
+		return fmt.Errorf("TWO_FACTOR_SECRET_KEY should be 32 bytes long, got %d", len(key))
+	}
+
+	return nil
+}
+
+// Requires
+func (t TwoFactor) Requires(role string) bool {
+	return slices.Contains(t.RequiredRoles, role)
+}
+
+type RateLimit struct {
+	Enabled bool `env:"RATE_LIMIT_ENABLED" envDefault:"true"`
+	// Default applies to every route group without a policy of its own
+	Default RateLimitPolicy `env:"RATE_LIMIT_DEFAULT" envDefault:"300/1m"`
+	Auth    RateLimitPolicy `env:"RATE_LIMIT_AUTH" envDefault:"10/1m"`
+	Books   RateLimitPolicy `env:"RATE_LIMIT_BOOKS" envDefault:"600/1m"`
+}
+
+// RateLimitPolicy allows Limit requests per Window, it is written as limit/window, e.g. 100/1m. A limit of 0 disables it
+type RateLimitPolicy struct {
+	Limit  int
+	Window time.Duration
+}
+
+func (p *RateLimitPolicy) UnmarshalText(text []byte) error {
+	limit, window, ok := strings.Cut(string(text), "/")
+	if !ok {
+		return fmt.Errorf("rate limit policy %q should be limit/window, e.g. 100/1m", text)
+	}
+
+	var err error
+	if p.Limit, err = strconv.Atoi(strings.TrimSpace(limit)); err != nil || p.Limit < 0 {
+		return fmt.Errorf("invalid rate limit %q", limit)
+	}
+	if p.Window, err = time.ParseDuration(strings.TrimSpace(window)); err != nil || p.Window <= 0 {
+		return fmt.Errorf("invalid rate limit window %q", window)
+	}
+
+	return nil
 }
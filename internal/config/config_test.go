@@ -0,0 +1,60 @@
+package config
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+)
+
+func TestRateLimitPolicyUnmarshalText(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    RateLimitPolicy
+		wantErr bool
+	}{
+		{text: "100/1m", want: RateLimitPolicy{Limit: 100, Window: time.Minute}},
+		{text: " 5 / 30s ", want: RateLimitPolicy{Limit: 5, Window: 30 * time.Second}},
+		{text: "0/1h", want: RateLimitPolicy{Limit: 0, Window: time.Hour}},
+		{text: "100", wantErr: true},
+		{text: "", wantErr: true},
+		{text: "-1/1m", wantErr: true},
+		{text: "many/1m", wantErr: true},
+		{text: "100/minute", wantErr: true},
+		{text: "100/0s", wantErr: true},
+		{text: "100/-1m", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			var got RateLimitPolicy
+			err := got.UnmarshalText([]byte(tt.text))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UnmarshalText(%q) error = %v, want error %v", tt.text, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("UnmarshalText(%q) = %+v, want %+v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTwoFactorValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		wantErr bool
+	}{
+		{name: "32 bytes", key: base64.StdEncoding.EncodeToString(make([]byte, 32))},
+		{name: "24 bytes", key: base64.StdEncoding.EncodeToString(make([]byte, 24)), wantErr: true},
+		{name: "not base64", key: "not a key", wantErr: true},
+		{name: "empty", key: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := (TwoFactor{SecretKey: tt.key}).Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,9 +1,9 @@
 package customer
 
 import (
-	"errors"
 	"book-store/internal/domain"
 	"book-store/internal/middleware/jwt"
+	"book-store/internal/middleware/softdelete"
 	"book-store/internal/middleware/validation"
 	"book-store/internal/utilities"
 	"strconv"
@@ -23,11 +23,17 @@ func NewHttpHandler(r fiber.Router, customerSvc domain.CustomerService, authMidd
 		authMiddleware: authMiddleware,
 	}
 
-	r.Get("/", handler.Fetch)
-	r.Get("/:id", handler.GetById)
+	r.Get("/", softdelete.New(authMiddleware), handler.Fetch)
+	r.Get("/export", authMiddleware.RequireRole("admin", "employee"), handler.Export)
+	r.Get("/:id", softdelete.New(authMiddleware), handler.GetById)
+	r.Get("/:id/transactions", authMiddleware.RequireRole("admin", "employee"), handler.FetchTransactions)
+	r.Get("/:id/stats", authMiddleware.RequireRole("admin", "employee"), handler.GetStats)
+	r.Get("/:id/duplicates", authMiddleware.RequireRole("admin", "employee"), handler.FindDuplicates)
+	r.Post("/:id/merge", authMiddleware.RequireRole("admin"), validation.New[domain.CustomerMergeRequest](), handler.Merge)
 	r.Post("/", authMiddleware.RequireRole("admin", "employee"), validation.New[domain.CustomerStoreRequest](), handler.Store)
 	r.Put("/:id", authMiddleware.RequireRole("admin", "employee"), validation.New[domain.CustomerUpdateRequest](), handler.Update)
 	r.Delete("/:id", authMiddleware.RequireRole("admin"), handler.Delete)
+	r.Post("/:id/restore", authMiddleware.RequireRole("admin"), handler.Restore)
 }
 
 // Fetch used to get list of customer
@@ -40,6 +46,7 @@ func NewHttpHandler(r fiber.Router, customerSvc domain.CustomerService, authMidd
 //	@Param			page	query		int				false	"Page number (default 1)"
 //	@Param			size	query		int				false	"Size of page (default 10)"
 //	@Param			q		query		string			false	"Search query"
+//	@Param			include_deleted	query		bool			false	"Include soft-deleted records, admin only"
 //	@Header			200		{string}	X-Cursor		"Next page"
 //	@Header			200		{string}	X-Total-Count	"Total item"
 //	@Header			200		{string}	X-Max-Page		"Max page"
@@ -50,40 +57,25 @@ func NewHttpHandler(r fiber.Router, customerSvc domain.CustomerService, authMidd
 func (h *HttpCustomerHandler) Fetch(c *fiber.Ctx) error {
 	page, size, query := c.QueryInt("page", 1), c.QueryInt("size", 10), c.Query("q")
 	if page <= 0 {
-		return c.Status(fiber.StatusBadRequest).JSON(domain.Error{
-			Code:    fiber.StatusBadRequest,
-			Message: "page must be a positive integer",
-		})
+		return domain.NewValidationError("page must be a positive integer")
 	}
 	if size <= 0 {
-		return c.Status(fiber.StatusBadRequest).JSON(domain.Error{
-			Code:    fiber.StatusBadRequest,
-			Message: "size must be a positive integer",
-		})
+		return domain.NewValidationError("size must be a positive integer")
 	}
 
 	filter := &domain.Customer{Name: query}
-	customers, nextPage, err := h.customerSvc.Fetch(page, size, filter)
+	customers, nextPage, err := h.customerSvc.Fetch(c.UserContext(), page, size, filter)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(domain.Error{
-			Code:    fiber.StatusInternalServerError,
-			Message: err.Error(),
-		})
+		return err
 	}
 
 	if customers == nil {
-		return c.Status(fiber.StatusNotFound).JSON(domain.Error{
-			Code:    fiber.StatusNotFound,
-			Message: "customers not found",
-		})
+		return domain.NewNotFoundError("customers")
 	}
 
-	totalItem, err := h.customerSvc.Count(filter)
+	totalItem, err := h.customerSvc.Count(c.UserContext(), filter)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(domain.Error{
-			Code:    fiber.StatusInternalServerError,
-			Message: err.Error(),
-		})
+		return err
 	}
 
 	maxPage := int(totalItem) / size
@@ -100,6 +92,33 @@ func (h *HttpCustomerHandler) Fetch(c *fiber.Ctx) error {
 	})
 }
 
+// Export used to download customers as csv or xlsx
+//
+//	@Summary		Export customers
+//	@Description	Stream the customers matching the list filters as csv or xlsx
+//	@Tags			customers
+//	@Produce		text/csv
+//	@Produce		application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+//	@Param			format	query		string			false	"Export format (csv, xlsx), default csv"
+//	@Param			q		query		string			false	"Search query"
+//	@Success		200		{file}		file			"customers export"
+//	@Failure		400		{object}	domain.Error	"Bad Request"
+//	@Router			/customers/export [get]
+//
+// @Security Bearer
+func (h *HttpCustomerHandler) Export(c *fiber.Ctx) error {
+	format, query := domain.ExportFormat(c.Query("format", string(domain.ExportFormatCSV))), c.Query("q")
+
+	filter := &domain.Customer{Name: query}
+	header := []string{"id", "name", "email", "phone_number", "loyalty_points", "loyalty_tier", "created_at"}
+	ctx := c.UserContext()
+	return utilities.StreamExport(c, format, "customers", header, func(write func(values ...any) error) error {
+		return h.customerSvc.Each(ctx, filter, func(customer *domain.Customer) error {
+			return write(customer.ID, customer.Name, customer.Email, customer.PhoneNumber, customer.LoyaltyPoints, customer.LoyaltyTier, customer.CreatedAt)
+		})
+	})
+}
+
 // GetByID used to get customer by id
 //
 //	@Summary		Get customer by id
@@ -108,6 +127,7 @@ func (h *HttpCustomerHandler) Fetch(c *fiber.Ctx) error {
 //	@Accept			json
 //	@Produce		json
 //	@Param			id	path		int				true	"customer ID"
+//	@Param			include_deleted	query		bool			false	"Include soft-deleted records, admin only"
 //	@Success		200	{object}	domain.Success	"customer detail"
 //	@Failure		400	{object}	domain.Error	"Bad Request"
 //	@Failure		404	{object}	domain.Error	"Not Found"
@@ -116,24 +136,173 @@ func (h *HttpCustomerHandler) Fetch(c *fiber.Ctx) error {
 func (h *HttpCustomerHandler) GetById(c *fiber.Ctx) error {
 	id, err := c.ParamsInt("id")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(domain.Error{
-			Code:    fiber.StatusBadRequest,
-			Message: "invalid customer id",
-		})
+		return domain.NewValidationError("invalid customer id")
 	}
 
-	customer, err := h.customerSvc.GetById(uint(id))
+	customer, err := h.customerSvc.GetById(c.UserContext(), uint(id))
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return c.Status(fiber.StatusNotFound).JSON(domain.Error{
-				Code:    fiber.StatusNotFound,
-				Message: "customer not found",
-			})
-		}
-		return c.Status(fiber.StatusInternalServerError).JSON(domain.Error{
-			Code:    fiber.StatusInternalServerError,
-			Message: err.Error(),
-		})
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(domain.Success{
+		Code:    fiber.StatusOK,
+		Message: "success",
+		Data:    customer,
+	})
+}
+
+// FetchTransactions used to get purchase history of customer
+//
+//	@Summary		Get purchase history of customer
+//	@Description	Get transactions of a customer with line items and books
+//	@Tags			customers
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int				true	"customer ID"
+//	@Param			page	query		int				false	"Page number (default 1)"
+//	@Param			size	query		int				false	"Size of page (default 10)"
+//	@Header			200		{string}	X-Cursor		"Next page"
+//	@Header			200		{string}	X-Total-Count	"Total item"
+//	@Header			200		{string}	X-Max-Page		"Max page"
+//	@Success		200		{array}		domain.Success	"List of transactions"
+//	@Failure		400		{object}	domain.Error	"Bad Request"
+//	@Failure		404		{object}	domain.Error	"Not Found"
+//	@Failure		500		{object}	domain.Error	"Internal Server Error"
+//	@Router			/customers/{id}/transactions [get]
+//
+// @Security Bearer
+func (h *HttpCustomerHandler) FetchTransactions(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return domain.NewValidationError("invalid customer id")
+	}
+
+	page, size := c.QueryInt("page", 1), c.QueryInt("size", 10)
+	if page <= 0 {
+		return domain.NewValidationError("page must be a positive integer")
+	}
+	if size <= 0 {
+		return domain.NewValidationError("size must be a positive integer")
+	}
+
+	transactions, nextPage, err := h.customerSvc.FetchTransactions(c.UserContext(), uint(id), page, size)
+	if err != nil {
+		return err
+	}
+
+	totalItem, err := h.customerSvc.CountTransactions(c.UserContext(), uint(id))
+	if err != nil {
+		return err
+	}
+
+	maxPage := int(totalItem) / size
+
+	if nextPage > 0 && nextPage <= maxPage {
+		c.Set("X-Cursor", strconv.Itoa(nextPage))
+	}
+	c.Set("X-Total-Count", strconv.Itoa(int(totalItem)))
+	c.Set("X-Max-Page", strconv.Itoa(maxPage))
+
+	return c.Status(fiber.StatusOK).JSON(domain.Success{
+		Code:    fiber.StatusOK,
+		Message: "success",
+		Data:    transactions,
+	})
+}
+
+// GetStats used to get lifetime value of customer
+//
+//	@Summary		Get customer lifetime value
+//	@Description	Get lifetime spend, order count, average basket, last purchase date and favourite authors of a customer
+//	@Tags			customers
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		int				true	"customer ID"
+//	@Success		200	{object}	domain.Success	"customer stats"
+//	@Failure		400	{object}	domain.Error	"Bad Request"
+//	@Failure		404	{object}	domain.Error	"Not Found"
+//	@Failure		500	{object}	domain.Error	"Internal Server Error"
+//	@Router			/customers/{id}/stats [get]
+//
+// @Security Bearer
+func (h *HttpCustomerHandler) GetStats(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return domain.NewValidationError("invalid customer id")
+	}
+
+	stats, err := h.customerSvc.GetStats(c.UserContext(), uint(id))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(domain.Success{
+		Code:    fiber.StatusOK,
+		Message: "success",
+		Data:    stats,
+	})
+}
+
+// FindDuplicates used to find likely duplicates of customer
+//
+//	@Summary		Find duplicates of customer
+//	@Description	Find customers sharing the normalized email or phone number, or with a similar name
+//	@Tags			customers
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		int				true	"customer ID"
+//	@Success		200	{object}	domain.Success	"List of likely duplicates"
+//	@Failure		400	{object}	domain.Error	"Bad Request"
+//	@Failure		404	{object}	domain.Error	"Not Found"
+//	@Failure		500	{object}	domain.Error	"Internal Server Error"
+//	@Router			/customers/{id}/duplicates [get]
+//
+// @Security Bearer
+func (h *HttpCustomerHandler) FindDuplicates(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return domain.NewValidationError("invalid customer id")
+	}
+
+	duplicates, err := h.customerSvc.FindDuplicates(c.UserContext(), uint(id))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(domain.Success{
+		Code:    fiber.StatusOK,
+		Message: "success",
+		Data:    duplicates,
+	})
+}
+
+// Merge used to merge duplicates into customer
+//
+//	@Summary		Merge customers
+//	@Description	Reassign transactions, loyalty points and store credit of the duplicates to the customer and delete the duplicates, whose emails are renamed to merged-<id>-<email> so they can be used again
+//	@Tags			customers
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int							true	"Surviving customer ID"
+//	@Param			merge	body		domain.CustomerMergeRequest	true	"Duplicates to merge"
+//	@Success		200		{object}	domain.Success				"Merged customer"
+//	@Failure		400		{object}	domain.Error				"Bad Request"
+//	@Failure		404		{object}	domain.Error				"Not Found"
+//	@Failure		500		{object}	domain.Error				"Internal Server Error"
+//	@Router			/customers/{id}/merge [post]
+//
+// @Security Bearer
+func (h *HttpCustomerHandler) Merge(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return domain.NewValidationError("invalid customer id")
+	}
+
+	mergeReq := utilities.ExtractStructFromValidator[domain.CustomerMergeRequest](c)
+
+	customer, err := h.customerSvc.Merge(c.UserContext(), uint(id), mergeReq.DuplicateIds)
+	if err != nil {
+		return err
 	}
 
 	return c.Status(fiber.StatusOK).JSON(domain.Success{
@@ -166,11 +335,8 @@ func (h *HttpCustomerHandler) Store(c *fiber.Ctx) error {
 		PhoneNumber: customerReq.PhoneNumber,
 	}
 
-	if err := h.customerSvc.Store(customer); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(domain.Error{
-			Code:    fiber.StatusInternalServerError,
-			Message: err.Error(),
-		})
+	if err := h.customerSvc.Store(c.UserContext(), customer); err != nil {
+		return err
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(domain.Success{
@@ -199,10 +365,7 @@ func (h *HttpCustomerHandler) Store(c *fiber.Ctx) error {
 func (h *HttpCustomerHandler) Update(c *fiber.Ctx) error {
 	id, err := c.ParamsInt("id")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(domain.Error{
-			Code:    fiber.StatusBadRequest,
-			Message: "invalid customer id",
-		})
+		return domain.NewValidationError("invalid customer id")
 	}
 
 	customerReq := utilities.ExtractStructFromValidator[domain.CustomerUpdateRequest](c)
@@ -214,17 +377,8 @@ func (h *HttpCustomerHandler) Update(c *fiber.Ctx) error {
 		PhoneNumber: customerReq.PhoneNumber,
 	}
 
-	if err := h.customerSvc.Update(customer); err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return c.Status(fiber.StatusNotFound).JSON(domain.Error{
-				Code:    fiber.StatusNotFound,
-				Message: err.Error(),
-			})
-		}
-		return c.Status(fiber.StatusInternalServerError).JSON(domain.Error{
-			Code:    fiber.StatusInternalServerError,
-			Message: err.Error(),
-		})
+	if err := h.customerSvc.Update(c.UserContext(), customer); err != nil {
+		return err
 	}
 
 	return c.Status(fiber.StatusOK).JSON(domain.Success{
@@ -244,6 +398,7 @@ func (h *HttpCustomerHandler) Update(c *fiber.Ctx) error {
 //	@Param			id	path		int				true	"Customer ID"
 //	@Success		200	{object}	domain.Success	"Success delete customer"
 //	@Failure		400	{object}	domain.Error	"Bad Request"
+//	@Failure		404	{object}	domain.Error	"Not Found"
 //	@Failure		500	{object}	domain.Error	"Internal Server Error"
 //	@Router			/customers/{id} [delete]
 //
@@ -251,17 +406,11 @@ func (h *HttpCustomerHandler) Update(c *fiber.Ctx) error {
 func (h *HttpCustomerHandler) Delete(c *fiber.Ctx) error {
 	id, err := c.ParamsInt("id")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(domain.Error{
-			Code:    fiber.StatusBadRequest,
-			Message: "invalid customer id",
-		})
+		return domain.NewValidationError("invalid customer id")
 	}
 
-	if err := h.customerSvc.Delete(uint(id)); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(domain.Error{
-			Code:    fiber.StatusInternalServerError,
-			Message: err.Error(),
-		})
+	if err := h.customerSvc.Delete(c.UserContext(), uint(id)); err != nil {
+		return err
 	}
 
 	return c.Status(fiber.StatusOK).JSON(domain.Success{
@@ -269,3 +418,37 @@ func (h *HttpCustomerHandler) Delete(c *fiber.Ctx) error {
 		Message: "success",
 	})
 }
+
+// Restore used to restore a deleted customer
+//
+//	@Summary		Restore customer
+//	@Description	Restore a soft-deleted customer
+//	@Tags			customers
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		int				true	"Customer ID"
+//	@Success		200	{object}	domain.Success	"customer detail"
+//	@Failure		400	{object}	domain.Error	"Bad Request"
+//	@Failure		404	{object}	domain.Error	"Not Found"
+//	@Failure		409	{object}	domain.Error	"Not Deleted"
+//	@Failure		500	{object}	domain.Error	"Internal Server Error"
+//	@Router			/customers/{id}/restore [post]
+//
+// @Security Bearer
+func (h *HttpCustomerHandler) Restore(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return domain.NewValidationError("invalid customer id")
+	}
+
+	customer, err := h.customerSvc.Restore(c.UserContext(), uint(id))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(domain.Success{
+		Code:    fiber.StatusOK,
+		Message: "customer restored successfully",
+		Data:    customer,
+	})
+}
@@ -2,8 +2,14 @@ package customer
 
 import (
 	"book-store/internal/domain"
+	"book-store/internal/utilities"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type mysqlCustomerRepository struct {
@@ -11,9 +17,9 @@ type mysqlCustomerRepository struct {
 }
 
 // Count
-func (m *mysqlCustomerRepository) Count(filter *domain.Customer) (int64, error) {
+func (m *mysqlCustomerRepository) Count(ctx context.Context, filter *domain.Customer) (int64, error) {
 	var count int64
-	query := m.db.Model(&domain.Customer{})
+	query := utilities.Scoped(ctx, m.db).Model(&domain.Customer{})
 
 	if filter.Name != "" {
 		query = query.Where("name LIKE ?", "%"+filter.Name+"%")
@@ -27,11 +33,11 @@ func (m *mysqlCustomerRepository) Count(filter *domain.Customer) (int64, error)
 }
 
 // Fetch
-func (m *mysqlCustomerRepository) Fetch(page int, size int, filter *domain.Customer) ([]*domain.Customer, int, error) {
+func (m *mysqlCustomerRepository) Fetch(ctx context.Context, page int, size int, filter *domain.Customer) ([]*domain.Customer, int, error) {
 	var customers []*domain.Customer
 
 	offset := (page - 1) * size
-	query := m.db
+	query := utilities.Scoped(ctx, m.db)
 
 	if filter.Name != "" {
 		query = query.Where("name LIKE ?", "%"+filter.Name+"%")
@@ -50,10 +56,10 @@ func (m *mysqlCustomerRepository) Fetch(page int, size int, filter *domain.Custo
 }
 
 // GetById
-func (m *mysqlCustomerRepository) GetById(id uint) (*domain.Customer, error) {
+func (m *mysqlCustomerRepository) GetById(ctx context.Context, id uint) (*domain.Customer, error) {
 	var customer *domain.Customer
 
-	if err := m.db.First(&customer, id).Error; err != nil {
+	if err := utilities.Scoped(ctx, m.db).First(&customer, id).Error; err != nil {
 		return nil, err
 	}
 
@@ -61,18 +67,204 @@ func (m *mysqlCustomerRepository) GetById(id uint) (*domain.Customer, error) {
 }
 
 // Store
-func (m *mysqlCustomerRepository) Store(customer *domain.Customer) error {
-	return m.db.Create(customer).Error
+func (m *mysqlCustomerRepository) Store(ctx context.Context, customer *domain.Customer) error {
+	return m.db.WithContext(ctx).Create(customer).Error
 }
 
-// Update
-func (m *mysqlCustomerRepository) Update(customer *domain.Customer) error {
-	return m.db.Updates(customer).Error
+// Update returns gorm.ErrRecordNotFound when there is no such customer
+func (m *mysqlCustomerRepository) Update(ctx context.Context, customer *domain.Customer) error {
+	db := m.db.WithContext(ctx)
+	return utilities.RequireRows(db, db.Updates(customer), &domain.Customer{}, customer.ID)
 }
 
-// Delete
-func (m *mysqlCustomerRepository) Delete(id uint) error {
-	return m.db.Delete(&domain.Customer{}, id).Error
+// Delete returns gorm.ErrRecordNotFound when there is no such customer
+func (m *mysqlCustomerRepository) Delete(ctx context.Context, id uint) error {
+	db := m.db.WithContext(ctx)
+	return utilities.RequireRows(db, db.Delete(&domain.Customer{}, id), &domain.Customer{}, id)
+}
+
+// Restore
+func (m *mysqlCustomerRepository) Restore(ctx context.Context, id uint) error {
+	return utilities.Restore(m.db.WithContext(ctx), &domain.Customer{}, id)
+}
+
+// Purge keeps customers with transactions, loyalty points or store credit, their history outlives the customer
+func (m *mysqlCustomerRepository) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	transactions := m.db.Unscoped().Model(&domain.Transaction{}).Select("1").Where("transactions.customer_id = customers.id")
+	ledger := m.db.Unscoped().Model(&domain.LoyaltyLedger{}).Select("1").Where("loyalty_ledgers.customer_id = customers.id")
+	credit := m.db.Unscoped().Model(&domain.StoreCreditLedger{}).Select("1").Where("store_credit_ledgers.customer_id = customers.id")
+
+	result := m.db.WithContext(ctx).Unscoped().
+		Where("deleted_at < ? AND NOT EXISTS (?) AND NOT EXISTS (?) AND NOT EXISTS (?)", deletedBefore, transactions, ledger, credit).
+		Delete(&domain.Customer{})
+	return result.RowsAffected, result.Error
+}
+
+// Each walks every customer matching the filter in batches
+func (m *mysqlCustomerRepository) Each(ctx context.Context, filter *domain.Customer, fn func(customer *domain.Customer) error) error {
+	var customers []*domain.Customer
+
+	query := m.db.WithContext(ctx)
+	if filter.Name != "" {
+		query = query.Where("name LIKE ?", "%"+filter.Name+"%")
+	}
+
+	return query.FindInBatches(&customers, 500, func(tx *gorm.DB, batch int) error {
+		for _, customer := range customers {
+			if err := fn(customer); err != nil {
+				return err
+			}
+		}
+		return nil
+	}).Error
+}
+
+// GetStats computes the lifetime value of a customer from their paid transactions
+func (m *mysqlCustomerRepository) GetStats(ctx context.Context, id uint) (*domain.CustomerStats, error) {
+	var totals struct {
+		OrderCount     int
+		LifetimeSpend  int
+		LastPurchaseAt sql.NullTime
+	}
+
+	if err := m.db.WithContext(ctx).Model(&domain.Transaction{}).
+		Select("COUNT(*) AS order_count, COALESCE(SUM(total_price), 0) AS lifetime_spend, MAX(created_at) AS last_purchase_at").
+		Where("customer_id = ? AND status = ?", id, domain.TransactionStatusPaid).
+		Scan(&totals).Error; err != nil {
+		return nil, err
+	}
+
+	stats := &domain.CustomerStats{
+		CustomerId:       id,
+		LifetimeSpend:    totals.LifetimeSpend,
+		OrderCount:       totals.OrderCount,
+		FavouriteAuthors: []*domain.AuthorCount{},
+	}
+	if totals.OrderCount > 0 {
+		stats.AverageBasket = totals.LifetimeSpend / totals.OrderCount
+	}
+	if totals.LastPurchaseAt.Valid {
+		stats.LastPurchaseAt = &totals.LastPurchaseAt.Time
+	}
+
+	// books are joined without the soft delete scope so removed titles still count
+	if err := m.db.WithContext(ctx).Table("transaction_details").
+		Select("books.author AS author, SUM(transaction_details.quantity) AS quantity").
+		Joins("JOIN transactions ON transactions.id = transaction_details.transaction_id").
+		Joins("JOIN books ON books.id = transaction_details.book_id").
+		Where("transactions.customer_id = ? AND transactions.status = ?", id, domain.TransactionStatusPaid).
+		Where("transactions.deleted_at IS NULL AND transaction_details.deleted_at IS NULL").
+		Group("books.author").
+		Order("quantity DESC").
+		Limit(3).
+		Scan(&stats.FavouriteAuthors).Error; err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// FetchDuplicateCandidates returns customers sharing the contact details of customer, then customers with a similar
+// sounding name up to 50 candidates in total
+func (m *mysqlCustomerRepository) FetchDuplicateCandidates(ctx context.Context, customer *domain.Customer) ([]*domain.Customer, error) {
+	var customers []*domain.Customer
+
+	// exact matches are looked up on their own, a common sounding name could otherwise crowd them out of the limit
+	if customer.NormalizedEmail != "" || customer.NormalizedPhone != "" {
+		conditions := m.db.WithContext(ctx)
+		if customer.NormalizedEmail != "" {
+			conditions = conditions.Or("normalized_email = ?", customer.NormalizedEmail)
+		}
+		if customer.NormalizedPhone != "" {
+			conditions = conditions.Or("normalized_phone = ?", customer.NormalizedPhone)
+		}
+
+		if err := m.db.WithContext(ctx).Where("id <> ?", customer.ID).Where(conditions).Order("id").Limit(50).Find(&customers).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	if len(customers) >= 50 {
+		return customers, nil
+	}
+
+	excludedIds := []uint{customer.ID}
+	for _, candidate := range customers {
+		excludedIds = append(excludedIds, candidate.ID)
+	}
+
+	var similar []*domain.Customer
+	if err := m.db.WithContext(ctx).
+		Where("id NOT IN ?", excludedIds).
+		Where("SOUNDEX(name) = SOUNDEX(?)", customer.Name).
+		Order("id").
+		Limit(50 - len(customers)).
+		Find(&similar).Error; err != nil {
+		return nil, err
+	}
+
+	return append(customers, similar...), nil
+}
+
+// Merge moves every reference of the duplicates to the survivor, adds up their loyalty points
+// and deletes the duplicates, all in one database transaction
+func (m *mysqlCustomerRepository) Merge(ctx context.Context, survivorId uint, duplicateIds []uint) error {
+	return m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var survivor domain.Customer
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&survivor, survivorId).Error; err != nil {
+			return err
+		}
+
+		var duplicates []*domain.Customer
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Find(&duplicates, duplicateIds).Error; err != nil {
+			return err
+		}
+		if len(duplicates) != len(duplicateIds) {
+			return gorm.ErrRecordNotFound
+		}
+
+		// deleted transactions and entries move too, none may point at a duplicate once it is merged away
+		if err := tx.Unscoped().Model(&domain.Transaction{}).Where("customer_id IN ?", duplicateIds).Update("customer_id", survivorId).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Unscoped().Model(&domain.LoyaltyLedger{}).Where("customer_id IN ?", duplicateIds).Update("customer_id", survivorId).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Unscoped().Model(&domain.StoreCreditLedger{}).Where("customer_id IN ?", duplicateIds).Update("customer_id", survivorId).Error; err != nil {
+			return err
+		}
+
+		loyaltyPoints, lifetimePoints, storeCredit := survivor.LoyaltyPoints, survivor.LifetimePoints, survivor.StoreCredit
+		for _, duplicate := range duplicates {
+			loyaltyPoints += duplicate.LoyaltyPoints
+			lifetimePoints += duplicate.LifetimePoints
+			storeCredit += duplicate.StoreCredit
+		}
+		tier, _ := domain.TierFor(lifetimePoints)
+
+		if err := tx.Model(&survivor).Updates(map[string]any{
+			"loyalty_points":  loyaltyPoints,
+			"lifetime_points": lifetimePoints,
+			"loyalty_tier":    tier.Name,
+			"store_credit":    storeCredit,
+		}).Error; err != nil {
+			return err
+		}
+
+		// the email of a duplicate is unique even once deleted, it is set aside so it can be used again
+		for _, duplicate := range duplicates {
+			if err := tx.Model(duplicate).Updates(map[string]any{
+				"email":            fmt.Sprintf("merged-%d-%s", duplicate.ID, duplicate.Email),
+				"normalized_email": "",
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Delete(&domain.Customer{}, duplicateIds).Error
+	})
 }
 
 func NewMysqlCustomerRepository(db *gorm.DB) domain.CustomerRepository {
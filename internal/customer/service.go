@@ -1,20 +1,28 @@
 package customer
 
 import (
-	"errors"
 	"book-store/internal/domain"
+	"book-store/internal/tracing"
+	"book-store/internal/utilities"
+	"context"
+	"errors"
+	"slices"
 
-	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 )
 
 type customerService struct {
-	customerRepo domain.CustomerRepository
+	customerRepo    domain.CustomerRepository
+	transactionRepo domain.TransactionRepository
+	reportSvc       domain.ReportService
 }
 
 // Count
-func (c *customerService) Count(filter *domain.Customer) (int64, error) {
-	count, err := c.customerRepo.Count(filter)
+func (c *customerService) Count(ctx context.Context, filter *domain.Customer) (int64, error) {
+	ctx, span := tracing.Start(ctx, "CustomerService.Count")
+	defer span.End()
+
+	count, err := c.customerRepo.Count(ctx, filter)
 	if err != nil {
 		return 0, err
 	}
@@ -22,13 +30,25 @@ func (c *customerService) Count(filter *domain.Customer) (int64, error) {
 }
 
 // Delete
-func (c *customerService) Delete(id uint) error {
-	return c.customerRepo.Delete(id)
+func (c *customerService) Delete(ctx context.Context, id uint) error {
+	ctx, span := tracing.Start(ctx, "CustomerService.Delete")
+	defer span.End()
+
+	if err := c.customerRepo.Delete(ctx, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrCustomerNotFound
+		}
+		return err
+	}
+	return nil
 }
 
 // Fetch
-func (c *customerService) Fetch(page int, size int, filter *domain.Customer) ([]*domain.Customer, int, error) {
-	customers, nextCursor, err := c.customerRepo.Fetch(page, size, filter)
+func (c *customerService) Fetch(ctx context.Context, page int, size int, filter *domain.Customer) ([]*domain.Customer, int, error) {
+	ctx, span := tracing.Start(ctx, "CustomerService.Fetch")
+	defer span.End()
+
+	customers, nextCursor, err := c.customerRepo.Fetch(ctx, page, size, filter)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -37,11 +57,14 @@ func (c *customerService) Fetch(page int, size int, filter *domain.Customer) ([]
 }
 
 // GetById
-func (c *customerService) GetById(id uint) (*domain.Customer, error) {
-	customer, err := c.customerRepo.GetById(id)
+func (c *customerService) GetById(ctx context.Context, id uint) (*domain.Customer, error) {
+	ctx, span := tracing.Start(ctx, "CustomerService.GetById")
+	defer span.End()
+
+	customer, err := c.customerRepo.GetById(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, fiber.ErrNotFound
+			return nil, domain.ErrCustomerNotFound
 		}
 		return nil, err
 	}
@@ -50,15 +73,175 @@ func (c *customerService) GetById(id uint) (*domain.Customer, error) {
 }
 
 // Store
-func (c *customerService) Store(customer *domain.Customer) error {
-	return c.customerRepo.Store(customer)
+func (c *customerService) Store(ctx context.Context, customer *domain.Customer) error {
+	ctx, span := tracing.Start(ctx, "CustomerService.Store")
+	defer span.End()
+
+	normalize(customer)
+	return c.customerRepo.Store(ctx, customer)
 }
 
 // Update
-func (c *customerService) Update(customer *domain.Customer) error {
-	return c.customerRepo.Update(customer)
+func (c *customerService) Update(ctx context.Context, customer *domain.Customer) error {
+	ctx, span := tracing.Start(ctx, "CustomerService.Update")
+	defer span.End()
+
+	normalize(customer)
+	if err := c.customerRepo.Update(ctx, customer); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrCustomerNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// GetStats
+func (c *customerService) GetStats(ctx context.Context, id uint) (*domain.CustomerStats, error) {
+	ctx, span := tracing.Start(ctx, "CustomerService.GetStats")
+	defer span.End()
+
+	if _, err := c.GetById(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return c.customerRepo.GetStats(ctx, id)
+}
+
+// FetchTransactions
+func (c *customerService) FetchTransactions(ctx context.Context, id uint, page int, size int) ([]*domain.Transaction, int, error) {
+	ctx, span := tracing.Start(ctx, "CustomerService.FetchTransactions")
+	defer span.End()
+
+	if _, err := c.GetById(ctx, id); err != nil {
+		return nil, 0, err
+	}
+
+	return c.transactionRepo.FetchByCustomer(ctx, id, page, size)
+}
+
+// CountTransactions
+func (c *customerService) CountTransactions(ctx context.Context, id uint) (int64, error) {
+	ctx, span := tracing.Start(ctx, "CustomerService.CountTransactions")
+	defer span.End()
+
+	return c.transactionRepo.Count(ctx, &domain.Transaction{CustomerId: id})
+}
+
+// FindDuplicates scores the customers that likely are the same person as the given one
+func (c *customerService) FindDuplicates(ctx context.Context, id uint) ([]*domain.CustomerDuplicate, error) {
+	ctx, span := tracing.Start(ctx, "CustomerService.FindDuplicates")
+	defer span.End()
+
+	customer, err := c.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates, err := c.customerRepo.FetchDuplicateCandidates(ctx, customer)
+	if err != nil {
+		return nil, err
+	}
+
+	name := utilities.NormalizeName(customer.Name)
+	duplicates := []*domain.CustomerDuplicate{}
+
+	for _, candidate := range candidates {
+		duplicate := &domain.CustomerDuplicate{Customer: candidate}
+
+		if customer.NormalizedEmail != "" && candidate.NormalizedEmail == customer.NormalizedEmail {
+			duplicate.Score += 0.5
+			duplicate.Reasons = append(duplicate.Reasons, "same email")
+		}
+		if customer.NormalizedPhone != "" && candidate.NormalizedPhone == customer.NormalizedPhone {
+			duplicate.Score += 0.3
+			duplicate.Reasons = append(duplicate.Reasons, "same phone number")
+		}
+		if similarity := utilities.Similarity(name, utilities.NormalizeName(candidate.Name)); similarity >= 0.8 {
+			duplicate.Score += 0.2 * similarity
+			duplicate.Reasons = append(duplicate.Reasons, "similar name")
+		}
+
+		// a similar name alone is not enough to call it a duplicate
+		if duplicate.Score >= 0.3 {
+			duplicates = append(duplicates, duplicate)
+		}
+	}
+
+	slices.SortFunc(duplicates, func(a, b *domain.CustomerDuplicate) int {
+		switch {
+		case a.Score > b.Score:
+			return -1
+		case a.Score < b.Score:
+			return 1
+		}
+		return 0
+	})
+
+	return duplicates, nil
 }
 
-func NewCustomerService(customerRepo domain.CustomerRepository) domain.CustomerService {
-	return &customerService{customerRepo: customerRepo}
+// Merge folds the duplicates into the customer, which is kept
+func (c *customerService) Merge(ctx context.Context, id uint, duplicateIds []uint) (*domain.Customer, error) {
+	ctx, span := tracing.Start(ctx, "CustomerService.Merge")
+	defer span.End()
+
+	if slices.Contains(duplicateIds, id) {
+		return nil, domain.ErrMergeIntoSelf
+	}
+
+	slices.Sort(duplicateIds)
+	duplicateIds = slices.Compact(duplicateIds)
+
+	if err := c.customerRepo.Merge(ctx, id, duplicateIds); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrCustomerNotFound
+		}
+		return nil, err
+	}
+	// the top customers of past ranges now count the duplicates' sales for the customer
+	c.reportSvc.Invalidate(ctx)
+
+	return c.GetById(ctx, id)
+}
+
+// normalize fills the normalized contact details used for duplicate detection
+func normalize(customer *domain.Customer) {
+	if customer.Email != "" {
+		customer.NormalizedEmail = utilities.NormalizeEmail(customer.Email)
+	}
+	if customer.PhoneNumber != "" {
+		customer.NormalizedPhone = utilities.NormalizePhone(customer.PhoneNumber)
+	}
+}
+
+// Restore
+func (c *customerService) Restore(ctx context.Context, id uint) (*domain.Customer, error) {
+	ctx, span := tracing.Start(ctx, "CustomerService.Restore")
+	defer span.End()
+
+	if err := c.customerRepo.Restore(ctx, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrCustomerNotFound
+		}
+		return nil, err
+	}
+
+	return c.GetById(ctx, id)
+}
+
+// Each
+func (c *customerService) Each(ctx context.Context, filter *domain.Customer, fn func(customer *domain.Customer) error) error {
+	ctx, span := tracing.Start(ctx, "CustomerService.Each")
+	defer span.End()
+
+	return c.customerRepo.Each(ctx, filter, fn)
+}
+
+func NewCustomerService(customerRepo domain.CustomerRepository, transactionRepo domain.TransactionRepository, reportSvc domain.ReportService) domain.CustomerService {
+	return &customerService{
+		customerRepo:    customerRepo,
+		transactionRepo: transactionRepo,
+		reportSvc:       reportSvc,
+	}
 }
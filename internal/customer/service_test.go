@@ -0,0 +1,58 @@
+package customer
+
+import (
+	"book-store/internal/domain"
+	"book-store/internal/testdb"
+	"context"
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestCustomerServiceNotFound(t *testing.T) {
+	db := testdb.Open(t, &domain.Customer{})
+	svc := &customerService{customerRepo: NewMysqlCustomerRepository(db)}
+	ctx := context.Background()
+
+	deleted := &domain.Customer{Name: "Jane", Email: "jane@mail.com", PhoneNumber: "081234567890"}
+	if err := db.Create(deleted).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Delete(deleted).Error; err != nil {
+		t.Fatal(err)
+	}
+	const missing = 404
+
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{name: "update missing", call: func() error {
+			return svc.Update(ctx, &domain.Customer{Model: gorm.Model{ID: missing}, Name: "Jane Doe"})
+		}},
+		{name: "update deleted", call: func() error {
+			return svc.Update(ctx, &domain.Customer{Model: gorm.Model{ID: deleted.ID}, Name: "Jane Doe"})
+		}},
+		{name: "delete missing", call: func() error { return svc.Delete(ctx, missing) }},
+		{name: "delete deleted", call: func() error { return svc.Delete(ctx, deleted.ID) }},
+		{name: "restore missing", call: func() error {
+			_, err := svc.Restore(ctx, missing)
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			if !errors.Is(err, domain.ErrCustomerNotFound) {
+				t.Fatalf("err = %v, want %v", err, domain.ErrCustomerNotFound)
+			}
+
+			var appErr *domain.AppError
+			if !errors.As(err, &appErr) || appErr.Status() != 404 {
+				t.Errorf("err = %v, want a 404", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+var (
+	ErrEmailTaken               = NewError(KindConflict, "email_taken", "email is already used by another user")
+	ErrInvalidEmailVerification = NewError(KindValidation, "invalid_email_verification", "email verification token is invalid, used or expired")
+)
+
+// Account is the authenticated user with the email they are changing to, if any
+type Account struct {
+	*User
+	PendingEmail *string `json:"pending_email,omitempty"`
+}
+
+// EmailVerification changes the email of a user once the token mailed to the new address comes back, only a
+// hash of the token is kept
+type EmailVerification struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserId    uint       `json:"user_id" gorm:"not null;index"`
+	Email     string     `json:"email" gorm:"not null"`
+	TokenHash string     `json:"-" gorm:"not null;size:64;uniqueIndex"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type AccountUpdateRequest struct {
+	Name string `json:"name"`
+	// Email is changed once verified, asking for it takes the current password so a stolen token can't take over
+	// the account through a password reset mailed to an address of the thief
+	Email           string `json:"email" validate:"omitempty,email"`
+	CurrentPassword string `json:"current_password" validate:"required_with=Email"`
+}
+
+type EmailVerificationRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type EmailVerificationRepository interface {
+	// Store revokes the unused verifications of the user before storing the new one
+	Store(ctx context.Context, verification *EmailVerification) error
+	// GetPending returns gorm.ErrRecordNotFound when the user has no verification usable at now
+	GetPending(ctx context.Context, userId uint, now time.Time) (*EmailVerification, error)
+	// Redeem uses the verification and sets the email of its user in one go, it returns gorm.ErrRecordNotFound
+	// when the token is unknown, used or expired at now
+	Redeem(ctx context.Context, tokenHash string, now time.Time) (*EmailVerification, error)
+}
+
+// AccountService serves the user of the token, identified by its subject
+type AccountService interface {
+	Get(ctx context.Context, userId uint) (*Account, error)
+	// Update changes the name right away and mails a verification to a new email
+	Update(ctx context.Context, userId uint, req *AccountUpdateRequest) (*Account, error)
+	VerifyEmail(ctx context.Context, req *EmailVerificationRequest) error
+	FetchTransactions(ctx context.Context, userId uint, page int, size int) ([]*Transaction, int, error)
+	CountTransactions(ctx context.Context, userId uint) (int64, error)
+}
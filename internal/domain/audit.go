@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+type AuditAction string
+
+const (
+	AuditActionCreate AuditAction = "create"
+	AuditActionUpdate AuditAction = "update"
+	// AuditActionDelete is a soft delete, AuditActionPurge removes the row for good
+	AuditActionDelete  AuditAction = "delete"
+	AuditActionRestore AuditAction = "restore"
+	AuditActionPurge   AuditAction = "purge"
+)
+
+// AuditActorSystem is the actor of changes made outside a request, e.g. by background jobs
+const AuditActorSystem = "system"
+
+// AuditLog records one change of one row, Before and After only hold the columns that changed. ActorId is the
+// user who made the change, nil for the system, and Actor their name at the time since names change
+type AuditLog struct {
+	ID         uint            `json:"id" gorm:"primarykey"`
+	EntityType string          `json:"entity_type" gorm:"not null;size:64;index:idx_audit_logs_entity,priority:1"`
+	EntityId   uint            `json:"entity_id" gorm:"not null;index:idx_audit_logs_entity,priority:2"`
+	Action     AuditAction     `json:"action" gorm:"not null;size:16"`
+	ActorId    *uint           `json:"actor_id" gorm:"index"`
+	Actor      string          `json:"actor" gorm:"not null;size:255;index"`
+	RequestId  string          `json:"request_id" gorm:"size:64;index"`
+	Before     json.RawMessage `json:"before" gorm:"type:text"`
+	After      json.RawMessage `json:"after" gorm:"type:text"`
+	CreatedAt  time.Time       `json:"created_at" gorm:"not null;index"`
+}
+
+// AuditLogFilter narrows the audit log, zero fields match everything and the time range is half-open
+type AuditLogFilter struct {
+	EntityType string
+	EntityId   uint
+	Action     AuditAction
+	ActorId    uint
+	Actor      string
+	RequestId  string
+	From       time.Time
+	To         time.Time
+}
+
+type AuditRepository interface {
+	Fetch(ctx context.Context, page int, size int, filter *AuditLogFilter) ([]*AuditLog, int, error)
+	Count(ctx context.Context, filter *AuditLogFilter) (int64, error)
+}
+
+type AuditService interface {
+	Fetch(ctx context.Context, page int, size int, filter *AuditLogFilter) ([]*AuditLog, int, error)
+	Count(ctx context.Context, filter *AuditLogFilter) (int64, error)
+}
+
+type (
+	actorKey     struct{}
+	requestIdKey struct{}
+)
+
+// WithActor names who makes the changes done with ctx
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFrom is the actor set by WithActor, AuditActorSystem when there is none
+func ActorFrom(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return AuditActorSystem
+}
+
+// WithRequestId
+func WithRequestId(ctx context.Context, requestId string) context.Context {
+	return context.WithValue(ctx, requestIdKey{}, requestId)
+}
+
+// RequestIdFrom is empty outside a request
+func RequestIdFrom(ctx context.Context) string {
+	requestId, _ := ctx.Value(requestIdKey{}).(string)
+	return requestId
+}
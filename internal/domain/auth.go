@@ -1,16 +1,54 @@
 package domain
 
-import "github.com/golang-jwt/jwt/v5"
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	// ErrLoginLocked is returned alike for known and unknown emails so it doesn't tell which accounts exist
+	ErrLoginLocked  = NewError(KindTooManyRequests, "login_locked", "too many failed logins, try again later")
+	ErrTokenRevoked = NewError(KindUnauthorized, "token_revoked", "token was issued before the password changed, log in again")
+)
 
 type JwtTokenClaims struct {
 	jwt.RegisteredClaims
 	// list yang dibuat di payload
 	UserName string `json:"user_name"`
 	RoleName string `json:"role_name"`
+	// MustChangePassword limits the token to changing the password
+	MustChangePassword bool `json:"must_change_password,omitempty"`
+	// TwoFactorSetupRequired limits the token to setting up two-factor authentication
+	TwoFactorSetupRequired bool `json:"two_factor_setup_required,omitempty"`
+}
+
+// JsonWebKey is the public part of a key tokens are signed with (RFC 7517), members unused by its type are left out
+type JsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+type JsonWebKeySet struct {
+	Keys []JsonWebKey `json:"keys"`
 }
 
+// Token is either the token or, for users with two-factor authentication, the challenge to exchange for it
+// with a code at /auth/token/2fa
 type Token struct {
-	Token string `json:"token"`
+	Token              string     `json:"token,omitempty"`
+	TwoFactorRequired  bool       `json:"two_factor_required,omitempty"`
+	Challenge          string     `json:"challenge,omitempty"`
+	ChallengeExpiresAt *time.Time `json:"challenge_expires_at,omitempty"`
 }
 
 type AuthRequest struct {
@@ -18,6 +56,74 @@ type AuthRequest struct {
 	Password string `json:"password" validate:"required"`
 }
 
+type TwoFactorAuthRequest struct {
+	Challenge string `json:"challenge" validate:"required"`
+	// Code is the current code of the authenticator app or an unused recovery code
+	Code string `json:"code" validate:"required"`
+}
+
+// LoginThrottle counts the recent failed logins of an email or an ip, Key is e.g. account:jane@mail.com or ip:10.0.0.1
+type LoginThrottle struct {
+	Key           string     `json:"key" gorm:"primaryKey;size:320"`
+	Failures      int        `json:"failures" gorm:"not null;default:0"`
+	LastFailureAt *time.Time `json:"last_failure_at"`
+	LockedUntil   *time.Time `json:"locked_until"`
+}
+
+// Locked
+func (t *LoginThrottle) Locked(now time.Time) bool {
+	return t.LockedUntil != nil && now.Before(*t.LockedUntil)
+}
+
+// Fail counts a failure at now, forgetting the failures older than window, and locks the key out for lockout once
+// it reaches limit, a limit of 0 never does
+func (t *LoginThrottle) Fail(now time.Time, window time.Duration, limit int, lockout time.Duration) {
+	if t.LastFailureAt == nil || now.Sub(*t.LastFailureAt) > window {
+		t.Failures = 0
+	}
+	t.Failures++
+	t.LastFailureAt = &now
+
+	if limit > 0 && t.Failures >= limit {
+		lockedUntil := now.Add(lockout)
+		t.LockedUntil = &lockedUntil
+	}
+}
+
+// AccountThrottleKey ignores the case of the email, MySQL compares them case-insensitively too
+func AccountThrottleKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+type LoginThrottleRepository interface {
+	Get(ctx context.Context, key string) (*LoginThrottle, error)
+	// Update locks the throttle of key, creating it if needed, and saves it once fn changed it
+	Update(ctx context.Context, key string, fn func(throttle *LoginThrottle)) (*LoginThrottle, error)
+	Delete(ctx context.Context, key string) error
+	// Purge removes the throttles without a failure since lastFailureBefore that aren't locked out at now
+	Purge(ctx context.Context, lastFailureBefore time.Time, now time.Time) (int64, error)
+}
+
 type AuthService interface {
-	GetToken(userCredential *AuthRequest) (Token, error)
+	// GetToken slows down and then locks out logins failing repeatedly for the email or from the ip
+	GetToken(ctx context.Context, userCredential *AuthRequest, ip string) (Token, error)
+	// GetTwoFactorToken exchanges the challenge GetToken answered with and a code for the token, failed codes
+	// count as failed logins
+	GetTwoFactorToken(ctx context.Context, req *TwoFactorAuthRequest, ip string) (Token, error)
+	GetLockout(ctx context.Context, userId uint) (*LoginThrottle, error)
+	Unlock(ctx context.Context, userId uint) error
+	GetKeySet(ctx context.Context) JsonWebKeySet
+}
+
+type userIdKey struct{}
+
+// WithUserId keeps the id of the authenticated user, the subject of their token
+func WithUserId(ctx context.Context, userId uint) context.Context {
+	return context.WithValue(ctx, userIdKey{}, userId)
+}
+
+// UserIdFrom is false outside an authenticated request
+func UserIdFrom(ctx context.Context) (uint, bool) {
+	userId, ok := ctx.Value(userIdKey{}).(uint)
+	return userId, ok && userId != 0
 }
@@ -1,11 +1,14 @@
 package domain
 
 import (
+	"context"
 	"time"
 
 	"gorm.io/gorm"
 )
 
+var ErrBookNotFound = NewNotFoundError("book")
+
 type Book struct {
 	gorm.Model
 	Title       string    `json:"title" gorm:"not null"`
@@ -44,19 +47,26 @@ type BookUpdateRequest struct {
 }
 
 type BookService interface {
-	Fetch(page int, size int, filter *Book) ([]*Book, int, error)
-	GetById(id uint) (*Book, error)
-	Count(filter *Book) (int64, error)
-	Store(book *Book) error
-	Update(book *Book) error
-	Delete(id uint) error
+	Fetch(ctx context.Context, page int, size int, filter *Book) ([]*Book, int, error)
+	GetById(ctx context.Context, id uint) (*Book, error)
+	Count(ctx context.Context, filter *Book) (int64, error)
+	Store(ctx context.Context, book *Book) error
+	Update(ctx context.Context, book *Book) error
+	Delete(ctx context.Context, id uint) error
+	Each(ctx context.Context, filter *Book, fn func(book *Book) error) error
+	Restore(ctx context.Context, id uint) (*Book, error)
 }
 
 type BookRepository interface {
-	Fetch(page int, size int, filter *Book) ([]*Book, int, error)
-	GetById(id uint) (*Book, error)
-	Count(filter *Book) (int64, error)
-	Store(book *Book) error
-	Update(book *Book) error
-	Delete(id uint) error
+	Fetch(ctx context.Context, page int, size int, filter *Book) ([]*Book, int, error)
+	GetById(ctx context.Context, id uint) (*Book, error)
+	Count(ctx context.Context, filter *Book) (int64, error)
+	Store(ctx context.Context, book *Book) error
+	Update(ctx context.Context, book *Book) error
+	Delete(ctx context.Context, id uint) error
+	Each(ctx context.Context, filter *Book, fn func(book *Book) error) error
+	GetByIsbn(ctx context.Context, isbn string) (*Book, error)
+	Restore(ctx context.Context, id uint) error
+	// Purge hard deletes records deleted before deletedBefore
+	Purge(ctx context.Context, deletedBefore time.Time) (int64, error)
 }
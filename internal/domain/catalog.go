@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+type ImportFormat string
+
+const (
+	ImportFormatCSV   ImportFormat = "csv"
+	ImportFormatJSONL ImportFormat = "jsonl"
+	ImportFormatONIX  ImportFormat = "onix"
+)
+
+type ImportStatus string
+
+const (
+	ImportStatusRunning ImportStatus = "running"
+	ImportStatusDone    ImportStatus = "done"
+	ImportStatusFailed  ImportStatus = "failed"
+)
+
+var (
+	ErrInvalidImportFormat = NewError(KindValidation, "invalid_import_format", "invalid import format, should be csv, jsonl or onix")
+	ErrImportJobNotFound   = NewNotFoundError("import job")
+)
+
+// BookImportReport describes the outcome of an import, rows are numbered from 1 without the csv header
+type BookImportReport struct {
+	JobId      string                `json:"job_id,omitempty"`
+	Status     ImportStatus          `json:"status"`
+	DryRun     bool                  `json:"dry_run"`
+	Total      int                   `json:"total"`
+	Created    int                   `json:"created"`
+	Updated    int                   `json:"updated"`
+	Failed     int                   `json:"failed"`
+	Errors     []*BookImportRowError `json:"errors"`
+	Message    string                `json:"message,omitempty"`
+	StartedAt  time.Time             `json:"started_at"`
+	FinishedAt *time.Time            `json:"finished_at,omitempty"`
+}
+
+type BookImportRowError struct {
+	Row    int      `json:"row"`
+	Isbn   string   `json:"isbn,omitempty"`
+	Errors []string `json:"errors"`
+}
+
+// ImportUpload keeps the file of a background import in the database, so the instance picking up the job can read it
+type ImportUpload struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	Data      []byte    `json:"-" gorm:"type:longblob;not null"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ImportUploadRepository interface {
+	Store(ctx context.Context, upload *ImportUpload) error
+	GetById(ctx context.Context, id uint) (*ImportUpload, error)
+	Delete(ctx context.Context, id uint) error
+}
+
+type CatalogService interface {
+	Import(ctx context.Context, r io.Reader, format ImportFormat, dryRun bool) (*BookImportReport, error)
+	ImportAsync(ctx context.Context, r io.Reader, format ImportFormat, dryRun bool) (*BookImportReport, error)
+	GetImportJob(ctx context.Context, jobId string) (*BookImportReport, error)
+	// ExportONIX writes the whole catalog as an ONIX 3.0 message
+	ExportONIX(ctx context.Context, w io.Writer) error
+}
@@ -1,6 +1,9 @@
 package domain
 
 import (
+	"context"
+	"time"
+
 	"gorm.io/gorm"
 )
 
@@ -9,6 +12,15 @@ type Customer struct {
 	Name        string `json:"name" gorm:"not null"`
 	Email       string `json:"email" gorm:"not null;unique"`
 	PhoneNumber string `json:"phone_number" gorm:"not null"`
+	// loyalty balances are only changed through the loyalty ledger
+	LoyaltyPoints  int    `json:"loyalty_points" gorm:"not null;default:0"`
+	LifetimePoints int    `json:"lifetime_points" gorm:"not null;default:0"`
+	LoyaltyTier    string `json:"loyalty_tier" gorm:"not null;default:bronze"`
+	// StoreCredit is money the customer can pay with, only changed through the store credit ledger
+	StoreCredit int `json:"store_credit" gorm:"not null;default:0"`
+	// normalized contact details used to detect duplicates
+	NormalizedEmail string `json:"-" gorm:"not null;default:'';index;size:255"`
+	NormalizedPhone string `json:"-" gorm:"not null;default:'';index;size:32"`
 }
 
 type CustomerStoreRequest struct {
@@ -23,20 +35,65 @@ type CustomerUpdateRequest struct {
 	PhoneNumber string `json:"phone_number"`
 }
 
+var (
+	ErrCustomerNotFound = NewNotFoundError("customer")
+	ErrMergeIntoSelf    = NewError(KindValidation, "merge_into_self", "customer can't be merged into itself")
+)
+
+type CustomerMergeRequest struct {
+	DuplicateIds []uint `json:"duplicate_ids" validate:"required,min=1,dive,required"`
+}
+
+// CustomerDuplicate is a likely duplicate of a customer, Score goes from 0 to 1
+type CustomerDuplicate struct {
+	Customer *Customer `json:"customer"`
+	Score    float64   `json:"score"`
+	Reasons  []string  `json:"reasons"`
+}
+
+// CustomerStats aggregates the paid transactions of a customer
+type CustomerStats struct {
+	CustomerId       uint           `json:"customer_id"`
+	LifetimeSpend    int            `json:"lifetime_spend"`
+	OrderCount       int            `json:"order_count"`
+	AverageBasket    int            `json:"average_basket"`
+	LastPurchaseAt   *time.Time     `json:"last_purchase_at"`
+	FavouriteAuthors []*AuthorCount `json:"favourite_authors"`
+}
+
+type AuthorCount struct {
+	Author   string `json:"author"`
+	Quantity int    `json:"quantity"`
+}
+
 type CustomerRepository interface {
-	Fetch(page int, size int, filter *Customer) ([]*Customer, int, error)
-	GetById(id uint) (*Customer, error)
-	Count(filter *Customer) (int64, error)
-	Store(customer *Customer) error
-	Update(customer *Customer) error
-	Delete(id uint) error
+	Fetch(ctx context.Context, page int, size int, filter *Customer) ([]*Customer, int, error)
+	GetById(ctx context.Context, id uint) (*Customer, error)
+	Count(ctx context.Context, filter *Customer) (int64, error)
+	Store(ctx context.Context, customer *Customer) error
+	Update(ctx context.Context, customer *Customer) error
+	Delete(ctx context.Context, id uint) error
+	GetStats(ctx context.Context, id uint) (*CustomerStats, error)
+	FetchDuplicateCandidates(ctx context.Context, customer *Customer) ([]*Customer, error)
+	Merge(ctx context.Context, survivorId uint, duplicateIds []uint) error
+	Each(ctx context.Context, filter *Customer, fn func(customer *Customer) error) error
+	Restore(ctx context.Context, id uint) error
+	// Purge hard deletes records deleted before deletedBefore
+	Purge(ctx context.Context, deletedBefore time.Time) (int64, error)
 }
 
 type CustomerService interface {
-	Fetch(page int, size int, filter *Customer) ([]*Customer, int, error)
-	GetById(id uint) (*Customer, error)
-	Count(filter *Customer) (int64, error)
-	Store(customer *Customer) error
-	Update(customer *Customer) error
-	Delete(id uint) error
+	Fetch(ctx context.Context, page int, size int, filter *Customer) ([]*Customer, int, error)
+	GetById(ctx context.Context, id uint) (*Customer, error)
+	Count(ctx context.Context, filter *Customer) (int64, error)
+	Store(ctx context.Context, customer *Customer) error
+	Update(ctx context.Context, customer *Customer) error
+	Delete(ctx context.Context, id uint) error
+	GetStats(ctx context.Context, id uint) (*CustomerStats, error)
+	FetchTransactions(ctx context.Context, id uint, page int, size int) ([]*Transaction, int, error)
+	CountTransactions(ctx context.Context, id uint) (int64, error)
+	FindDuplicates(ctx context.Context, id uint) ([]*CustomerDuplicate, error)
+	Merge(ctx context.Context, id uint, duplicateIds []uint) (*Customer, error)
+	Each(ctx context.Context, filter *Customer, fn func(customer *Customer) error) error
+	Restore(ctx context.Context, id uint) (*Customer, error)
 }
@@ -1,15 +1,126 @@
 package domain
 
-type Error struct {
-	Code    int      `json:"code"`
-	Errors  []string `json:"errors,omitempty"`
-	Message string   `json:"message"`
+import (
+	"net/http"
+	"strings"
+)
+
+// ErrorKind groups errors by how a client should react to them, every kind answers with one http status
+type ErrorKind string
+
+const (
+	KindValidation        ErrorKind = "validation"
+	KindUnauthorized      ErrorKind = "unauthorized"
+	KindForbidden         ErrorKind = "forbidden"
+	KindNotFound          ErrorKind = "not_found"
+	KindConflict          ErrorKind = "conflict"
+	KindTooManyRequests   ErrorKind = "too_many_requests"
+	KindInsufficientStock ErrorKind = "insufficient_stock"
+	// KindUnprocessable is a valid request breaking a business rule
+	KindUnprocessable ErrorKind = "unprocessable"
+	KindUnavailable   ErrorKind = "unavailable"
+	KindInternal      ErrorKind = "internal"
+)
+
+var kindStatus = map[ErrorKind]int{
+	KindValidation:        http.StatusBadRequest,
+	KindUnauthorized:      http.StatusUnauthorized,
+	KindForbidden:         http.StatusForbidden,
+	KindNotFound:          http.StatusNotFound,
+	KindConflict:          http.StatusConflict,
+	KindTooManyRequests:   http.StatusTooManyRequests,
+	KindInsufficientStock: http.StatusUnprocessableEntity,
+	KindUnprocessable:     http.StatusUnprocessableEntity,
+	KindUnavailable:       http.StatusServiceUnavailable,
+	KindInternal:          http.StatusInternalServerError,
+}
+
+var (
+	ErrUnauthorized       = NewError(KindUnauthorized, "unauthorized", "unauthorized")
+	ErrForbidden          = NewError(KindForbidden, "forbidden", "this role is not allowed to access this resource")
+	ErrInsufficientStock  = NewError(KindInsufficientStock, "insufficient_stock", "stock not enough")
+	ErrInvalidCredentials = NewError(KindUnauthorized, "invalid_credentials", "invalid email or password")
+	ErrRateLimited        = NewError(KindTooManyRequests, "rate_limited", "too many requests, try again later")
+)
+
+// AppError is what services and handlers return for anything a client can act on. Code is stable for
+// clients to match on, the message is shown as is and the wrapped cause never leaves the server
+type AppError struct {
+	Kind    ErrorKind
+	Code    string
+	Message string
+	// Details lists the individual problems, one per invalid field
+	Details []string
+	cause   error
+}
+
+func NewError(kind ErrorKind, code string, message string) *AppError {
+	return &AppError{Kind: kind, Code: code, Message: message}
 }
 
-func NewError(code int, message string) Error {
-	return Error{Code: code, Message: message}
+// NewValidationError is a bad input caught before reaching a service
+func NewValidationError(message string, details ...string) *AppError {
+	return &AppError{Kind: KindValidation, Code: "invalid_request", Message: message, Details: details}
 }
 
-func (e Error) Error() string {
+// NewNotFoundError names the missing resource, the code is e.g. book_not_found
+func NewNotFoundError(resource string) *AppError {
+	return &AppError{
+		Kind:    KindNotFound,
+		Code:    strings.ReplaceAll(resource, " ", "_") + "_not_found",
+		Message: resource + " not found",
+	}
+}
+
+func (e *AppError) Error() string {
+	if e.cause != nil {
+		return e.Message + ": " + e.cause.Error()
+	}
 	return e.Message
 }
+
+func (e *AppError) Unwrap() error {
+	return e.cause
+}
+
+// Is matches errors of the same code so sentinels still match once wrapped or given details
+func (e *AppError) Is(target error) bool {
+	t, ok := target.(*AppError)
+	return ok && t.Code == e.Code
+}
+
+// Wrap keeps err as the cause for logs and errors.Is, clients only see the message
+func (e *AppError) Wrap(err error) *AppError {
+	wrapped := *e
+	wrapped.cause = err
+	return &wrapped
+}
+
+// WithDetails
+func (e *AppError) WithDetails(details ...string) *AppError {
+	detailed := *e
+	detailed.Details = details
+	return &detailed
+}
+
+// Status is the http status of the kind, unknown kinds are internal errors
+func (e *AppError) Status() int {
+	if status, ok := kindStatus[e.Kind]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// Error is the RFC 7807 problem document every failed request is answered with
+type Error struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	// Instance is the path of the failed request
+	Instance string `json:"instance,omitempty"`
+	// Code is the machine-readable error code
+	Code      string   `json:"code"`
+	Errors    []string `json:"errors,omitempty"`
+	RequestId string   `json:"request_id,omitempty"`
+}
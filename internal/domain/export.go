@@ -0,0 +1,10 @@
+package domain
+
+type ExportFormat string
+
+const (
+	ExportFormatCSV  ExportFormat = "csv"
+	ExportFormatXLSX ExportFormat = "xlsx"
+)
+
+var ErrInvalidExportFormat = NewError(KindValidation, "invalid_export_format", "invalid export format, should be csv or xlsx")
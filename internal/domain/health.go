@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+type HealthStatus string
+
+const (
+	HealthStatusUp   HealthStatus = "up"
+	HealthStatusDown HealthStatus = "down"
+)
+
+// HealthChecker is implemented by anything readiness depends on, Check returns why it isn't usable
+type HealthChecker interface {
+	Name() string
+	Check(ctx context.Context) error
+}
+
+type HealthCheck struct {
+	Name      string       `json:"name"`
+	Status    HealthStatus `json:"status"`
+	LatencyMs float64      `json:"latency_ms"`
+	Error     string       `json:"error,omitempty"`
+}
+
+type HealthReport struct {
+	Status    HealthStatus   `json:"status"`
+	Uptime    string         `json:"uptime"`
+	Checks    []*HealthCheck `json:"checks"`
+	CheckedAt time.Time      `json:"checked_at"`
+}
+
+type HealthService interface {
+	// Register adds a checker to readiness, it must be called before serving
+	Register(checker HealthChecker)
+	// Liveness only tells the process is serving, dependencies are left to readiness
+	Liveness(ctx context.Context) *HealthReport
+	Readiness(ctx context.Context) *HealthReport
+}
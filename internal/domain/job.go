@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type JobStatus string
+
+const (
+	JobStatusPending JobStatus = "pending"
+	JobStatusRunning JobStatus = "running"
+	JobStatusDone    JobStatus = "done"
+	// JobStatusDead is the dead-letter state of jobs that used up their attempts
+	JobStatusDead JobStatus = "dead"
+)
+
+var (
+	ErrJobNotFound = NewNotFoundError("job")
+	ErrJobNotDead  = NewError(KindConflict, "job_not_dead", "only dead jobs can be retried")
+)
+
+// Job is a unit of background work persisted so it survives restarts
+type Job struct {
+	gorm.Model
+	Type        string          `json:"type" gorm:"not null;size:64;index:idx_jobs_queue,priority:2"`
+	Payload     json.RawMessage `json:"payload" gorm:"type:text"`
+	Status      JobStatus       `json:"status" gorm:"not null;size:16;default:pending;index:idx_jobs_queue,priority:1"`
+	Attempts    int             `json:"attempts" gorm:"not null;default:0"`
+	MaxAttempts int             `json:"max_attempts" gorm:"not null"`
+	RunAt       time.Time       `json:"run_at" gorm:"not null;index:idx_jobs_queue,priority:3"`
+	LockedAt    *time.Time      `json:"locked_at"`
+	LastError   string          `json:"last_error" gorm:"type:text"`
+	Result      json.RawMessage `json:"result" gorm:"type:text"`
+	FinishedAt  *time.Time      `json:"finished_at"`
+}
+
+// JobOptions tune a single enqueue, zero values fall back to the runner defaults
+type JobOptions struct {
+	// RunAt schedules the job, it runs as soon as possible when zero
+	RunAt       time.Time
+	MaxAttempts int
+}
+
+// JobHandler does the work of one job, the result is stored on the job when it succeeds.
+// ctx is cancelled on timeout or when the runner is shut down
+type JobHandler func(ctx context.Context, job *Job) (any, error)
+
+type JobRepository interface {
+	Fetch(ctx context.Context, page int, size int, filter *Job) ([]*Job, int, error)
+	GetById(ctx context.Context, id uint) (*Job, error)
+	Count(ctx context.Context, filter *Job) (int64, error)
+	Store(ctx context.Context, job *Job) error
+	Update(ctx context.Context, job *Job) error
+	Reserve(ctx context.Context, types []string, now time.Time) (*Job, error)
+	// Finish saves the outcome of the run reserved at lockedAt, it returns gorm.ErrRecordNotFound when the job
+	// was requeued meanwhile
+	Finish(ctx context.Context, job *Job, lockedAt time.Time) error
+	// RequeueStale returns how many stale jobs went back to the queue and how many used up their attempts
+	RequeueStale(ctx context.Context, lockedBefore time.Time) (int64, int64, error)
+}
+
+type JobService interface {
+	Fetch(ctx context.Context, page int, size int, filter *Job) ([]*Job, int, error)
+	GetById(ctx context.Context, id uint) (*Job, error)
+	Count(ctx context.Context, filter *Job) (int64, error)
+	Enqueue(ctx context.Context, jobType string, payload any, opts *JobOptions) (*Job, error)
+	Retry(ctx context.Context, id uint) (*Job, error)
+}
+
+// JobRunner polls the queue and runs the jobs of the registered types, it is healthy while polling
+type JobRunner interface {
+	HealthChecker
+	// Register must be called before Start, concurrency caps how many jobs of the type run at once
+	Register(jobType string, concurrency int, handler JobHandler)
+	Start()
+	// Shutdown stops polling and waits for running jobs until ctx is done, then cancels them
+	Shutdown(ctx context.Context) error
+}
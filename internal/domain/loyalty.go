@@ -0,0 +1,103 @@
+package domain
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type LoyaltyEntryType string
+
+const (
+	LoyaltyEntryEarn   LoyaltyEntryType = "earn"
+	LoyaltyEntryRedeem LoyaltyEntryType = "redeem"
+)
+
+var (
+	ErrInsufficientPoints   = NewError(KindValidation, "insufficient_points", "loyalty points not enough")
+	ErrDiscountExceedsTotal = NewError(KindValidation, "discount_exceeds_total", "loyalty discount exceeds the total price")
+)
+
+// LoyaltyTier is reached once a customer's lifetime points pass MinPoints,
+// EarnRate is the percentage of base points awarded on each purchase
+type LoyaltyTier struct {
+	Name      string `json:"name"`
+	MinPoints int    `json:"min_points"`
+	EarnRate  int    `json:"earn_rate"`
+}
+
+// LoyaltyTiers is ordered from the lowest to the highest tier
+var LoyaltyTiers = []LoyaltyTier{
+	{Name: "bronze", MinPoints: 0, EarnRate: 100},
+	{Name: "silver", MinPoints: 1000, EarnRate: 125},
+	{Name: "gold", MinPoints: 5000, EarnRate: 150},
+}
+
+// TierFor returns the tier matching the lifetime points and the next tier, if any
+func TierFor(lifetimePoints int) (LoyaltyTier, *LoyaltyTier) {
+	current := LoyaltyTiers[0]
+	for i, tier := range LoyaltyTiers {
+		if lifetimePoints < tier.MinPoints {
+			return current, &LoyaltyTiers[i]
+		}
+		current = tier
+	}
+
+	return current, nil
+}
+
+// AddLoyalty books the points of entry on the customer, moving them up a tier as lifetime points grow, and
+// records the new balance on entry. A redemption can't take the balance below zero
+func (c *Customer) AddLoyalty(entry *LoyaltyLedger) error {
+	balance := c.LoyaltyPoints + entry.Points
+	if balance < 0 {
+		return ErrInsufficientPoints
+	}
+
+	if entry.Type == LoyaltyEntryEarn {
+		c.LifetimePoints += entry.Points
+	}
+	tier, _ := TierFor(c.LifetimePoints)
+
+	c.LoyaltyPoints, c.LoyaltyTier = balance, tier.Name
+	entry.Balance = balance
+	return nil
+}
+
+type LoyaltyLedger struct {
+	gorm.Model
+	CustomerId    uint             `json:"customer_id" gorm:"not null;index"`
+	TransactionId *uint            `json:"transaction_id,omitempty"`
+	Type          LoyaltyEntryType `json:"type" gorm:"not null"`
+	Points        int              `json:"points" gorm:"not null"`
+	Balance       int              `json:"balance" gorm:"not null"`
+	Description   string           `json:"description"`
+}
+
+type LoyaltySummary struct {
+	CustomerId       uint             `json:"customer_id"`
+	Tier             LoyaltyTier      `json:"tier"`
+	NextTier         *LoyaltyTier     `json:"next_tier,omitempty"`
+	PointsToNextTier int              `json:"points_to_next_tier,omitempty"`
+	Balance          int              `json:"balance"`
+	BalanceValue     int              `json:"balance_value"`
+	LifetimePoints   int              `json:"lifetime_points"`
+	Ledger           []*LoyaltyLedger `json:"ledger"`
+}
+
+type LoyaltyRepository interface {
+	FetchLedger(ctx context.Context, customerId uint, page int, size int) ([]*LoyaltyLedger, int, error)
+	CountLedger(ctx context.Context, customerId uint) (int64, error)
+}
+
+type LoyaltyService interface {
+	GetSummary(ctx context.Context, customerId uint, page int, size int) (*LoyaltySummary, int, error)
+	CountLedger(ctx context.Context, customerId uint) (int64, error)
+	CheckRedeemable(ctx context.Context, customerId uint, amount int) error
+	// EarnEntry is the points a paid transaction earns, nil when it earns none. Entries are booked with the
+	// transaction they belong to
+	EarnEntry(ctx context.Context, customerId uint, totalPrice int) (*LoyaltyLedger, error)
+	RedeemEntry(amount int) *LoyaltyLedger
+	// DiscountEntry takes points off the balance as a discount, it returns the money value of the discount
+	DiscountEntry(points int) (*LoyaltyLedger, int)
+}
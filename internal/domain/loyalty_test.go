@@ -0,0 +1,93 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTierFor(t *testing.T) {
+	tests := []struct {
+		lifetimePoints int
+		want           string
+		wantNext       string
+	}{
+		{lifetimePoints: 0, want: "bronze", wantNext: "silver"},
+		{lifetimePoints: 999, want: "bronze", wantNext: "silver"},
+		{lifetimePoints: 1000, want: "silver", wantNext: "gold"},
+		{lifetimePoints: 4999, want: "silver", wantNext: "gold"},
+		{lifetimePoints: 5000, want: "gold"},
+		{lifetimePoints: 1000000, want: "gold"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			got, next := TierFor(tt.lifetimePoints)
+			if got.Name != tt.want {
+				t.Errorf("TierFor(%d) = %q, want %q", tt.lifetimePoints, got.Name, tt.want)
+			}
+
+			var nextName string
+			if next != nil {
+				nextName = next.Name
+			}
+			if nextName != tt.wantNext {
+				t.Errorf("TierFor(%d) next = %q, want %q", tt.lifetimePoints, nextName, tt.wantNext)
+			}
+		})
+	}
+}
+
+func TestCustomerAddLoyalty(t *testing.T) {
+	tests := []struct {
+		name         string
+		customer     Customer
+		entry        LoyaltyLedger
+		wantErr      error
+		wantBalance  int
+		wantLifetime int
+		wantTier     string
+	}{
+		{
+			name:        "earn",
+			customer:    Customer{LoyaltyPoints: 100, LifetimePoints: 100, LoyaltyTier: "bronze"},
+			entry:       LoyaltyLedger{Type: LoyaltyEntryEarn, Points: 50},
+			wantBalance: 150, wantLifetime: 150, wantTier: "bronze",
+		},
+		{
+			name:        "earn moves up a tier",
+			customer:    Customer{LoyaltyPoints: 200, LifetimePoints: 990, LoyaltyTier: "bronze"},
+			entry:       LoyaltyLedger{Type: LoyaltyEntryEarn, Points: 10},
+			wantBalance: 210, wantLifetime: 1000, wantTier: "silver",
+		},
+		{
+			name:        "redeem keeps lifetime points and tier",
+			customer:    Customer{LoyaltyPoints: 300, LifetimePoints: 1200, LoyaltyTier: "silver"},
+			entry:       LoyaltyLedger{Type: LoyaltyEntryRedeem, Points: -300},
+			wantBalance: 0, wantLifetime: 1200, wantTier: "silver",
+		},
+		{
+			name:        "redeem more than the balance",
+			customer:    Customer{LoyaltyPoints: 100, LifetimePoints: 1200, LoyaltyTier: "silver"},
+			entry:       LoyaltyLedger{Type: LoyaltyEntryRedeem, Points: -101},
+			wantErr:     ErrInsufficientPoints,
+			wantBalance: 100, wantLifetime: 1200, wantTier: "silver",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			customer, entry := tt.customer, tt.entry
+			if err := customer.AddLoyalty(&entry); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("AddLoyalty() = %v, want %v", err, tt.wantErr)
+			}
+
+			if customer.LoyaltyPoints != tt.wantBalance || customer.LifetimePoints != tt.wantLifetime || customer.LoyaltyTier != tt.wantTier {
+				t.Errorf("customer = %d points, %d lifetime, %q, want %d points, %d lifetime, %q",
+					customer.LoyaltyPoints, customer.LifetimePoints, customer.LoyaltyTier, tt.wantBalance, tt.wantLifetime, tt.wantTier)
+			}
+			if tt.wantErr == nil && entry.Balance != tt.wantBalance {
+				t.Errorf("entry balance = %d, want %d", entry.Balance, tt.wantBalance)
+			}
+		})
+	}
+}
@@ -0,0 +1,14 @@
+package domain
+
+import "context"
+
+type Mail struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers mails to users, e.g. the link verifying a new email address
+type Mailer interface {
+	Send(ctx context.Context, mail *Mail) error
+}
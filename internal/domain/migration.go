@@ -0,0 +1,9 @@
+package domain
+
+import "time"
+
+// DataMigration records a one-time change of existing rows, so it runs once across restarts and instances
+type DataMigration struct {
+	Name      string    `json:"name" gorm:"primaryKey;size:128"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+var (
+	// ErrWeakPassword lists the rules of the password policy the password breaks as details
+	ErrWeakPassword           = NewError(KindValidation, "weak_password", "password does not meet the password policy")
+	ErrInvalidCurrentPassword = NewError(KindValidation, "invalid_current_password", "current password is wrong")
+	ErrInvalidResetToken      = NewError(KindValidation, "invalid_reset_token", "password reset token is invalid, used or expired")
+	ErrPasswordChangeRequired = NewError(KindForbidden, "password_change_required", "password has to be changed at POST /api/users/me/password first")
+)
+
+// PasswordResetToken lets a user set a new password once before ExpiresAt, only a hash of the token is kept
+type PasswordResetToken struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserId    uint       `json:"user_id" gorm:"not null;index"`
+	TokenHash string     `json:"-" gorm:"not null;size:64;uniqueIndex"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at"`
+	// CreatedBy is the admin who handed it out
+	CreatedBy string    `json:"created_by" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PasswordReset is what the admin hands over to the user, the token is shown only once
+type PasswordReset struct {
+	UserId    uint      `json:"user_id"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type PasswordChangeRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required"`
+}
+
+type PasswordResetRequest struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required"`
+}
+
+type PasswordResetRepository interface {
+	// Store revokes the unused tokens of the user before storing the new one
+	Store(ctx context.Context, token *PasswordResetToken) error
+	// Redeem uses the token and sets the password of its user in one go, it returns gorm.ErrRecordNotFound
+	// when the token is unknown, used or expired at now
+	Redeem(ctx context.Context, tokenHash string, passwordHash string, now time.Time) (*PasswordResetToken, error)
+}
+
+type PasswordService interface {
+	// Hash checks password against the password policy and hashes it, every password is stored through it
+	Hash(password string) (string, error)
+	// Change sets a new password after checking the current one, the token returned no longer requires a change
+	Change(ctx context.Context, userId uint, req *PasswordChangeRequest) (Token, error)
+	// VerifyCurrent checks the password of a signed in user before a sensitive change, wrong ones count as failed
+	// logins of their email. It returns ErrInvalidCurrentPassword, or ErrLoginLocked once the email is locked out
+	VerifyCurrent(ctx context.Context, user *User, password string) error
+	CreateReset(ctx context.Context, userId uint) (*PasswordReset, error)
+	Reset(ctx context.Context, req *PasswordResetRequest) error
+}
@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type PaymentMethod string
+
+const (
+	PaymentMethodCash     PaymentMethod = "cash"
+	PaymentMethodCard     PaymentMethod = "card"
+	PaymentMethodTransfer PaymentMethod = "transfer"
+	// PaymentMethodLoyalty redeems the customer's loyalty points, the amount is in money
+	PaymentMethodLoyalty PaymentMethod = "loyalty_points"
+	// PaymentMethodStoreCredit spends the customer's store credit
+	PaymentMethodStoreCredit PaymentMethod = "store_credit"
+)
+
+type PaymentStatus string
+
+const (
+	PaymentStatusPaid     PaymentStatus = "paid"
+	PaymentStatusRefunded PaymentStatus = "refunded"
+)
+
+var (
+	ErrPaymentExceedsTotal      = NewError(KindValidation, "payment_exceeds_total", "non-cash payment exceeds outstanding amount")
+	ErrTransactionAlreadyPaid   = NewError(KindConflict, "transaction_already_paid", "transaction already paid")
+	ErrPaymentDeclined          = NewError(KindUnprocessable, "payment_declined", "payment was declined")
+	ErrUnsupportedPaymentMethod = NewError(KindValidation, "unsupported_payment_method", "payment method is not supported")
+)
+
+type Payment struct {
+	gorm.Model
+	TransactionId uint          `json:"transaction_id" gorm:"not null"`
+	Method        PaymentMethod `json:"method" gorm:"not null"`
+	// Amount is the part of the tender applied to the transaction
+	Amount int `json:"amount" gorm:"not null"`
+	// Tendered is what the customer handed over, only differs from Amount for cash
+	Tendered  int           `json:"tendered" gorm:"not null"`
+	Change    int           `json:"change" gorm:"not null"`
+	Reference string        `json:"reference"`
+	Status    PaymentStatus `json:"status" gorm:"not null"`
+}
+
+type PaymentStoreRequest struct {
+	Method PaymentMethod `json:"method" validate:"required,oneof=cash card transfer loyalty_points store_credit"`
+	Amount int           `json:"amount" validate:"required,gt=0"`
+}
+
+type TransactionPaymentRequest struct {
+	Payments []*PaymentStoreRequest `json:"payments" validate:"required,min=1,dive"`
+}
+
+// PaymentGateway charges non-cash tenders against an external provider
+type PaymentGateway interface {
+	Charge(ctx context.Context, payment *Payment) (string, error)
+	Refund(ctx context.Context, payment *Payment) error
+}
+
+type PaymentRepository interface {
+	FetchByTransactionId(ctx context.Context, transactionId uint) ([]*Payment, error)
+	Store(ctx context.Context, payment *Payment) error
+}
+
+type PaymentService interface {
+	FetchByTransactionId(ctx context.Context, transactionId uint) ([]*Payment, error)
+	Process(ctx context.Context, outstanding int, paymentReqs []*PaymentStoreRequest) ([]*Payment, error)
+	Refund(ctx context.Context, payments []*Payment) error
+}
@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+type ReceiptFormat string
+
+const (
+	ReceiptFormatHTML ReceiptFormat = "html"
+	ReceiptFormatText ReceiptFormat = "text"
+	ReceiptFormatPDF  ReceiptFormat = "pdf"
+)
+
+// Valid reports whether receipts can be rendered in the format
+func (f ReceiptFormat) Valid() bool {
+	switch f {
+	case ReceiptFormatHTML, ReceiptFormatText, ReceiptFormatPDF:
+		return true
+	}
+	return false
+}
+
+var (
+	ErrTransactionNotPaid   = NewError(KindConflict, "transaction_not_paid", "receipt is only available for paid transactions")
+	ErrInvalidReceiptFormat = NewError(KindValidation, "invalid_receipt_format", "invalid receipt format, should be html, text or pdf")
+)
+
+// InvoiceSequence holds the last invoice number issued by a store
+type InvoiceSequence struct {
+	StoreCode  string `gorm:"primaryKey;size:32"`
+	LastNumber int    `gorm:"not null"`
+	UpdatedAt  time.Time
+}
+
+type Receipt struct {
+	InvoiceNumber string
+	StoreName     string
+	StoreAddress  string
+	IssuedAt      time.Time
+	Cashier       string
+	Customer      string
+	Lines         []*ReceiptLine
+	Discount      int
+	TotalPrice    int
+	PaidAmount    int
+	ChangeAmount  int
+	Payments      []*Payment
+}
+
+type ReceiptLine struct {
+	Title     string
+	Isbn      string
+	Quantity  int
+	UnitPrice int
+	SubTotal  int
+}
+
+type ReceiptRepository interface {
+	AssignInvoiceNumber(ctx context.Context, transactionId uint, storeCode string) (string, error)
+}
+
+type ReceiptService interface {
+	Generate(ctx context.Context, transactionId uint) (*Receipt, error)
+	Render(ctx context.Context, receipt *Receipt, format ReceiptFormat) ([]byte, error)
+}
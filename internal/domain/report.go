@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+type ReportPeriod string
+
+const (
+	ReportPeriodDay   ReportPeriod = "day"
+	ReportPeriodWeek  ReportPeriod = "week"
+	ReportPeriodMonth ReportPeriod = "month"
+)
+
+// ReportFilter selects the transactions paid in [From, To), buckets are cut at
+// midnight in Location. A transaction paid later than it was created counts on
+// the day it was paid, so sales of a closed range don't change afterwards
+type ReportFilter struct {
+	From     time.Time
+	To       time.Time
+	Period   ReportPeriod
+	Location *time.Location
+	Limit    int
+}
+
+// Closed tells whether the range is over, reports of closed ranges only change
+// when a transaction is deleted, restored or edited afterwards
+func (f *ReportFilter) Closed(now time.Time) bool {
+	return !f.To.After(now)
+}
+
+type SaleRow struct {
+	PaidAt     time.Time
+	TotalPrice int
+	Units      int
+}
+
+// Revenue of every report is what customers paid, after the loyalty discount. A
+// transaction's discount is taken off its line items in proportion to their sub
+// total for the revenue of books and authors, rounded per book or author
+type SalesBucket struct {
+	PeriodStart   time.Time `json:"period_start"`
+	Revenue       int       `json:"revenue"`
+	Units         int       `json:"units"`
+	Orders        int       `json:"orders"`
+	AverageBasket int       `json:"average_basket"`
+}
+
+type SalesReport struct {
+	From     time.Time      `json:"from"`
+	To       time.Time      `json:"to"`
+	Period   ReportPeriod   `json:"period"`
+	Timezone string         `json:"timezone"`
+	Totals   *SalesBucket   `json:"totals"`
+	Buckets  []*SalesBucket `json:"buckets"`
+}
+
+type TopBook struct {
+	BookId  uint   `json:"book_id"`
+	Title   string `json:"title"`
+	Author  string `json:"author"`
+	Units   int    `json:"units"`
+	Revenue int    `json:"revenue"`
+}
+
+type TopAuthor struct {
+	Author  string `json:"author"`
+	Units   int    `json:"units"`
+	Revenue int    `json:"revenue"`
+}
+
+type TopCustomer struct {
+	CustomerId uint   `json:"customer_id"`
+	Name       string `json:"name"`
+	Orders     int    `json:"orders"`
+	Revenue    int    `json:"revenue"`
+}
+
+type ReportRepository interface {
+	EachSale(ctx context.Context, from time.Time, to time.Time, fn func(row *SaleRow) error) error
+	TopBooks(ctx context.Context, from time.Time, to time.Time, limit int) ([]*TopBook, error)
+	TopAuthors(ctx context.Context, from time.Time, to time.Time, limit int) ([]*TopAuthor, error)
+	TopCustomers(ctx context.Context, from time.Time, to time.Time, limit int) ([]*TopCustomer, error)
+}
+
+type ReportService interface {
+	Sales(ctx context.Context, filter *ReportFilter) (*SalesReport, error)
+	TopBooks(ctx context.Context, filter *ReportFilter) ([]*TopBook, error)
+	TopAuthors(ctx context.Context, filter *ReportFilter) ([]*TopAuthor, error)
+	TopCustomers(ctx context.Context, filter *ReportFilter) ([]*TopCustomer, error)
+	// Invalidate drops the cached reports, past ranges change when a transaction is deleted, restored or edited
+	Invalidate(ctx context.Context)
+}
@@ -1,22 +1,25 @@
 package domain
 
 import (
+	"context"
 	"gorm.io/gorm"
 )
 
+var ErrRoleNotFound = NewNotFoundError("role")
+
 type Role struct {
 	gorm.Model
 	Name string `json:"name" gorm:"not null"`
 }
 
 type RoleRepository interface {
-	Fetch(page int, size int, filter *Role) ([]*Role, int, error)
-	GetById(id uint) (*Role, error)
-	Count(filter *Role) (int64, error)
+	Fetch(ctx context.Context, page int, size int, filter *Role) ([]*Role, int, error)
+	GetById(ctx context.Context, id uint) (*Role, error)
+	Count(ctx context.Context, filter *Role) (int64, error)
 }
 
 type RoleService interface {
-	Fetch(page int, size int, filter *Role) ([]*Role, int, error)
-	GetById(id uint) (*Role, error)
-	Count(filter *Role) (int64, error)
+	Fetch(ctx context.Context, page int, size int, filter *Role) ([]*Role, int, error)
+	GetById(ctx context.Context, id uint) (*Role, error)
+	Count(ctx context.Context, filter *Role) (int64, error)
 }
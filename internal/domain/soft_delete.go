@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+var ErrNotDeleted = NewError(KindConflict, "not_deleted", "only deleted records can be restored")
+
+type includeDeletedKey struct{}
+
+// WithDeleted makes Fetch, Count and GetById of repositories also return soft-deleted records
+func WithDeleted(ctx context.Context) context.Context {
+	return context.WithValue(ctx, includeDeletedKey{}, true)
+}
+
+// IncludesDeleted reports whether ctx was made by WithDeleted
+func IncludesDeleted(ctx context.Context) bool {
+	included, _ := ctx.Value(includeDeletedKey{}).(bool)
+	return included
+}
+
+// PurgeResult counts the records removed for good by one purge, login throttles are removed once their failures
+// are past the failure window and their lockout is over
+type PurgeResult struct {
+	DeletedBefore  time.Time `json:"deleted_before"`
+	Transactions   int64     `json:"transactions"`
+	Books          int64     `json:"books"`
+	Customers      int64     `json:"customers"`
+	Users          int64     `json:"users"`
+	LoginThrottles int64     `json:"login_throttles"`
+}
+
+// PurgeService hard deletes records soft-deleted longer than the retention period and expired login throttles
+type PurgeService interface {
+	Purge(ctx context.Context) (*PurgeResult, error)
+	// Schedule queues the first purge unless one is already pending, every purge queues the next one
+	Schedule(ctx context.Context) error
+}
@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type StoreCreditEntryType string
+
+const (
+	StoreCreditEntryIssue StoreCreditEntryType = "issue"
+	StoreCreditEntrySpend StoreCreditEntryType = "spend"
+)
+
+var ErrInsufficientStoreCredit = NewError(KindValidation, "insufficient_store_credit", "store credit not enough")
+
+// StoreCreditLedger records every change of a customer's store credit, Amount is in money and negative when spent
+type StoreCreditLedger struct {
+	gorm.Model
+	CustomerId    uint                 `json:"customer_id" gorm:"not null;index"`
+	TransactionId *uint                `json:"transaction_id,omitempty"`
+	Type          StoreCreditEntryType `json:"type" gorm:"not null"`
+	Amount        int                  `json:"amount" gorm:"not null"`
+	Balance       int                  `json:"balance" gorm:"not null"`
+	Description   string               `json:"description"`
+}
+
+// AddStoreCredit books the amount of entry on the customer and records the new balance on entry. Spending
+// can't take the balance below zero
+func (c *Customer) AddStoreCredit(entry *StoreCreditLedger) error {
+	balance := c.StoreCredit + entry.Amount
+	if balance < 0 {
+		return ErrInsufficientStoreCredit
+	}
+
+	c.StoreCredit = balance
+	entry.CustomerId = c.ID
+	entry.Balance = balance
+	return nil
+}
+
+type StoreCreditIssueRequest struct {
+	Amount      int    `json:"amount" validate:"required,gt=0"`
+	Description string `json:"description"`
+}
+
+type StoreCreditSummary struct {
+	CustomerId uint                 `json:"customer_id"`
+	Balance    int                  `json:"balance"`
+	Ledger     []*StoreCreditLedger `json:"ledger"`
+}
+
+type StoreCreditRepository interface {
+	FetchLedger(ctx context.Context, customerId uint, page int, size int) ([]*StoreCreditLedger, int, error)
+	CountLedger(ctx context.Context, customerId uint) (int64, error)
+	// Issue books the entry on the customer under the same lock sales spend store credit under
+	Issue(ctx context.Context, customerId uint, entry *StoreCreditLedger) error
+}
+
+type StoreCreditService interface {
+	GetSummary(ctx context.Context, customerId uint, page int, size int) (*StoreCreditSummary, int, error)
+	CountLedger(ctx context.Context, customerId uint) (int64, error)
+	Issue(ctx context.Context, customerId uint, req *StoreCreditIssueRequest) (*StoreCreditLedger, error)
+	// CheckSpendable fails early when the balance doesn't cover amount, the sale checks it again under a lock
+	CheckSpendable(ctx context.Context, customerId uint, amount int) error
+}
@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestCustomerAddStoreCredit(t *testing.T) {
+	tests := []struct {
+		name        string
+		balance     int
+		amount      int
+		wantErr     error
+		wantBalance int
+	}{
+		{name: "issue", balance: 0, amount: 5000, wantBalance: 5000},
+		{name: "spend part", balance: 5000, amount: -2000, wantBalance: 3000},
+		{name: "spend all", balance: 5000, amount: -5000, wantBalance: 0},
+		{name: "spend more than the balance", balance: 5000, amount: -5001, wantErr: ErrInsufficientStoreCredit, wantBalance: 5000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			customer := Customer{Model: gorm.Model{ID: 7}, StoreCredit: tt.balance}
+			entry := StoreCreditLedger{Amount: tt.amount}
+			if err := customer.AddStoreCredit(&entry); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("AddStoreCredit() = %v, want %v", err, tt.wantErr)
+			}
+
+			if customer.StoreCredit != tt.wantBalance {
+				t.Errorf("store credit = %d, want %d", customer.StoreCredit, tt.wantBalance)
+			}
+			if tt.wantErr == nil && (entry.Balance != tt.wantBalance || entry.CustomerId != 7) {
+				t.Errorf("entry = balance %d of customer %d, want balance %d of customer 7", entry.Balance, entry.CustomerId, tt.wantBalance)
+			}
+		})
+	}
+}
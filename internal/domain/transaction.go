@@ -1,23 +1,47 @@
 package domain
 
 import (
+	"context"
+	"time"
+
 	"gorm.io/gorm"
 )
 
+var ErrTransactionNotFound = NewNotFoundError("transaction")
+
+type TransactionStatus string
+
+const (
+	TransactionStatusPending TransactionStatus = "pending"
+	TransactionStatusPaid    TransactionStatus = "paid"
+)
+
 type Transaction struct {
 	gorm.Model
-	UserId             uint                 `json:"user_id" gorm:"not null" validate:"required"`
-	User               *User                `json:"user,omitempty" gorm:"foreignKey:UserId"`
-	CustomerId         uint                 `json:"customer_id" gorm:"not null" validate:"required"`
-	Customer           *Customer            `json:"customer,omitempty" gorm:"foreignKey:CustomerId"`
-	TotalPrice         int                  `json:"total_price" gorm:"not null" validate:"required"`
+	UserId     uint      `json:"user_id" gorm:"not null" validate:"required"`
+	User       *User     `json:"user,omitempty" gorm:"foreignKey:UserId"`
+	CustomerId uint      `json:"customer_id" gorm:"not null" validate:"required"`
+	Customer   *Customer `json:"customer,omitempty" gorm:"foreignKey:CustomerId"`
+	TotalPrice int       `json:"total_price" gorm:"not null" validate:"required"`
+	// Discount is what loyalty points took off the sum of the details at checkout, TotalPrice is what is left
+	Discount           int                  `json:"discount" gorm:"not null;default:0"`
+	PaidAmount         int                  `json:"paid_amount" gorm:"not null;default:0"`
+	ChangeAmount       int                  `json:"change_amount" gorm:"not null;default:0"`
+	Status             TransactionStatus    `json:"status" gorm:"not null;default:pending"`
+	PaidAt             *time.Time           `json:"paid_at,omitempty" gorm:"index"`
+	InvoiceNumber      *string              `json:"invoice_number,omitempty" gorm:"unique;size:64"`
 	TransactionDetails []*TransactionDetail `json:"transaction_details,omitempty"`
+	Payments           []*Payment           `json:"payments,omitempty"`
 }
 
 type TransactionStoreRequest struct {
 	UserId             uint                             `json:"user_id" validate:"required"`
 	CustomerId         uint                             `json:"customer_id" validate:"required"`
-	TransactionDetails []*TransactionDetailStoreRequest `json:"transaction_details" validate:"required"`
+	TransactionDetails []*TransactionDetailStoreRequest `json:"transaction_details" validate:"required,min=1,dive"`
+	// RedeemPoints are loyalty points taken off the price as a discount, unlike the loyalty_points tender
+	// they lower the price points are earned on
+	RedeemPoints int                    `json:"redeem_points" validate:"omitempty,gt=0"`
+	Payments     []*PaymentStoreRequest `json:"payments" validate:"omitempty,dive"`
 }
 
 type TransactionUpdateRequest struct {
@@ -27,19 +51,36 @@ type TransactionUpdateRequest struct {
 }
 
 type TransactionRepository interface {
-	Fetch(page int, size int, filter *Transaction) ([]*Transaction, int, error)
-	GetById(id uint) (*Transaction, error)
-	Count(filter *Transaction) (int64, error)
-	Store(transaction *Transaction) error
-	Update(transaction *Transaction) error
-	Delete(id uint) error
+	Fetch(ctx context.Context, page int, size int, filter *Transaction) ([]*Transaction, int, error)
+	FetchByCustomer(ctx context.Context, customerId uint, page int, size int) ([]*Transaction, int, error)
+	GetById(ctx context.Context, id uint) (*Transaction, error)
+	Count(ctx context.Context, filter *Transaction) (int64, error)
+	// Store writes the transaction with its details, payments and loyalty entries in one database transaction,
+	// taking the quantities out of stock when it is paid and the store credit the payments spend. It returns
+	// ErrInsufficientStock when a book ran out and ErrInsufficientPoints or ErrInsufficientStoreCredit when the
+	// customer's balances did meanwhile
+	Store(ctx context.Context, transaction *Transaction, loyaltyEntries []*LoyaltyLedger) error
+	// Pay locks the transaction while fn charges the tenders, then saves the totals fn left on it with the
+	// payments and loyalty entries fn returned and the store credit the payments spend, taking the stock out
+	// once it is paid, all in one database transaction. It returns gorm.ErrRecordNotFound when there is no such
+	// transaction
+	Pay(ctx context.Context, id uint, fn func(transaction *Transaction) ([]*Payment, []*LoyaltyLedger, error)) (*Transaction, error)
+	Update(ctx context.Context, transaction *Transaction) error
+	Delete(ctx context.Context, id uint) error
+	Each(ctx context.Context, filter *Transaction, fn func(transaction *Transaction) error) error
+	Restore(ctx context.Context, id uint) error
+	// Purge hard deletes records deleted before deletedBefore
+	Purge(ctx context.Context, deletedBefore time.Time) (int64, error)
 }
 
 type TransactionService interface {
-	Fetch(page int, size int, filter *Transaction) ([]*Transaction, int, error)
-	GetById(id uint) (*Transaction, error)
-	Count(filter *Transaction) (int64, error)
-	Store(transaction *TransactionStoreRequest) error
-	Update(transaction *Transaction) error
-	Delete(id uint) error
+	Fetch(ctx context.Context, page int, size int, filter *Transaction) ([]*Transaction, int, error)
+	GetById(ctx context.Context, id uint) (*Transaction, error)
+	Count(ctx context.Context, filter *Transaction) (int64, error)
+	Store(ctx context.Context, transaction *TransactionStoreRequest) (*Transaction, error)
+	Pay(ctx context.Context, id uint, paymentReqs []*PaymentStoreRequest) (*Transaction, error)
+	Update(ctx context.Context, transaction *Transaction) error
+	Delete(ctx context.Context, id uint) error
+	Each(ctx context.Context, filter *Transaction, fn func(transaction *Transaction) error) error
+	Restore(ctx context.Context, id uint) (*Transaction, error)
 }
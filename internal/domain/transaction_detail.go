@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"context"
 	"gorm.io/gorm"
 )
 
@@ -15,17 +16,17 @@ type TransactionDetail struct {
 
 type TransactionDetailStoreRequest struct {
 	BookId   uint `json:"book_id" validate:"required"`
-	Quantity int  `json:"quantity" validate:"required"`
+	Quantity int  `json:"quantity" validate:"required,gt=0"`
 }
 
 type TransactionDetailRepository interface {
-	Store(transactionDetail *TransactionDetail) error
-	Update(transactionDetail *TransactionDetail) error
-	Delete(id uint) error
+	Store(ctx context.Context, transactionDetail *TransactionDetail) error
+	Update(ctx context.Context, transactionDetail *TransactionDetail) error
+	Delete(ctx context.Context, id uint) error
 }
 
 type TransactionDetailService interface {
-	Store(transactionDetail *TransactionDetail) error
-	Update(transactionDetail *TransactionDetail) error
-	Delete(id uint) error
+	Store(ctx context.Context, transactionDetail *TransactionDetail) error
+	Update(ctx context.Context, transactionDetail *TransactionDetail) error
+	Delete(ctx context.Context, id uint) error
 }
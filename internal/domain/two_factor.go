@@ -0,0 +1,102 @@
+package domain
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrTwoFactorSetupRequired    = NewError(KindForbidden, "two_factor_setup_required", "two-factor authentication has to be set up at POST /api/auth/me/2fa first")
+	ErrTwoFactorRequired         = NewError(KindForbidden, "two_factor_required", "two-factor authentication is required for this role")
+	ErrTwoFactorEnabled          = NewError(KindConflict, "two_factor_enabled", "two-factor authentication is already enabled")
+	ErrTwoFactorNotEnabled       = NewError(KindConflict, "two_factor_not_enabled", "two-factor authentication is not enabled")
+	ErrTwoFactorNotEnrolled      = NewError(KindConflict, "two_factor_not_enrolled", "two-factor authentication has to be enrolled at POST /api/auth/me/2fa first")
+	ErrInvalidTwoFactorCode      = NewError(KindValidation, "invalid_two_factor_code", "two-factor code is invalid")
+	ErrInvalidTwoFactorChallenge = NewError(KindUnauthorized, "invalid_two_factor_challenge", "login challenge is invalid, used or expired, log in again")
+)
+
+// TwoFactor is the TOTP (RFC 6238) secret of a user, enrolled until the first code confirms it
+type TwoFactor struct {
+	UserId uint `json:"user_id" gorm:"primaryKey;autoIncrement:false"`
+	// Secret is encrypted for SecretContext, the 20 bytes sealed with AES-GCM are 64 characters of base64
+	Secret      string     `json:"-" gorm:"not null;size:64"`
+	ConfirmedAt *time.Time `json:"confirmed_at"`
+	// LastUsedStep is the time step of the last code accepted, codes of it and earlier steps can't be replayed
+	LastUsedStep int64     `json:"-" gorm:"not null;default:0"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// SecretContext binds the encrypted secret to the user, it can't be opened once copied to another one
+func (t *TwoFactor) SecretContext() string {
+	return "two_factor:" + strconv.FormatUint(uint64(t.UserId), 10)
+}
+
+// RecoveryCode logs in once in place of a code when the authenticator app is lost, only a hash is kept
+type RecoveryCode struct {
+	ID       uint       `json:"id" gorm:"primaryKey"`
+	UserId   uint       `json:"user_id" gorm:"not null;index"`
+	CodeHash string     `json:"-" gorm:"not null;size:64"`
+	UsedAt   *time.Time `json:"used_at"`
+}
+
+// TwoFactorChallenge is handed out once the password of a user with two-factor authentication checks out, only
+// a hash is kept
+type TwoFactorChallenge struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserId    uint       `json:"user_id" gorm:"not null;index"`
+	TokenHash string     `json:"-" gorm:"not null;size:64;uniqueIndex"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	Attempts  int        `json:"attempts" gorm:"not null;default:0"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// TwoFactorEnrollment is shown once, the provisioning uri is rendered as a QR code for authenticator apps
+type TwoFactorEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningUri string `json:"provisioning_uri"`
+}
+
+// TwoFactorConfirmation carries the recovery codes, shown once, and a token no longer requiring the setup
+type TwoFactorConfirmation struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+	Token         string   `json:"token"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type TwoFactorRepository interface {
+	Get(ctx context.Context, userId uint) (*TwoFactor, error)
+	// Enroll replaces an unconfirmed secret of the user
+	Enroll(ctx context.Context, twoFactor *TwoFactor) error
+	// Confirm enables two-factor authentication of the user with the recovery codes
+	Confirm(ctx context.Context, userId uint, step int64, codeHashes []string) error
+	// Disable removes the secret and recovery codes of the user, it returns gorm.ErrRecordNotFound when there are none
+	Disable(ctx context.Context, userId uint) error
+	// UseStep returns gorm.ErrRecordNotFound when a code of step or a later one was accepted already
+	UseStep(ctx context.Context, userId uint, step int64) error
+	// UseRecoveryCode returns gorm.ErrRecordNotFound when the user has no such unused code
+	UseRecoveryCode(ctx context.Context, userId uint, codeHash string) error
+	ReplaceRecoveryCodes(ctx context.Context, userId uint, codeHashes []string) error
+	StoreChallenge(ctx context.Context, challenge *TwoFactorChallenge) error
+	// AttemptChallenge counts an attempt at the challenge, it returns gorm.ErrRecordNotFound when the challenge is
+	// unknown, used, expired at now or out of attempts
+	AttemptChallenge(ctx context.Context, tokenHash string, now time.Time, maxAttempts int) (*TwoFactorChallenge, error)
+	CompleteChallenge(ctx context.Context, id uint, now time.Time) error
+}
+
+type TwoFactorService interface {
+	// Enroll starts over an enrollment not confirmed yet
+	Enroll(ctx context.Context, userId uint) (*TwoFactorEnrollment, error)
+	Confirm(ctx context.Context, userId uint, req *TwoFactorCodeRequest) (*TwoFactorConfirmation, error)
+	// Disable needs a code, Reset is the way out for users who lost their authenticator app and recovery codes
+	Disable(ctx context.Context, userId uint, req *TwoFactorCodeRequest) error
+	Reset(ctx context.Context, userId uint) error
+	RegenerateRecoveryCodes(ctx context.Context, userId uint, req *TwoFactorCodeRequest) ([]string, error)
+	// Verify checks a code or recovery code of a user with two-factor authentication, using it up
+	Verify(ctx context.Context, userId uint, code string) error
+}
@@ -1,9 +1,20 @@
 package domain
 
 import (
+	"context"
+	"errors"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
+var (
+	ErrUserNotFound = NewNotFoundError("user")
+	// errPasswordNotHashed guards against a path storing a password without going through PasswordService.Hash
+	errPasswordNotHashed = errors.New("password has to be hashed before it is stored")
+)
+
 type User struct {
 	gorm.Model
 	Name     string `json:"name" gorm:"not null"`
@@ -11,6 +22,25 @@ type User struct {
 	Password string `json:"-" gorm:"not null"`
 	RoleId   uint   `json:"role_id" gorm:"not null"`
 	Role     *Role  `json:"role,omitempty" gorm:"foreignKey:RoleId"`
+	// MustChangePassword limits the tokens of the user to changing their password, e.g. for the seeded admin
+	MustChangePassword bool       `json:"must_change_password" gorm:"not null;default:false"`
+	PasswordChangedAt  *time.Time `json:"password_changed_at"`
+	// TwoFactorEnabled is set once the user confirmed an authenticator app
+	TwoFactorEnabled bool `json:"two_factor_enabled" gorm:"not null;default:false"`
+}
+
+// UserPasswordColumns are written together whenever a password is set
+var UserPasswordColumns = []string{"password", "must_change_password", "password_changed_at"}
+
+// BeforeSave refuses to store a password that isn't a bcrypt hash
+func (u *User) BeforeSave(tx *gorm.DB) error {
+	if u.Password == "" {
+		return nil
+	}
+	if _, err := bcrypt.Cost([]byte(u.Password)); err != nil {
+		return errPasswordNotHashed
+	}
+	return nil
 }
 
 type UserStoreRequest struct {
@@ -28,20 +58,26 @@ type UserUpdateRequest struct {
 }
 
 type UserRepository interface {
-	Fetch(page int, size int, filter *User) ([]*User, int, error)
-	GetById(id uint) (*User, error)
-	GetByEmail(email string) (*User, error)
-	Count(filter *User) (int64, error)
-	Store(user *User) error
-	Update(user *User) error
-	Delete(id uint) error
+	Fetch(ctx context.Context, page int, size int, filter *User) ([]*User, int, error)
+	GetById(ctx context.Context, id uint) (*User, error)
+	GetByEmail(ctx context.Context, email string) (*User, error)
+	Count(ctx context.Context, filter *User) (int64, error)
+	Store(ctx context.Context, user *User) error
+	Update(ctx context.Context, user *User) error
+	// UpdatePassword writes the password columns of user, unlike Update also a cleared MustChangePassword
+	UpdatePassword(ctx context.Context, user *User) error
+	Delete(ctx context.Context, id uint) error
+	Restore(ctx context.Context, id uint) error
+	// Purge hard deletes records deleted before deletedBefore
+	Purge(ctx context.Context, deletedBefore time.Time) (int64, error)
 }
 
 type UserService interface {
-	Fetch(page int, size int, filter *User) ([]*User, int, error)
-	GetById(id uint) (*User, error)
-	Count(filter *User) (int64, error)
-	Store(user *User) error
-	Update(user *User) error
-	Delete(id uint) error
+	Fetch(ctx context.Context, page int, size int, filter *User) ([]*User, int, error)
+	GetById(ctx context.Context, id uint) (*User, error)
+	Count(ctx context.Context, filter *User) (int64, error)
+	Store(ctx context.Context, user *User) error
+	Update(ctx context.Context, user *User) error
+	Delete(ctx context.Context, id uint) error
+	Restore(ctx context.Context, id uint) (*User, error)
 }
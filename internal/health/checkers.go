@@ -0,0 +1,89 @@
+package health
+
+import (
+	"book-store/internal/domain"
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"gorm.io/gorm"
+)
+
+type databaseChecker struct {
+	db *gorm.DB
+}
+
+func (d *databaseChecker) Name() string {
+	return "database"
+}
+
+// Check pings the pool, it fails once the pool is closed during shutdown
+func (d *databaseChecker) Check(ctx context.Context) error {
+	sqlDB, err := d.db.DB()
+	if err != nil {
+		return err
+	}
+
+	return sqlDB.PingContext(ctx)
+}
+
+func NewDatabaseChecker(db *gorm.DB) domain.HealthChecker {
+	return &databaseChecker{db: db}
+}
+
+type migrationChecker struct {
+	db     *gorm.DB
+	models []any
+
+	// the schema isn't expected to lose tables, so it is only inspected until it is complete once
+	migrated atomic.Bool
+}
+
+func (m *migrationChecker) Name() string {
+	return "migrations"
+}
+
+// Check reports the tables and columns of the models missing from the database
+func (m *migrationChecker) Check(ctx context.Context) error {
+	if m.migrated.Load() {
+		return nil
+	}
+
+	migrator := m.db.WithContext(ctx).Migrator()
+
+	var pending []string
+	for _, model := range m.models {
+		stmt := &gorm.Statement{DB: m.db}
+		if err := stmt.Parse(model); err != nil {
+			return err
+		}
+
+		if !migrator.HasTable(model) {
+			pending = append(pending, stmt.Schema.Table)
+			continue
+		}
+
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName != "" && !field.IgnoreMigration && !migrator.HasColumn(model, field.DBName) {
+				pending = append(pending, stmt.Schema.Table+"."+field.DBName)
+			}
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if len(pending) > 0 {
+		return fmt.Errorf("pending migrations: %s", strings.Join(pending, ", "))
+	}
+
+	m.migrated.Store(true)
+	return nil
+}
+
+// NewMigrationChecker compares the schema of the models with the database
+func NewMigrationChecker(db *gorm.DB, models []any) domain.HealthChecker {
+	return &migrationChecker{db: db, models: models}
+}
@@ -0,0 +1,42 @@
+package health
+
+import (
+	"book-store/internal/domain"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type HttpHealthHandler struct {
+	healthSvc domain.HealthService
+}
+
+func NewHttpHandler(r fiber.Router, healthSvc domain.HealthService) {
+	handler := &HttpHealthHandler{
+		healthSvc: healthSvc,
+	}
+
+	r.Get("/healthz", handler.Liveness)
+	r.Get("/readyz", handler.Readiness)
+}
+
+// Liveness used by the orchestrator to restart a stuck process, dependencies are not checked.
+// Probes live outside /api so they are left out of the swagger docs
+func (h *HttpHealthHandler) Liveness(c *fiber.Ctx) error {
+	return send(c, h.healthSvc.Liveness(c.UserContext()))
+}
+
+// Readiness used by the orchestrator to route traffic only to instances that can serve it,
+// answers 503 with the failing checks when any is down
+func (h *HttpHealthHandler) Readiness(c *fiber.Ctx) error {
+	return send(c, h.healthSvc.Readiness(c.UserContext()))
+}
+
+func send(c *fiber.Ctx, report *domain.HealthReport) error {
+	// probes must never see a cached answer
+	c.Set(fiber.HeaderCacheControl, "no-store")
+
+	if report.Status != domain.HealthStatusUp {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(report)
+	}
+	return c.Status(fiber.StatusOK).JSON(report)
+}
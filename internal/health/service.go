@@ -0,0 +1,86 @@
+package health
+
+import (
+	"book-store/internal/config"
+	"book-store/internal/domain"
+	"context"
+	"sync"
+	"time"
+)
+
+type healthService struct {
+	cfg       config.Health
+	checkers  []domain.HealthChecker
+	startedAt time.Time
+}
+
+// Register
+func (h *healthService) Register(checker domain.HealthChecker) {
+	h.checkers = append(h.checkers, checker)
+}
+
+// Liveness
+func (h *healthService) Liveness(_ context.Context) *domain.HealthReport {
+	return h.report([]*domain.HealthCheck{})
+}
+
+// Readiness runs every checker at once, each bounded by the check timeout
+func (h *healthService) Readiness(ctx context.Context) *domain.HealthReport {
+	checks := make([]*domain.HealthCheck, len(h.checkers))
+
+	var wg sync.WaitGroup
+	for i, checker := range h.checkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checks[i] = h.check(ctx, checker)
+		}()
+	}
+	wg.Wait()
+
+	return h.report(checks)
+}
+
+func (h *healthService) check(ctx context.Context, checker domain.HealthChecker) *domain.HealthCheck {
+	ctx, cancel := context.WithTimeout(ctx, h.cfg.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := checker.Check(ctx)
+
+	check := &domain.HealthCheck{
+		Name:      checker.Name(),
+		Status:    domain.HealthStatusUp,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		check.Status = domain.HealthStatusDown
+		check.Error = err.Error()
+	}
+
+	return check
+}
+
+func (h *healthService) report(checks []*domain.HealthCheck) *domain.HealthReport {
+	report := &domain.HealthReport{
+		Status:    domain.HealthStatusUp,
+		Uptime:    time.Since(h.startedAt).Round(time.Second).String(),
+		Checks:    checks,
+		CheckedAt: time.Now(),
+	}
+
+	for _, check := range checks {
+		if check.Status == domain.HealthStatusDown {
+			report.Status = domain.HealthStatusDown
+		}
+	}
+
+	return report
+}
+
+func NewHealthService(cfg config.Health) domain.HealthService {
+	return &healthService{
+		cfg:       cfg,
+		startedAt: time.Now(),
+	}
+}
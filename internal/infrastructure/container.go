@@ -1,17 +1,34 @@
 package infrastructure
 
 import (
+	"book-store/internal/account"
+	"book-store/internal/audit"
 	"book-store/internal/auth"
 	"book-store/internal/book"
+	"book-store/internal/catalog"
 	"book-store/internal/config"
 	"book-store/internal/customer"
 	"book-store/internal/domain"
+	"book-store/internal/health"
+	"book-store/internal/job"
+	"book-store/internal/loyalty"
+	"book-store/internal/mail"
 	"book-store/internal/middleware/jwt"
+	"book-store/internal/middleware/ratelimit"
+	"book-store/internal/password"
+	"book-store/internal/payment"
+	"book-store/internal/purge"
+	"book-store/internal/receipt"
+	"book-store/internal/report"
 	"book-store/internal/role"
+	"book-store/internal/storecredit"
+	"book-store/internal/tracing"
 	"book-store/internal/transaction"
+	"book-store/internal/twofactor"
 	"book-store/internal/user"
 	"book-store/internal/utilities"
 	"book-store/pkg/xlogger"
+	"context"
 
 	"github.com/caarlos0/env/v10"
 	_ "github.com/joho/godotenv/autoload"
@@ -20,29 +37,79 @@ import (
 var (
 	cfg config.Config
 
-	customerRepository    domain.CustomerRepository
-	bookRepository        domain.BookRepository
-	roleRepository        domain.RoleRepository
-	userRepository        domain.UserRepository
-	transactionRepository domain.TransactionRepository
+	customerRepository          domain.CustomerRepository
+	bookRepository              domain.BookRepository
+	roleRepository              domain.RoleRepository
+	userRepository              domain.UserRepository
+	transactionRepository       domain.TransactionRepository
+	paymentRepository           domain.PaymentRepository
+	receiptRepository           domain.ReceiptRepository
+	loyaltyRepository           domain.LoyaltyRepository
+	storeCreditRepository       domain.StoreCreditRepository
+	reportRepository            domain.ReportRepository
+	jobRepository               domain.JobRepository
+	auditRepository             domain.AuditRepository
+	loginThrottleRepository     domain.LoginThrottleRepository
+	passwordResetRepository     domain.PasswordResetRepository
+	emailVerificationRepository domain.EmailVerificationRepository
+	twoFactorRepository         domain.TwoFactorRepository
+	importUploadRepository      domain.ImportUploadRepository
+
+	paymentGateway domain.PaymentGateway
+	mailer         domain.Mailer
 
 	jwtService         utilities.JwtTokenService
+	secretBox          *utilities.SecretBox
 	customerService    domain.CustomerService
 	bookService        domain.BookService
 	roleService        domain.RoleService
+	passwordService    domain.PasswordService
 	userService        domain.UserService
+	twoFactorService   domain.TwoFactorService
 	authService        domain.AuthService
+	accountService     domain.AccountService
 	transactionService domain.TransactionService
+	paymentService     domain.PaymentService
+	receiptService     domain.ReceiptService
+	loyaltyService     domain.LoyaltyService
+	storeCreditService domain.StoreCreditService
+	reportService      domain.ReportService
+	catalogService     domain.CatalogService
+	jobService         domain.JobService
+	purgeService       domain.PurgeService
+	auditService       domain.AuditService
+	healthService      domain.HealthService
+
+	jobRunner domain.JobRunner
 
 	authMiddleware jwt.AuthMiddleware
+	rateLimiter    *ratelimit.Limiter
+
+	// tracerShutdown flushes the spans still buffered
+	tracerShutdown func(ctx context.Context) error
 )
 
 func init() {
 	if err := env.Parse(&cfg); err != nil {
 		panic(err)
 	}
+	if err := cfg.Validate(); err != nil {
+		panic(err)
+	}
+
+	if err := xlogger.Setup(cfg); err != nil {
+		panic(err)
+	}
+
+	var err error
+	if tracerShutdown, err = tracing.Setup(cfg.Tracing); err != nil {
+		panic(err)
+	}
+
+	if secretBox, err = utilities.NewSecretBox(cfg.TwoFactor.SecretKey); err != nil {
+		panic(err)
+	}
 
-	xlogger.Setup(cfg)
 	dbSetup()
 
 	customerRepository = customer.NewMysqlCustomerRepository(db)
@@ -50,14 +117,51 @@ func init() {
 	roleRepository = role.NewMysqlRoleRepository(db)
 	userRepository = user.NewMysqlUserRepository(db)
 	transactionRepository = transaction.NewMysqlTransactionRepository(db)
+	paymentRepository = payment.NewMysqlPaymentRepository(db)
+	receiptRepository = receipt.NewMysqlReceiptRepository(db)
+	loyaltyRepository = loyalty.NewMysqlLoyaltyRepository(db)
+	storeCreditRepository = storecredit.NewMysqlStoreCreditRepository(db)
+	reportRepository = report.NewMysqlReportRepository(db)
+	jobRepository = job.NewMysqlJobRepository(db)
+	auditRepository = audit.NewMysqlAuditRepository(db)
+	loginThrottleRepository = auth.NewMysqlLoginThrottleRepository(db)
+	passwordResetRepository = password.NewMysqlPasswordResetRepository(db)
+	emailVerificationRepository = account.NewMysqlEmailVerificationRepository(db)
+	twoFactorRepository = twofactor.NewMysqlTwoFactorRepository(db)
+	importUploadRepository = catalog.NewMysqlImportUploadRepository(db)
 
-	jwtService = utilities.NewJwtTokenService(cfg)
-	customerService = customer.NewCustomerService(customerRepository)
+	paymentGateway = payment.NewFakePaymentGateway()
+	mailer = mail.NewLogMailer()
+
+	if jwtService, err = utilities.NewJwtTokenService(cfg); err != nil {
+		panic(err)
+	}
+	reportService = report.NewReportService(reportRepository, cfg.Report)
+	customerService = customer.NewCustomerService(customerRepository, transactionRepository, reportService)
 	bookService = book.NewBookService(bookRepository)
 	roleService = role.NewRoleService(roleRepository)
-	userService = user.NewUserService(userRepository)
-	authService = auth.NewAuthService(userRepository, jwtService)
-	transactionService = transaction.NewTransactionService(transactionRepository, bookRepository)
+	passwordService = password.NewPasswordService(userRepository, passwordResetRepository, loginThrottleRepository, jwtService, cfg.Password, cfg.Login)
+	userService = user.NewUserService(userRepository, passwordService)
+	twoFactorService = twofactor.NewTwoFactorService(twoFactorRepository, userRepository, loginThrottleRepository, jwtService, secretBox, cfg.TwoFactor, cfg.Login)
+	authService = auth.NewAuthService(userRepository, loginThrottleRepository, twoFactorRepository, twoFactorService, jwtService, cfg.Login, cfg.TwoFactor)
+	accountService = account.NewAccountService(userRepository, emailVerificationRepository, transactionRepository, passwordService, mailer, cfg.Account)
+	paymentService = payment.NewPaymentService(paymentRepository, paymentGateway)
+	loyaltyService = loyalty.NewLoyaltyService(loyaltyRepository, customerRepository, cfg.Loyalty)
+	storeCreditService = storecredit.NewStoreCreditService(storeCreditRepository, customerRepository)
+	transactionService = transaction.NewTransactionService(transactionRepository, bookRepository, paymentService, loyaltyService, storeCreditService, reportService)
+	receiptService = receipt.NewReceiptService(transactionRepository, receiptRepository, cfg.Store)
+	jobService = job.NewJobService(jobRepository, cfg.Job)
+	auditService = audit.NewAuditService(auditRepository)
+	jobRunner = job.NewJobRunner(jobRepository, cfg.Job)
+	catalogService = catalog.NewCatalogService(bookRepository, importUploadRepository, jobService, jobRunner, cfg.Store)
+	purgeService = purge.NewPurgeService(transactionRepository, bookRepository, customerRepository, userRepository, loginThrottleRepository, jobService, jobRunner, cfg.Purge, cfg.Login)
+
+	healthService = health.NewHealthService(cfg.Health)
+	healthService.Register(health.NewDatabaseChecker(db))
+	healthService.Register(health.NewMigrationChecker(db, models))
+	healthService.Register(jobRunner)
+	healthService.Register(drainChecker{})
 
-	authMiddleware = jwt.NewAuthMiddleware(jwtService)
+	authMiddleware = jwt.NewAuthMiddleware(jwtService, userRepository)
+	rateLimiter = ratelimit.NewLimiter(ratelimit.NewMemoryStore(), authMiddleware, cfg.RateLimit)
 }
@@ -1,53 +1,141 @@
 package infrastructure
 
 import (
+	"book-store/internal/account"
+	"book-store/internal/audit"
 	"book-store/internal/auth"
 	"book-store/internal/book"
+	"book-store/internal/catalog"
 	"book-store/internal/customer"
 	"book-store/internal/docs"
+	"book-store/internal/health"
+	"book-store/internal/job"
+	"book-store/internal/loyalty"
+	"book-store/internal/metrics"
+	requestlogger "book-store/internal/middleware/logger"
+	"book-store/internal/password"
+	"book-store/internal/receipt"
+	"book-store/internal/report"
 	"book-store/internal/role"
+	"book-store/internal/storecredit"
+	"book-store/internal/tracing"
 	"book-store/internal/transaction"
+	"book-store/internal/twofactor"
 	"book-store/internal/user"
+	"book-store/internal/utilities"
 	"book-store/pkg/xlogger"
+	"context"
 	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 
 	"github.com/gofiber/contrib/fiberzerolog"
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
 	"github.com/gofiber/fiber/v2/middleware/etag"
 	recover2 "github.com/gofiber/fiber/v2/middleware/recover"
 	"github.com/gofiber/fiber/v2/middleware/requestid"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rs/zerolog"
 )
 
 func Run() {
 	logger := xlogger.Logger
 
+	// login throttling and rate limits key on the client ip, only trusted proxies may set it with the proxy header
 	app := fiber.New(fiber.Config{
-		ProxyHeader:           cfg.ProxyHeader,
-		DisableStartupMessage: true,
-		ErrorHandler:          defaultErrorHandler,
-		AppName:               "book-store",
+		ProxyHeader:             cfg.ProxyHeader,
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          cfg.TrustedProxies,
+		DisableStartupMessage:   true,
+		ErrorHandler:            utilities.ErrorHandler,
+		AppName:                 "book-store",
 	})
 
 	app.Use(fiberzerolog.New(fiberzerolog.Config{
 		Logger: logger,
 		Fields: cfg.LogFields,
+		// the request logger is only known once the logger middleware ran
+		GetLogger: func(c *fiber.Ctx) zerolog.Logger {
+			return *xlogger.Ctx(c.UserContext())
+		},
 	}))
+	if cfg.Metrics.Enabled {
+		app.Use(metrics.New())
+		app.Get("/metrics", adaptor.HTTPHandler(promhttp.Handler()))
+	}
 	app.Use(recover2.New())
-	app.Use(etag.New())
+	app.Use(etag.New(etag.Config{
+		// exports are streamed, hashing them would read the whole body into memory
+		Next: func(c *fiber.Ctx) bool {
+			return strings.HasSuffix(c.Path(), "/export")
+		},
+	}))
 	app.Use(requestid.New())
+	app.Use(tracing.New())
+	app.Use(requestlogger.New())
 
+	health.NewHttpHandler(app, healthService)
+	auth.NewKeySetHttpHandler(app, authService)
+
+	// every group is declared once so routes of several packages under it share one rate limit
+	limit, policies := rateLimiter.Policy, cfg.RateLimit
 	api := app.Group("api")
+	customers := api.Group("/customers", limit("customers", policies.Default))
+	books := api.Group("/books", limit("books", policies.Books))
+	users := api.Group("/users", limit("users", policies.Default))
+	// the routes under /auth/me are registered ahead of /auth so they answer before its strict limit of logins applies
+	me := api.Group("/auth/me", limit("me", policies.Default))
+	account.NewHttpHandler(me, accountService, authMiddleware)
+	twofactor.NewHttpHandler(me, twoFactorService, authMiddleware)
+	authGroup := api.Group("/auth", limit("auth", policies.Auth))
+	transactions := api.Group("/transactions", limit("transactions", policies.Default))
+
 	docs.NewHttpHandler(api.Group("/docs"))
-	customer.NewHttpHandler(api.Group("/customers"), customerService, authMiddleware)
-	book.NewHttpHandler(api.Group("/books"), bookService, authMiddleware)
-	role.NewHttpHandler(api.Group("/roles"), roleService)
-	user.NewHttpHandler(api.Group("/users"), userService, authMiddleware)
-	auth.NewHttpHandler(api.Group("/auth"), authService)
-	transaction.NewHttpHandler(api.Group("/transactions"), transactionService, authMiddleware)
+	customer.NewHttpHandler(customers, customerService, authMiddleware)
+	loyalty.NewHttpHandler(customers, loyaltyService, authMiddleware)
+	storecredit.NewHttpHandler(customers, storeCreditService, authMiddleware)
+	book.NewHttpHandler(books, bookService, authMiddleware)
+	catalog.NewHttpHandler(books, catalogService, authMiddleware, cfg.Import)
+	role.NewHttpHandler(api.Group("/roles", limit("roles", policies.Default)), roleService)
+	user.NewHttpHandler(users, userService, authMiddleware)
+	auth.NewHttpHandler(authGroup, authService, authMiddleware)
+	auth.NewLockoutHttpHandler(users, authService, authMiddleware)
+	password.NewHttpHandler(users, passwordService, authMiddleware)
+	twofactor.NewResetHttpHandler(users, twoFactorService, authMiddleware)
+	password.NewResetHttpHandler(authGroup, passwordService, authMiddleware)
+	account.NewVerificationHttpHandler(authGroup, accountService, authMiddleware)
+	transaction.NewHttpHandler(transactions, transactionService, authMiddleware)
+	receipt.NewHttpHandler(transactions, receiptService, authMiddleware)
+	report.NewHttpHandler(api.Group("/reports", limit("reports", policies.Default)), reportService, authMiddleware, cfg.Report)
+	job.NewHttpHandler(api.Group("/jobs", limit("jobs", policies.Default)), jobService, authMiddleware)
+	audit.NewHttpHandler(api.Group("/audit", limit("audit", policies.Default)), auditService, authMiddleware)
+
+	if err := purgeService.Schedule(context.Background()); err != nil {
+		logger.Error().Err(err).Msg("Failed to schedule purge of deleted records")
+	}
+	jobRunner.Start()
 
 	addr := fmt.Sprintf("%s:%d", cfg.Host, cfg.Port)
-	logger.Info().Msgf("Server is running on address: %s", addr)
-	if err := app.Listen(addr); err != nil {
-		logger.Fatal().Err(err).Msg("Server failed to start")
+	listenErr := make(chan error, 1)
+	go func() {
+		logger.Info().Msgf("Server is running on address: %s", addr)
+		listenErr <- app.Listen(addr)
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+
+	select {
+	case sig := <-quit:
+		logger.Info().Str("signal", sig.String()).Msg("Received signal")
+		shutdown(app)
+	case err := <-listenErr:
+		shutdown(app)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("Server failed to start")
+		}
 	}
 }
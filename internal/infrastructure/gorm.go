@@ -1,25 +1,56 @@
 package infrastructure
 
 import (
-	"fmt"
+	"book-store/internal/audit"
 	"book-store/internal/domain"
+	"book-store/internal/metrics"
+	"book-store/internal/tracing"
+	"book-store/internal/twofactor"
 	"book-store/internal/utilities"
+	"book-store/pkg/xlogger"
+	"fmt"
 
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
-	gormLogger "gorm.io/gorm/logger"
+	"gorm.io/gorm/clause"
 )
 
 var db *gorm.DB
 
+// models are migrated in development and checked for pending migrations by readiness
+var models = []any{
+	&domain.User{},
+	&domain.Book{},
+	&domain.Customer{},
+	&domain.Role{},
+	&domain.Transaction{},
+	&domain.TransactionDetail{},
+	&domain.Payment{},
+	&domain.InvoiceSequence{},
+	&domain.LoyaltyLedger{},
+	&domain.StoreCreditLedger{},
+	&domain.Job{},
+	&domain.ImportUpload{},
+	&domain.AuditLog{},
+	&domain.LoginThrottle{},
+	&domain.PasswordResetToken{},
+	&domain.EmailVerification{},
+	&domain.TwoFactor{},
+	&domain.RecoveryCode{},
+	&domain.TwoFactorChallenge{},
+	&domain.DataMigration{},
+}
+
 func dbSetup() {
 	var err error
-	l := gormLogger.Default.LogMode(gormLogger.Silent)
+	l := xlogger.GormLogger{SlowThreshold: cfg.Database.SlowQueryThreshold}
 	if cfg.Database.Driver == "mysql" {
 		db, err = gorm.Open(mysql.New(mysql.Config{
 			DSN: cfg.Database.DSN,
 		}), &gorm.Config{
 			Logger: l,
+			// duplicate keys and foreign key violations come back as gorm errors the error handler maps to 409
+			TranslateError: true,
 		})
 	}
 
@@ -27,20 +58,66 @@ func dbSetup() {
 		panic(err)
 	}
 
+	if cfg.Metrics.Enabled {
+		if err := db.Use(metrics.GormPlugin{}); err != nil {
+			panic(err)
+		}
+	}
+
+	if err := db.Use(tracing.GormPlugin{}); err != nil {
+		panic(err)
+	}
+
+	if err := db.Use(audit.GormPlugin{}); err != nil {
+		panic(err)
+	}
+
 	if cfg.IsDevelopment {
 		fmt.Println("Development Mode")
-		if err := db.AutoMigrate(
-			&domain.User{},
-			&domain.Book{},
-			&domain.Customer{},
-			&domain.Role{},
-			&domain.Transaction{},
-			&domain.TransactionDetail{},
-		); err != nil {
+		if err := db.AutoMigrate(models...); err != nil {
 			panic(err)
 		}
 	}
 
+	// fill normalized contact details of customers created before duplicate detection
+	runDataMigration("normalize_customer_contact_details", func(tx *gorm.DB) error {
+		var customers []*domain.Customer
+		return tx.Where("normalized_email = ''").FindInBatches(&customers, 100, func(batchTx *gorm.DB, batch int) error {
+			for _, customer := range customers {
+				if err := tx.Model(customer).Updates(map[string]any{
+					"normalized_email": utilities.NormalizeEmail(customer.Email),
+					"normalized_phone": utilities.NormalizePhone(customer.PhoneNumber),
+				}).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+	})
+
+	// encrypt the TOTP secrets enrolled before they were encrypted at rest
+	runDataMigration("encrypt_two_factor_secrets", func(tx *gorm.DB) error {
+		var twoFactors []*domain.TwoFactor
+		return tx.FindInBatches(&twoFactors, 100, func(batchTx *gorm.DB, batch int) error {
+			for _, twoFactor := range twoFactors {
+				if err := twofactor.SealSecret(secretBox, twoFactor, twoFactor.Secret); err != nil {
+					return err
+				}
+				if err := tx.Model(twoFactor).UpdateColumn("secret", twoFactor.Secret).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+	})
+
+	// reports count sales when they were paid, the ones paid before that was recorded count when they were created
+	runDataMigration("backfill_transaction_paid_at", func(tx *gorm.DB) error {
+		return tx.Model(&domain.Transaction{}).Unscoped().
+			Where("status = ? AND paid_at IS NULL", domain.TransactionStatusPaid).
+			UpdateColumn("paid_at", gorm.Expr("created_at")).Error
+	})
+
 	// create initial roles
 	var roleCount int64
 	if err := db.Model(&domain.Role{}).Count(&roleCount).Error; err != nil {
@@ -58,7 +135,7 @@ func dbSetup() {
 		}
 	}
 
-	// create initial admin
+	// create initial admin, who has to change the default password on first login
 	var userCount int64
 	if err := db.Model(&domain.User{}).Count(&userCount).Error; err != nil {
 		panic(err)
@@ -70,14 +147,48 @@ func dbSetup() {
 			panic(err)
 		}
 		user := domain.User{
-			Name:     "admin",
-			Email:    "admin@mail.com",
-			Password: userPassword,
-			RoleId:   1,
+			Name:               "admin",
+			Email:              "admin@mail.com",
+			Password:           userPassword,
+			RoleId:             1,
+			MustChangePassword: true,
 		}
 
 		if err := db.Create(&user).Error; err != nil {
 			panic(err)
 		}
 	}
+
+	// the admin seeded before it had to change the default password has to change it too
+	var admin domain.User
+	if err := db.Where("email = ? AND must_change_password = ?", "admin@mail.com", false).Limit(1).Find(&admin).Error; err != nil {
+		panic(err)
+	}
+
+	if isDefault, _ := utilities.ComparePassword("admin", admin.Password); admin.ID != 0 && isDefault {
+		if err := db.Model(&admin).Update("must_change_password", true).Error; err != nil {
+			panic(err)
+		}
+	}
+}
+
+// runDataMigration runs fn and records it in one database transaction, unless it is recorded already. An instance
+// starting at the same time waits on the record and skips it once the first one commits. The instance doesn't
+// start when it can't run one, e.g. two-factor secrets would stay in plain text
+func runDataMigration(name string, fn func(tx *gorm.DB) error) {
+	if !db.Migrator().HasTable(&domain.DataMigration{}) {
+		panic(fmt.Sprintf("data migration %s can't run, data_migrations table is missing", name))
+	}
+
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&domain.DataMigration{Name: name})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		xlogger.Logger.Info().Str("migration", name).Msg("Running data migration")
+		return fn(tx)
+	}); err != nil {
+		panic(err)
+	}
 }
@@ -0,0 +1,66 @@
+package infrastructure
+
+import (
+	"book-store/pkg/xlogger"
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// draining is set once shutdown starts so readiness fails while in-flight requests finish
+var draining atomic.Bool
+
+// drainChecker fails readiness during drain so the load balancer stops sending new requests
+type drainChecker struct{}
+
+func (drainChecker) Name() string {
+	return "shutdown"
+}
+
+func (drainChecker) Check(_ context.Context) error {
+	if draining.Load() {
+		return errors.New("shutting down")
+	}
+	return nil
+}
+
+// shutdown stops the http server, then the job runner, then the database pool and flushes spans, all within the drain timeout.
+// Each step runs even when an earlier one failed so the pool is always closed
+func shutdown(app *fiber.App) {
+	logger := xlogger.Logger
+
+	draining.Store(true)
+	if cfg.Shutdown.Delay > 0 {
+		logger.Info().Dur("delay", cfg.Shutdown.Delay).Msg("Waiting for load balancer to notice drain")
+		time.Sleep(cfg.Shutdown.Delay)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Shutdown.Timeout)
+	defer cancel()
+
+	logger.Info().Dur("timeout", cfg.Shutdown.Timeout).Msg("Shutting down server")
+	if err := app.ShutdownWithContext(ctx); err != nil {
+		logger.Error().Err(err).Msg("Server failed to stop")
+	}
+
+	if err := jobRunner.Shutdown(ctx); err != nil {
+		logger.Error().Err(err).Msg("Job runner failed to stop")
+	}
+
+	sqlDB, err := db.DB()
+	if err == nil {
+		err = sqlDB.Close()
+	}
+	if err != nil {
+		logger.Error().Err(err).Msg("Database failed to close")
+	}
+
+	if err := tracerShutdown(ctx); err != nil {
+		logger.Error().Err(err).Msg("Tracer failed to flush")
+	}
+
+	logger.Info().Msg("Shutdown complete")
+}
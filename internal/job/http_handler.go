@@ -0,0 +1,154 @@
+package job
+
+import (
+	"book-store/internal/domain"
+	"book-store/internal/middleware/jwt"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type HttpJobHandler struct {
+	jobSvc         domain.JobService
+	authMiddleware jwt.AuthMiddleware
+}
+
+func NewHttpHandler(r fiber.Router, jobSvc domain.JobService, authMiddleware jwt.AuthMiddleware) {
+	handler := &HttpJobHandler{
+		jobSvc:         jobSvc,
+		authMiddleware: authMiddleware,
+	}
+
+	r.Get("/", authMiddleware.RequireRole("admin"), handler.Fetch)
+	r.Get("/:id", authMiddleware.RequireRole("admin"), handler.GetById)
+	r.Post("/:id/retry", authMiddleware.RequireRole("admin"), handler.Retry)
+}
+
+// Fetch used to get list of background jobs
+//
+//	@Summary		Get list of jobs
+//	@Description	Get list of background jobs, newest first
+//	@Tags			jobs
+//	@Accept			json
+//	@Produce		json
+//	@Param			page	query		int				false	"Page number (default 1)"
+//	@Param			size	query		int				false	"Size of page (default 10)"
+//	@Param			status	query		string			false	"Job status (pending, running, done, dead)"
+//	@Param			type	query		string			false	"Job type"
+//	@Header			200		{string}	X-Cursor		"Next page"
+//	@Header			200		{string}	X-Total-Count	"Total item"
+//	@Header			200		{string}	X-Max-Page		"Max page"
+//	@Success		200		{array}		domain.Success	"List of jobs"
+//	@Failure		400		{object}	domain.Error	"Bad Request"
+//	@Failure		404		{object}	domain.Error	"Not Found"
+//	@Failure		500		{object}	domain.Error	"Internal Server Error"
+//	@Router			/jobs [get]
+//
+// @Security Bearer
+func (h *HttpJobHandler) Fetch(c *fiber.Ctx) error {
+	page, size := c.QueryInt("page", 1), c.QueryInt("size", 10)
+	if page <= 0 {
+		return domain.NewValidationError("page must be a positive integer")
+	}
+	if size <= 0 {
+		return domain.NewValidationError("size must be a positive integer")
+	}
+
+	filter := &domain.Job{
+		Status: domain.JobStatus(c.Query("status")),
+		Type:   c.Query("type"),
+	}
+	jobs, nextPage, err := h.jobSvc.Fetch(c.UserContext(), page, size, filter)
+	if err != nil {
+		return err
+	}
+
+	if jobs == nil {
+		return domain.NewNotFoundError("jobs")
+	}
+
+	totalItem, err := h.jobSvc.Count(c.UserContext(), filter)
+	if err != nil {
+		return err
+	}
+
+	maxPage := int(totalItem) / size
+
+	if nextPage > 0 && nextPage <= maxPage {
+		c.Set("X-Cursor", strconv.Itoa(nextPage))
+	}
+	c.Set("X-Total-Count", strconv.Itoa(int(totalItem)))
+	c.Set("X-Max-Page", strconv.Itoa(maxPage))
+	return c.Status(fiber.StatusOK).JSON(domain.Success{
+		Code:    fiber.StatusOK,
+		Message: "success",
+		Data:    jobs,
+	})
+}
+
+// GetById used to get background job by id
+//
+//	@Summary		Get job by id
+//	@Description	Get background job by id with its payload, result and last error
+//	@Tags			jobs
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		int				true	"job ID"
+//	@Success		200	{object}	domain.Success	"job detail"
+//	@Failure		400	{object}	domain.Error	"Bad Request"
+//	@Failure		404	{object}	domain.Error	"Not Found"
+//	@Failure		500	{object}	domain.Error	"Internal Server Error"
+//	@Router			/jobs/{id} [get]
+//
+// @Security Bearer
+func (h *HttpJobHandler) GetById(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return domain.NewValidationError("invalid job id")
+	}
+
+	job, err := h.jobSvc.GetById(c.UserContext(), uint(id))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(domain.Success{
+		Code:    fiber.StatusOK,
+		Message: "success",
+		Data:    job,
+	})
+}
+
+// Retry used to put a dead job back on the queue
+//
+//	@Summary		Retry dead job
+//	@Description	Requeue a job from the dead letter with fresh attempts
+//	@Tags			jobs
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		int				true	"job ID"
+//	@Success		200	{object}	domain.Success	"requeued job"
+//	@Failure		400	{object}	domain.Error	"Bad Request"
+//	@Failure		404	{object}	domain.Error	"Not Found"
+//	@Failure		409	{object}	domain.Error	"Conflict"
+//	@Failure		500	{object}	domain.Error	"Internal Server Error"
+//	@Router			/jobs/{id}/retry [post]
+//
+// @Security Bearer
+func (h *HttpJobHandler) Retry(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return domain.NewValidationError("invalid job id")
+	}
+
+	job, err := h.jobSvc.Retry(c.UserContext(), uint(id))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(domain.Success{
+		Code:    fiber.StatusOK,
+		Message: "success",
+		Data:    job,
+	})
+}
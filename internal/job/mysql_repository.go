@@ -0,0 +1,166 @@
+package job
+
+import (
+	"book-store/internal/domain"
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var errStaleJob = errors.New("runner went away without finishing the job")
+
+type mysqlJobRepository struct {
+	db *gorm.DB
+}
+
+// Count
+func (m *mysqlJobRepository) Count(ctx context.Context, filter *domain.Job) (int64, error) {
+	var count int64
+
+	if err := applyFilter(m.db.WithContext(ctx).Model(&domain.Job{}), filter).Count(&count).Error; err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// Fetch
+func (m *mysqlJobRepository) Fetch(ctx context.Context, page int, size int, filter *domain.Job) ([]*domain.Job, int, error) {
+	var jobs []*domain.Job
+
+	offset := (page - 1) * size
+
+	if err := applyFilter(m.db.WithContext(ctx), filter).Order("created_at DESC").Offset(offset).Limit(size).Find(&jobs).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var nextCursor int
+	if len(jobs) > 0 {
+		nextCursor = page + 1 // Next page
+	}
+
+	return jobs, nextCursor, nil
+}
+
+// GetById
+func (m *mysqlJobRepository) GetById(ctx context.Context, id uint) (*domain.Job, error) {
+	var job *domain.Job
+
+	if err := m.db.WithContext(ctx).First(&job, id).Error; err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// Store
+func (m *mysqlJobRepository) Store(ctx context.Context, job *domain.Job) error {
+	return m.db.WithContext(ctx).Create(job).Error
+}
+
+// Update saves every field, a job going back to the queue has to clear its lock
+func (m *mysqlJobRepository) Update(ctx context.Context, job *domain.Job) error {
+	return m.db.WithContext(ctx).Save(job).Error
+}
+
+// Finish saves the outcome of the run reserved at lockedAt, unless the job was requeued as stale meanwhile. It
+// returns gorm.ErrRecordNotFound then, the job belongs to whichever runner reserved it next
+func (m *mysqlJobRepository) Finish(ctx context.Context, job *domain.Job, lockedAt time.Time) error {
+	result := m.db.WithContext(ctx).Model(job).
+		Where("status = ? AND locked_at = ?", domain.JobStatusRunning, lockedAt).
+		Select("*").
+		Updates(job)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Reserve takes the oldest due job of the given types and marks it running,
+// locked rows are skipped so several runners can share the table
+func (m *mysqlJobRepository) Reserve(ctx context.Context, types []string, now time.Time) (*domain.Job, error) {
+	var job domain.Job
+	// locked_at tells the runs of a job apart, it is kept to the milliseconds the column stores so Finish
+	// compares it equal
+	now = now.Truncate(time.Millisecond)
+
+	err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND run_at <= ? AND type IN ?", domain.JobStatusPending, now, types).
+			Order("run_at").
+			First(&job).Error; err != nil {
+			return err
+		}
+
+		job.Status = domain.JobStatusRunning
+		job.Attempts++
+		job.LockedAt = &now
+		return tx.Save(&job).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+// RequeueStale puts back running jobs whose runner went away without finishing them, the ones that used up
+// their attempts are moved to the dead letter instead so a job that keeps taking its runner down stops
+func (m *mysqlJobRepository) RequeueStale(ctx context.Context, lockedBefore time.Time) (int64, int64, error) {
+	var requeued, dead int64
+	now := time.Now()
+
+	err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&domain.Job{}).
+			Where("status = ? AND locked_at < ? AND attempts >= max_attempts", domain.JobStatusRunning, lockedBefore).
+			Updates(map[string]any{
+				"status":      domain.JobStatusDead,
+				"locked_at":   nil,
+				"finished_at": now,
+				"last_error":  errStaleJob.Error(),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		dead = result.RowsAffected
+
+		result = tx.Model(&domain.Job{}).
+			Where("status = ? AND locked_at < ?", domain.JobStatusRunning, lockedBefore).
+			Updates(map[string]any{
+				"status":    domain.JobStatusPending,
+				"locked_at": nil,
+				"run_at":    now,
+			})
+		requeued = result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return requeued, dead, nil
+}
+
+// applyFilter can filter by status/type
+func applyFilter(query *gorm.DB, filter *domain.Job) *gorm.DB {
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+
+	return query
+}
+
+func NewMysqlJobRepository(db *gorm.DB) domain.JobRepository {
+	return &mysqlJobRepository{db: db}
+}
@@ -0,0 +1,285 @@
+package job
+
+import (
+	"book-store/internal/config"
+	"book-store/internal/domain"
+	"book-store/internal/tracing"
+	"book-store/pkg/xlogger"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// maxBackoff caps the delay between attempts of a failing job
+const maxBackoff = time.Hour
+
+type registration struct {
+	handler domain.JobHandler
+	slots   chan struct{}
+}
+
+type jobRunner struct {
+	jobRepo domain.JobRepository
+	cfg     config.Job
+
+	handlers map[string]*registration
+	workers  chan struct{}
+
+	ctx      context.Context
+	cancel   context.CancelFunc
+	stop     chan struct{}
+	stopOnce sync.Once
+	polling  atomic.Bool
+	wg       sync.WaitGroup
+}
+
+// Register
+func (r *jobRunner) Register(jobType string, concurrency int, handler domain.JobHandler) {
+	if concurrency <= 0 || concurrency > r.cfg.Workers {
+		concurrency = r.cfg.Workers
+	}
+
+	r.handlers[jobType] = &registration{
+		handler: handler,
+		slots:   make(chan struct{}, concurrency),
+	}
+}
+
+// Start requeues jobs left running by a previous process and starts polling in the background
+func (r *jobRunner) Start() {
+	r.requeueStale()
+
+	r.wg.Add(1)
+	go r.poll()
+
+	xlogger.Logger.Info().Int("workers", r.cfg.Workers).Int("types", len(r.handlers)).Msg("job runner started")
+}
+
+// Name
+func (r *jobRunner) Name() string {
+	return "jobs"
+}
+
+// Check
+func (r *jobRunner) Check(_ context.Context) error {
+	if !r.polling.Load() {
+		return errors.New("job runner is not polling")
+	}
+	return nil
+}
+
+// Shutdown
+func (r *jobRunner) Shutdown(ctx context.Context) error {
+	r.stopOnce.Do(func() { close(r.stop) })
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		r.cancel()
+		return nil
+	case <-ctx.Done():
+		// jobs still running are requeued as stale by the next runner
+		r.cancel()
+		return ctx.Err()
+	}
+}
+
+func (r *jobRunner) poll() {
+	defer r.wg.Done()
+
+	r.polling.Store(true)
+	defer r.polling.Store(false)
+
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+
+	staleTicker := time.NewTicker(r.cfg.Timeout)
+	defer staleTicker.Stop()
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-staleTicker.C:
+			r.requeueStale()
+		case <-ticker.C:
+			r.dispatch()
+		}
+	}
+}
+
+// dispatch reserves due jobs while there are free workers, only types with a free slot are asked for
+func (r *jobRunner) dispatch() {
+	for {
+		select {
+		case <-r.stop:
+			return
+		default:
+		}
+
+		if len(r.workers) == cap(r.workers) {
+			return
+		}
+
+		var types []string
+		for jobType, reg := range r.handlers {
+			if len(reg.slots) < cap(reg.slots) {
+				types = append(types, jobType)
+			}
+		}
+		if len(types) == 0 {
+			return
+		}
+
+		job, err := r.jobRepo.Reserve(context.Background(), types, time.Now())
+		if err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				xlogger.Logger.Error().Err(err).Msg("failed to reserve job")
+			}
+			return
+		}
+
+		// only this goroutine acquires, so the free slots seen above are still free
+		reg := r.handlers[job.Type]
+		r.workers <- struct{}{}
+		reg.slots <- struct{}{}
+
+		r.wg.Add(1)
+		go func() {
+			defer r.wg.Done()
+			defer func() {
+				<-reg.slots
+				<-r.workers
+			}()
+
+			r.run(reg.handler, job)
+		}()
+	}
+}
+
+func (r *jobRunner) run(handler domain.JobHandler, job *domain.Job) {
+	ctx, span := tracing.Start(r.ctx, "job "+job.Type, trace.WithNewRoot(), trace.WithAttributes(
+		attribute.Int("job.id", int(job.ID)),
+		attribute.Int("job.attempt", job.Attempts),
+	))
+	defer span.End()
+
+	// the handler logs with the job fields through xlogger.Ctx
+	ctx = xlogger.With(ctx, func(l zerolog.Context) zerolog.Context {
+		return l.Uint("job_id", job.ID).Str("job_type", job.Type).Int("attempt", job.Attempts)
+	})
+	logger := xlogger.Ctx(ctx)
+
+	ctx, cancel := context.WithTimeout(ctx, r.cfg.Timeout)
+	defer cancel()
+
+	result, err := call(ctx, handler, job)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	now := time.Now()
+	lockedAt := *job.LockedAt
+	job.LockedAt = nil
+
+	switch {
+	case err == nil:
+		job.Status = domain.JobStatusDone
+		job.FinishedAt = &now
+		job.LastError = ""
+		if result != nil {
+			if job.Result, err = json.Marshal(result); err != nil {
+				logger.Error().Err(err).Msg("failed to encode job result")
+			}
+		}
+	case r.ctx.Err() != nil:
+		// interrupted by shutdown, the attempt doesn't count
+		job.Status = domain.JobStatusPending
+		job.Attempts--
+		job.RunAt = now
+		job.LastError = err.Error()
+	case job.Attempts >= job.MaxAttempts:
+		job.Status = domain.JobStatusDead
+		job.FinishedAt = &now
+		job.LastError = err.Error()
+		logger.Error().Err(err).Msg("job failed, moved to dead letter")
+	default:
+		job.Status = domain.JobStatusPending
+		job.RunAt = now.Add(r.backoff(job.Attempts))
+		job.LastError = err.Error()
+		logger.Warn().Err(err).Time("retry_at", job.RunAt).Msg("job failed, retrying")
+	}
+
+	// the outcome is saved even when the job timed out or was interrupted, but not over a later run of it
+	if err := r.jobRepo.Finish(context.WithoutCancel(ctx), job, lockedAt); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Warn().Msg("job was requeued as stale while running, outcome dropped")
+			return
+		}
+		logger.Error().Err(err).Msg("failed to save job")
+	}
+}
+
+// backoff doubles the configured delay on every attempt
+func (r *jobRunner) backoff(attempts int) time.Duration {
+	delay := r.cfg.RetryBackoff
+	for i := 1; i < attempts && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxBackoff)
+}
+
+// requeueStale gives a job twice its timeout before assuming the runner holding it is gone
+func (r *jobRunner) requeueStale() {
+	requeued, dead, err := r.jobRepo.RequeueStale(context.Background(), time.Now().Add(-2*r.cfg.Timeout))
+	if err != nil {
+		xlogger.Logger.Error().Err(err).Msg("failed to requeue stale jobs")
+		return
+	}
+	if requeued > 0 {
+		xlogger.Logger.Warn().Int64("count", requeued).Msg("requeued stale jobs")
+	}
+	if dead > 0 {
+		xlogger.Logger.Error().Int64("count", dead).Msg("stale jobs used up their attempts, moved to dead letter")
+	}
+}
+
+// call runs the handler and turns a panic into an error so one job can't take the runner down
+func call(ctx context.Context, handler domain.JobHandler, job *domain.Job) (result any, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("job panicked: %v", p)
+		}
+	}()
+
+	return handler(ctx, job)
+}
+
+func NewJobRunner(jobRepo domain.JobRepository, cfg config.Job) domain.JobRunner {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &jobRunner{
+		jobRepo:  jobRepo,
+		cfg:      cfg,
+		handlers: make(map[string]*registration),
+		workers:  make(chan struct{}, cfg.Workers),
+		ctx:      ctx,
+		cancel:   cancel,
+		stop:     make(chan struct{}),
+	}
+}
@@ -0,0 +1,126 @@
+package job
+
+import (
+	"book-store/internal/config"
+	"book-store/internal/domain"
+	"book-store/internal/tracing"
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type jobService struct {
+	jobRepo domain.JobRepository
+	cfg     config.Job
+}
+
+// Count
+func (j *jobService) Count(ctx context.Context, filter *domain.Job) (int64, error) {
+	ctx, span := tracing.Start(ctx, "JobService.Count")
+	defer span.End()
+
+	count, err := j.jobRepo.Count(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// Fetch
+func (j *jobService) Fetch(ctx context.Context, page int, size int, filter *domain.Job) ([]*domain.Job, int, error) {
+	ctx, span := tracing.Start(ctx, "JobService.Fetch")
+	defer span.End()
+
+	jobs, nextCursor, err := j.jobRepo.Fetch(ctx, page, size, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return jobs, nextCursor, nil
+}
+
+// GetById
+func (j *jobService) GetById(ctx context.Context, id uint) (*domain.Job, error) {
+	ctx, span := tracing.Start(ctx, "JobService.GetById")
+	defer span.End()
+
+	job, err := j.jobRepo.GetById(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrJobNotFound
+		}
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// Enqueue stores the job with its payload encoded as json, opts may be nil
+func (j *jobService) Enqueue(ctx context.Context, jobType string, payload any, opts *domain.JobOptions) (*domain.Job, error) {
+	ctx, span := tracing.Start(ctx, "JobService.Enqueue")
+	defer span.End()
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &domain.Job{
+		Type:        jobType,
+		Payload:     data,
+		Status:      domain.JobStatusPending,
+		MaxAttempts: j.cfg.MaxAttempts,
+		RunAt:       time.Now(),
+	}
+
+	if opts != nil {
+		if !opts.RunAt.IsZero() {
+			job.RunAt = opts.RunAt
+		}
+		if opts.MaxAttempts > 0 {
+			job.MaxAttempts = opts.MaxAttempts
+		}
+	}
+
+	if err := j.jobRepo.Store(ctx, job); err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// Retry puts a dead job back on the queue with fresh attempts
+func (j *jobService) Retry(ctx context.Context, id uint) (*domain.Job, error) {
+	ctx, span := tracing.Start(ctx, "JobService.Retry")
+	defer span.End()
+
+	job, err := j.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if job.Status != domain.JobStatusDead {
+		return nil, domain.ErrJobNotDead
+	}
+
+	job.Status = domain.JobStatusPending
+	job.Attempts = 0
+	job.RunAt = time.Now()
+	job.FinishedAt = nil
+
+	if err := j.jobRepo.Update(ctx, job); err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+func NewJobService(jobRepo domain.JobRepository, cfg config.Job) domain.JobService {
+	return &jobService{
+		jobRepo: jobRepo,
+		cfg:     cfg,
+	}
+}
@@ -0,0 +1,82 @@
+package loyalty
+
+import (
+	"book-store/internal/domain"
+	"book-store/internal/middleware/jwt"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type HttpLoyaltyHandler struct {
+	loyaltySvc     domain.LoyaltyService
+	authMiddleware jwt.AuthMiddleware
+}
+
+func NewHttpHandler(r fiber.Router, loyaltySvc domain.LoyaltyService, authMiddleware jwt.AuthMiddleware) {
+	handler := &HttpLoyaltyHandler{
+		loyaltySvc:     loyaltySvc,
+		authMiddleware: authMiddleware,
+	}
+
+	r.Get("/:id/loyalty", authMiddleware.RequireRole("admin", "employee"), handler.GetSummary)
+}
+
+// GetSummary used to get loyalty balance, tier and points ledger of a customer
+//
+//	@Summary		Get customer loyalty
+//	@Description	Get loyalty balance, tier and points ledger of a customer
+//	@Tags			customers
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int				true	"customer ID"
+//	@Param			page	query		int				false	"Ledger page number (default 1)"
+//	@Param			size	query		int				false	"Ledger size of page (default 10)"
+//	@Header			200		{string}	X-Cursor		"Next page"
+//	@Header			200		{string}	X-Total-Count	"Total ledger entry"
+//	@Header			200		{string}	X-Max-Page		"Max page"
+//	@Success		200		{object}	domain.Success	"loyalty summary"
+//	@Failure		400		{object}	domain.Error	"Bad Request"
+//	@Failure		404		{object}	domain.Error	"Not Found"
+//	@Failure		500		{object}	domain.Error	"Internal Server Error"
+//	@Router			/customers/{id}/loyalty [get]
+//
+// @Security Bearer
+func (h *HttpLoyaltyHandler) GetSummary(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return domain.NewValidationError("invalid customer id")
+	}
+
+	page, size := c.QueryInt("page", 1), c.QueryInt("size", 10)
+	if page <= 0 {
+		return domain.NewValidationError("page must be a positive integer")
+	}
+	if size <= 0 {
+		return domain.NewValidationError("size must be a positive integer")
+	}
+
+	summary, nextPage, err := h.loyaltySvc.GetSummary(c.UserContext(), uint(id), page, size)
+	if err != nil {
+		return err
+	}
+
+	totalItem, err := h.loyaltySvc.CountLedger(c.UserContext(), uint(id))
+	if err != nil {
+		return err
+	}
+
+	maxPage := int(totalItem) / size
+
+	if nextPage > 0 && nextPage <= maxPage {
+		c.Set("X-Cursor", strconv.Itoa(nextPage))
+	}
+	c.Set("X-Total-Count", strconv.Itoa(int(totalItem)))
+	c.Set("X-Max-Page", strconv.Itoa(maxPage))
+
+	return c.Status(fiber.StatusOK).JSON(domain.Success{
+		Code:    fiber.StatusOK,
+		Message: "success",
+		Data:    summary,
+	})
+}
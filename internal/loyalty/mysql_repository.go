@@ -0,0 +1,44 @@
+package loyalty
+
+import (
+	"book-store/internal/domain"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type mysqlLoyaltyRepository struct {
+	db *gorm.DB
+}
+
+// FetchLedger
+func (m *mysqlLoyaltyRepository) FetchLedger(ctx context.Context, customerId uint, page int, size int) ([]*domain.LoyaltyLedger, int, error) {
+	var entries []*domain.LoyaltyLedger
+
+	offset := (page - 1) * size
+	if err := m.db.WithContext(ctx).Where("customer_id = ?", customerId).Order("created_at DESC").Offset(offset).Limit(size).Find(&entries).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var nextCursor int
+	if len(entries) > 0 {
+		nextCursor = page + 1 // Next page
+	}
+
+	return entries, nextCursor, nil
+}
+
+// CountLedger
+func (m *mysqlLoyaltyRepository) CountLedger(ctx context.Context, customerId uint) (int64, error) {
+	var count int64
+
+	if err := m.db.WithContext(ctx).Model(&domain.LoyaltyLedger{}).Where("customer_id = ?", customerId).Count(&count).Error; err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func NewMysqlLoyaltyRepository(db *gorm.DB) domain.LoyaltyRepository {
+	return &mysqlLoyaltyRepository{db: db}
+}
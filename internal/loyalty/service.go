@@ -0,0 +1,133 @@
+package loyalty
+
+import (
+	"book-store/internal/config"
+	"book-store/internal/domain"
+	"book-store/internal/tracing"
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+type loyaltyService struct {
+	loyaltyRepo  domain.LoyaltyRepository
+	customerRepo domain.CustomerRepository
+	cfg          config.Loyalty
+}
+
+// GetSummary
+func (l *loyaltyService) GetSummary(ctx context.Context, customerId uint, page int, size int) (*domain.LoyaltySummary, int, error) {
+	ctx, span := tracing.Start(ctx, "LoyaltyService.GetSummary")
+	defer span.End()
+
+	customer, err := l.customerRepo.GetById(ctx, customerId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, 0, domain.ErrCustomerNotFound
+		}
+		return nil, 0, err
+	}
+
+	ledger, nextCursor, err := l.loyaltyRepo.FetchLedger(ctx, customerId, page, size)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	tier, nextTier := domain.TierFor(customer.LifetimePoints)
+	summary := &domain.LoyaltySummary{
+		CustomerId:     customer.ID,
+		Tier:           tier,
+		NextTier:       nextTier,
+		Balance:        customer.LoyaltyPoints,
+		BalanceValue:   customer.LoyaltyPoints * l.cfg.PointValue,
+		LifetimePoints: customer.LifetimePoints,
+		Ledger:         ledger,
+	}
+	if nextTier != nil {
+		summary.PointsToNextTier = nextTier.MinPoints - customer.LifetimePoints
+	}
+
+	return summary, nextCursor, nil
+}
+
+// CountLedger
+func (l *loyaltyService) CountLedger(ctx context.Context, customerId uint) (int64, error) {
+	ctx, span := tracing.Start(ctx, "LoyaltyService.CountLedger")
+	defer span.End()
+
+	return l.loyaltyRepo.CountLedger(ctx, customerId)
+}
+
+// CheckRedeemable
+func (l *loyaltyService) CheckRedeemable(ctx context.Context, customerId uint, amount int) error {
+	ctx, span := tracing.Start(ctx, "LoyaltyService.CheckRedeemable")
+	defer span.End()
+
+	customer, err := l.customerRepo.GetById(ctx, customerId)
+	if err != nil {
+		return err
+	}
+
+	if customer.LoyaltyPoints < l.pointsFor(amount) {
+		return domain.ErrInsufficientPoints
+	}
+
+	return nil
+}
+
+// EarnEntry awards points for a paid transaction, boosted by the customer's tier
+func (l *loyaltyService) EarnEntry(ctx context.Context, customerId uint, totalPrice int) (*domain.LoyaltyLedger, error) {
+	ctx, span := tracing.Start(ctx, "LoyaltyService.EarnEntry")
+	defer span.End()
+
+	customer, err := l.customerRepo.GetById(ctx, customerId)
+	if err != nil {
+		return nil, err
+	}
+
+	tier, _ := domain.TierFor(customer.LifetimePoints)
+	points := totalPrice / l.cfg.AmountPerPoint * tier.EarnRate / 100
+	if points <= 0 {
+		return nil, nil
+	}
+
+	return &domain.LoyaltyLedger{
+		CustomerId:  customerId,
+		Type:        domain.LoyaltyEntryEarn,
+		Points:      points,
+		Description: fmt.Sprintf("earned at %s tier", tier.Name),
+	}, nil
+}
+
+// RedeemEntry takes the points covering amount off the customer's balance
+func (l *loyaltyService) RedeemEntry(amount int) *domain.LoyaltyLedger {
+	return &domain.LoyaltyLedger{
+		Type:        domain.LoyaltyEntryRedeem,
+		Points:      -l.pointsFor(amount),
+		Description: "redeemed as payment",
+	}
+}
+
+// DiscountEntry
+func (l *loyaltyService) DiscountEntry(points int) (*domain.LoyaltyLedger, int) {
+	return &domain.LoyaltyLedger{
+		Type:        domain.LoyaltyEntryRedeem,
+		Points:      -points,
+		Description: "redeemed as discount",
+	}, points * l.cfg.PointValue
+}
+
+// pointsFor converts a money amount into points, rounding up so a redemption is never undercharged
+func (l *loyaltyService) pointsFor(amount int) int {
+	return (amount + l.cfg.PointValue - 1) / l.cfg.PointValue
+}
+
+func NewLoyaltyService(loyaltyRepo domain.LoyaltyRepository, customerRepo domain.CustomerRepository, cfg config.Loyalty) domain.LoyaltyService {
+	return &loyaltyService{
+		loyaltyRepo:  loyaltyRepo,
+		customerRepo: customerRepo,
+		cfg:          cfg,
+	}
+}
@@ -0,0 +1,20 @@
+package mail
+
+import (
+	"book-store/internal/domain"
+	"book-store/pkg/xlogger"
+	"context"
+)
+
+// logMailer writes mails to the log instead of sending them, used until a real provider is wired in
+type logMailer struct{}
+
+// Send
+func (logMailer) Send(ctx context.Context, mail *domain.Mail) error {
+	xlogger.Ctx(ctx).Info().Str("to", mail.To).Str("subject", mail.Subject).Str("body", mail.Body).Msg("mail sent")
+	return nil
+}
+
+func NewLogMailer() domain.Mailer {
+	return logMailer{}
+}
@@ -0,0 +1,48 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "book_store"
+
+var (
+	HttpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of http requests by route pattern and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	DbQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Latency of gorm queries by operation and table.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation", "table", "status"})
+
+	TransactionsCreated = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transactions_created_total",
+		Help:      "Transactions stored, paid or not.",
+	})
+
+	UnitsSold = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "units_sold_total",
+		Help:      "Book units taken off stock by paid transactions.",
+	})
+
+	FailedLogins = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "failed_logins_total",
+		Help:      "Rejected logins by reason.",
+	}, []string{"reason"})
+
+	StockOuts = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "stock_outs_total",
+		Help:      "Times a book sold its last unit.",
+	})
+)
@@ -3,49 +3,48 @@ package jwt
 import (
 	"book-store/internal/domain"
 	"book-store/internal/utilities"
+	"book-store/pkg/xlogger"
+	"errors"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
 )
 
 type AuthMiddleware interface {
+	// RequireRole lets users of the roles through, unless they have to change their password or set up
+	// two-factor authentication first
 	RequireRole(roles ...string) fiber.Handler
+	// RequireUser lets any authenticated user through, including the ones who have to change their password or
+	// set up two-factor authentication
+	RequireUser() fiber.Handler
+	// Subject is the user id of a valid bearer token, it doesn't reject anything
+	Subject(c *fiber.Ctx) (string, bool)
 }
 
 type authMiddleware struct {
 	jwtService utilities.JwtTokenService
+	userRepo   domain.UserRepository
 }
 
 // RequireRole
 func (a *authMiddleware) RequireRole(roles ...string) func(*fiber.Ctx) error {
 	return func(ctx *fiber.Ctx) error {
-		authHeader := ctx.Get("Authorization")
-
-		tokenString := strings.Replace(authHeader, "Bearer ", "", -1)
-		if tokenString == "" {
-			return ctx.Status(fiber.StatusUnauthorized).JSON(domain.Error{
-				Code:    fiber.StatusUnauthorized,
-				Message: "unauthorized",
-			})
-		}
-
-		claims, err := a.jwtService.VerifyToken(tokenString)
+		claims, err := a.authenticate(ctx)
 		if err != nil {
-			return ctx.Status(fiber.StatusUnauthorized).JSON(domain.Error{
-				Code:    fiber.StatusUnauthorized,
-				Message: "unauthorized",
-			})
+			return err
 		}
 
-		userName, ok := claims["user_name"].(string)
-		if !ok {
-			return ctx.Status(fiber.StatusUnauthorized).JSON(domain.Error{
-				Code:    fiber.StatusUnauthorized,
-				Message: "unauthorized",
-			})
+		if mustChange, _ := claims["must_change_password"].(bool); mustChange {
+			return domain.ErrPasswordChangeRequired
+		}
+		if setupRequired, _ := claims["two_factor_setup_required"].(bool); setupRequired {
+			return domain.ErrTwoFactorSetupRequired
 		}
-
-		ctx.Set("user", userName)
 
 		var validRole bool
 		for _, role := range roles {
@@ -56,18 +55,106 @@ func (a *authMiddleware) RequireRole(roles ...string) func(*fiber.Ctx) error {
 		}
 
 		if !validRole {
-			return ctx.Status(fiber.StatusForbidden).JSON(domain.Error{
-				Code:    fiber.StatusForbidden,
-				Message: "this role is not allowed to access this resource",
-			})
+			return domain.ErrForbidden
 		}
 
 		return ctx.Next()
 	}
 }
 
-func NewAuthMiddleware(jwtService utilities.JwtTokenService) AuthMiddleware {
+// RequireUser
+func (a *authMiddleware) RequireUser() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if _, err := a.authenticate(ctx); err != nil {
+			return err
+		}
+
+		return ctx.Next()
+	}
+}
+
+// authenticate verifies the bearer token and puts the user in the user context for logs, audit and services.
+// Tokens of deleted users and tokens issued before the last password change are refused
+func (a *authMiddleware) authenticate(ctx *fiber.Ctx) (jwt.MapClaims, error) {
+	claims, ok := a.claims(ctx)
+	if !ok {
+		return nil, domain.ErrUnauthorized
+	}
+
+	userName, ok := claims["user_name"].(string)
+	if !ok {
+		return nil, domain.ErrUnauthorized
+	}
+
+	subject, err := claims.GetSubject()
+	if err != nil {
+		return nil, domain.ErrUnauthorized
+	}
+	userId, err := strconv.ParseUint(subject, 10, 0)
+	if err != nil {
+		return nil, domain.ErrUnauthorized
+	}
+	issuedAt, err := claims.GetIssuedAt()
+	if err != nil || issuedAt == nil {
+		return nil, domain.ErrUnauthorized
+	}
+
+	user, err := a.userRepo.GetById(ctx.UserContext(), uint(userId))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrUnauthorized
+		}
+		return nil, err
+	}
+	if user.DeletedAt.Valid {
+		return nil, domain.ErrUnauthorized
+	}
+	// iat only has seconds, the token handed out with a password change is issued within its second
+	if user.PasswordChangedAt != nil && issuedAt.Before(user.PasswordChangedAt.Truncate(time.Second)) {
+		return nil, domain.ErrTokenRevoked
+	}
+
+	ctx.Set("user", userName)
+	userCtx := xlogger.With(ctx.UserContext(), func(l zerolog.Context) zerolog.Context {
+		return l.Str("user", userName).Interface("role", claims["role_name"]).Str("route", ctx.Route().Path)
+	})
+	userCtx = domain.WithActor(userCtx, userName)
+	userCtx = domain.WithUserId(userCtx, uint(userId))
+	ctx.SetUserContext(userCtx)
+
+	return claims, nil
+}
+
+// Subject
+func (a *authMiddleware) Subject(c *fiber.Ctx) (string, bool) {
+	claims, ok := a.claims(c)
+	if !ok {
+		return "", false
+	}
+
+	// user names can be changed and reused, the subject stays with the user
+	subject, err := claims.GetSubject()
+	return subject, err == nil && subject != ""
+}
+
+// claims of the bearer token, false when there is none or it doesn't verify
+func (a *authMiddleware) claims(c *fiber.Ctx) (jwt.MapClaims, bool) {
+	tokenString := strings.Replace(c.Get("Authorization"), "Bearer ", "", -1)
+	if tokenString == "" {
+		return nil, false
+	}
+
+	claims, err := a.jwtService.VerifyToken(tokenString)
+	if err != nil {
+		return nil, false
+	}
+
+	return claims, true
+}
+
+func NewAuthMiddleware(jwtService utilities.JwtTokenService, userRepo domain.UserRepository) AuthMiddleware {
 	return &authMiddleware{
 		jwtService: jwtService,
+		userRepo:   userRepo,
 	}
 }
@@ -0,0 +1,113 @@
+package jwt
+
+import (
+	"book-store/internal/domain"
+	"book-store/internal/utilities"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+// fixedClaims verifies every token as the claims it holds
+type fixedClaims struct {
+	utilities.JwtTokenService
+	claims jwt.MapClaims
+}
+
+func (f fixedClaims) VerifyToken(string) (jwt.MapClaims, error) {
+	return f.claims, nil
+}
+
+type users struct {
+	domain.UserRepository
+	users map[uint]*domain.User
+}
+
+func (u users) GetById(_ context.Context, id uint) (*domain.User, error) {
+	user, ok := u.users[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return user, nil
+}
+
+func TestAuthMiddlewareRequireUser(t *testing.T) {
+	issuedAt := time.Date(2024, 3, 14, 9, 0, 0, 0, time.UTC)
+	sameSecond := issuedAt.Add(700 * time.Millisecond)
+	after := issuedAt.Add(time.Minute)
+
+	repo := users{users: map[uint]*domain.User{
+		1: {Model: gorm.Model{ID: 1}},
+		2: {Model: gorm.Model{ID: 2}, PasswordChangedAt: &sameSecond},
+		3: {Model: gorm.Model{ID: 3}, PasswordChangedAt: &after},
+		4: {Model: gorm.Model{ID: 4, DeletedAt: gorm.DeletedAt{Time: after, Valid: true}}},
+	}}
+
+	claims := func(subject any) jwt.MapClaims {
+		claims := jwt.MapClaims{"user_name": "jane", "role_name": "admin", "iat": float64(issuedAt.Unix())}
+		if subject != nil {
+			claims["sub"] = subject
+		}
+		return claims
+	}
+
+	tests := []struct {
+		name       string
+		claims     jwt.MapClaims
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "valid", claims: claims("1"), wantStatus: fiber.StatusOK},
+		{name: "password changed within the second of issue", claims: claims("2"), wantStatus: fiber.StatusOK},
+		{name: "password changed after issue", claims: claims("3"), wantStatus: fiber.StatusUnauthorized, wantCode: "token_revoked"},
+		{name: "deleted user", claims: claims("4"), wantStatus: fiber.StatusUnauthorized, wantCode: "unauthorized"},
+		{name: "unknown user", claims: claims("5"), wantStatus: fiber.StatusUnauthorized, wantCode: "unauthorized"},
+		{name: "missing subject", claims: claims(nil), wantStatus: fiber.StatusUnauthorized, wantCode: "unauthorized"},
+		{name: "subject not an id", claims: claims("jane"), wantStatus: fiber.StatusUnauthorized, wantCode: "unauthorized"},
+		{name: "missing issued at", claims: jwt.MapClaims{"user_name": "jane", "sub": "1"}, wantStatus: fiber.StatusUnauthorized, wantCode: "unauthorized"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authMiddleware := NewAuthMiddleware(fixedClaims{claims: tt.claims}, repo)
+
+			app := fiber.New(fiber.Config{ErrorHandler: utilities.ErrorHandler})
+			app.Get("/", authMiddleware.RequireUser(), func(c *fiber.Ctx) error {
+				userId, ok := domain.UserIdFrom(c.UserContext())
+				if !ok {
+					return errors.New("user id missing from the user context")
+				}
+				return c.JSON(userId)
+			})
+
+			req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+			req.Header.Set(fiber.HeaderAuthorization, "Bearer token")
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if tt.wantCode == "" {
+				return
+			}
+
+			var problem domain.Error
+			if err := json.NewDecoder(resp.Body).Decode(&problem); err != nil {
+				t.Fatal(err)
+			}
+			if problem.Code != tt.wantCode {
+				t.Errorf("code = %q, want %q", problem.Code, tt.wantCode)
+			}
+		})
+	}
+}
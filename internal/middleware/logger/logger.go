@@ -0,0 +1,27 @@
+package logger
+
+import (
+	"book-store/internal/domain"
+	"book-store/pkg/xlogger"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog"
+)
+
+// New puts a logger with the request id, method and path in the user context, services get it with xlogger.Ctx,
+// and keeps the request id for the audit log.
+// It has to run after requestid and tracing, the user and route are added once the route is authorized
+func New() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		requestId := c.GetRespHeader(fiber.HeaderXRequestID)
+		ctx := xlogger.With(c.UserContext(), func(l zerolog.Context) zerolog.Context {
+			return l.
+				Str("request_id", requestId).
+				Str("method", c.Method()).
+				Str("path", c.Path())
+		})
+		c.SetUserContext(domain.WithRequestId(ctx, requestId))
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,72 @@
+package ratelimit
+
+import (
+	"book-store/internal/config"
+	"book-store/internal/domain"
+	"book-store/internal/middleware/jwt"
+	"book-store/pkg/xlogger"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Limiter hands out the middleware of each policy, they share the store but not their counts
+type Limiter struct {
+	store          Store
+	authMiddleware jwt.AuthMiddleware
+	enabled        bool
+}
+
+// Policy limits the requests of every user, or ip for anonymous requests, to the route group it is used on.
+// name keeps the counts apart from the other groups, the RateLimit-* headers tell the client where it stands
+func (l *Limiter) Policy(name string, policy config.RateLimitPolicy) fiber.Handler {
+	if !l.enabled || policy.Limit == 0 {
+		return func(c *fiber.Ctx) error {
+			return c.Next()
+		}
+	}
+
+	policyHeader := fmt.Sprintf("%d;w=%d", policy.Limit, int(policy.Window.Seconds()))
+
+	return func(c *fiber.Ctx) error {
+		result, err := l.store.Take(c.UserContext(), name+":"+l.principal(c), policy.Limit, policy.Window)
+		if err != nil {
+			// a broken store shouldn't take the api down with it
+			xlogger.Ctx(c.UserContext()).Error().Err(err).Str("policy", name).Msg("rate limit store failed")
+			return c.Next()
+		}
+
+		reset := strconv.Itoa(int(math.Ceil(time.Until(result.Reset).Seconds())))
+		c.Set("RateLimit-Policy", policyHeader)
+		c.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Set("RateLimit-Remaining", strconv.Itoa(max(result.Remaining, 0)))
+		c.Set("RateLimit-Reset", reset)
+
+		if !result.Allowed {
+			c.Set(fiber.HeaderRetryAfter, reset)
+			xlogger.Ctx(c.UserContext()).Warn().Str("policy", name).Msg("rate limited")
+			return domain.ErrRateLimited
+		}
+
+		return c.Next()
+	}
+}
+
+// principal is the user of a valid token, else the client ip, which comes from the proxy header when one is configured
+func (l *Limiter) principal(c *fiber.Ctx) string {
+	if subject, ok := l.authMiddleware.Subject(c); ok {
+		return "user:" + subject
+	}
+	return "ip:" + c.IP()
+}
+
+func NewLimiter(store Store, authMiddleware jwt.AuthMiddleware, cfg config.RateLimit) *Limiter {
+	return &Limiter{
+		store:          store,
+		authMiddleware: authMiddleware,
+		enabled:        cfg.Enabled,
+	}
+}
@@ -0,0 +1,84 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often MemoryStore forgets windows that are over
+const sweepInterval = time.Minute
+
+// Result is the state of a key after counting a request
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is when the current window ends and the count starts over
+	Reset time.Time
+}
+
+// Store counts requests per key in fixed windows. MemoryStore keeps the counts of one instance,
+// a backend shared by several instances only has to implement Take
+type Store interface {
+	Take(ctx context.Context, key string, limit int, window time.Duration) (*Result, error)
+}
+
+type counter struct {
+	count int
+	reset time.Time
+}
+
+// MemoryStore
+type MemoryStore struct {
+	mu        sync.Mutex
+	counters  map[string]*counter
+	nextSweep time.Time
+}
+
+// Take
+func (s *MemoryStore) Take(_ context.Context, key string, limit int, window time.Duration) (*Result, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	c, ok := s.counters[key]
+	if !ok || !now.Before(c.reset) {
+		c = &counter{reset: now.Add(window)}
+		s.counters[key] = c
+	}
+
+	// rejected requests aren't counted so a client that backs off gets its full limit in the next window
+	allowed := c.count < limit
+	if allowed {
+		c.count++
+	}
+
+	return &Result{
+		Allowed:   allowed,
+		Limit:     limit,
+		Remaining: limit - c.count,
+		Reset:     c.reset,
+	}, nil
+}
+
+// sweep drops the counters of windows that are over, at most once per sweepInterval
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Before(s.nextSweep) {
+		return
+	}
+	s.nextSweep = now.Add(sweepInterval)
+
+	for key, c := range s.counters {
+		if !now.Before(c.reset) {
+			delete(s.counters, key)
+		}
+	}
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{counters: make(map[string]*counter)}
+}
@@ -0,0 +1,23 @@
+package softdelete
+
+import (
+	"book-store/internal/domain"
+	"book-store/internal/middleware/jwt"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// New lets admins see soft-deleted records with ?include_deleted=true, the route stays open to
+// everyone it was open to before as long as the query isn't set
+func New(authMiddleware jwt.AuthMiddleware) fiber.Handler {
+	requireAdmin := authMiddleware.RequireRole("admin")
+
+	return func(c *fiber.Ctx) error {
+		if !c.QueryBool("include_deleted") {
+			return c.Next()
+		}
+
+		c.SetUserContext(domain.WithDeleted(c.UserContext()))
+		return requireAdmin(c)
+	}
+}
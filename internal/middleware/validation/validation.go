@@ -7,29 +7,44 @@ import (
 	"github.com/gofiber/fiber/v2"
 )
 
+var validate = validator.New(validator.WithRequiredStructEnabled())
+
 func New[V any]() fiber.Handler {
-	validate := validator.New(validator.WithRequiredStructEnabled())
 	return func(c *fiber.Ctx) error {
 		var v V
 		if err := c.BodyParser(&v); err != nil {
-			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+			return domain.NewValidationError("invalid request body").Wrap(err)
 		}
-		if err := validate.Struct(v); err != nil {
-			var errors []string
-			for _, err := range err.(validator.ValidationErrors) {
-				message := err.Field() + " is " + err.Tag()
-				if err.Param() != "" {
-					message += " " + err.Param()
-				}
-				errors = append(errors, message)
-			}
-			return c.Status(fiber.StatusBadRequest).JSON(domain.Error{
-				Code:    fiber.StatusBadRequest,
-				Errors:  errors,
-				Message: "validation error",
-			})
+		if errors := Struct(v); errors != nil {
+			return domain.NewValidationError("validation error", errors...)
 		}
 		c.Locals("parser", &v)
 		return c.Next()
 	}
 }
+
+// Struct validates v with the same rules as the middleware and returns one message per failed field
+func Struct(v any) []string {
+	return messages(validate.Struct(v))
+}
+
+// StructExcept is Struct without the rules of the given fields
+func StructExcept(v any, fields ...string) []string {
+	return messages(validate.StructExcept(v, fields...))
+}
+
+func messages(err error) []string {
+	if err == nil {
+		return nil
+	}
+
+	var errors []string
+	for _, err := range err.(validator.ValidationErrors) {
+		message := err.Field() + " is " + err.Tag()
+		if err.Param() != "" {
+			message += " " + err.Param()
+		}
+		errors = append(errors, message)
+	}
+	return errors
+}
@@ -0,0 +1,124 @@
+package password
+
+import (
+	"book-store/internal/domain"
+	"book-store/internal/middleware/jwt"
+	"book-store/internal/middleware/validation"
+	"book-store/internal/utilities"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type HttpPasswordHandler struct {
+	passwordSvc    domain.PasswordService
+	authMiddleware jwt.AuthMiddleware
+}
+
+// NewHttpHandler serves the passwords of users under /users
+func NewHttpHandler(r fiber.Router, passwordSvc domain.PasswordService, authMiddleware jwt.AuthMiddleware) {
+	handler := &HttpPasswordHandler{passwordSvc: passwordSvc, authMiddleware: authMiddleware}
+
+	r.Post("/me/password", authMiddleware.RequireUser(), validation.New[domain.PasswordChangeRequest](), handler.Change)
+	r.Post("/:id/password-reset", authMiddleware.RequireRole("admin"), handler.CreateReset)
+}
+
+// NewResetHttpHandler serves the redemption of reset tokens under /auth, it needs no token
+func NewResetHttpHandler(r fiber.Router, passwordSvc domain.PasswordService, authMiddleware jwt.AuthMiddleware) {
+	handler := &HttpPasswordHandler{passwordSvc: passwordSvc, authMiddleware: authMiddleware}
+
+	r.Post("/password-reset", validation.New[domain.PasswordResetRequest](), handler.Reset)
+}
+
+// Change used to change the password of the authenticated user
+//
+//	@Summary		Change own password
+//	@Description	Change the password of the authenticated user, the only thing users who have to change their password can do. Answers with a new token
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			password	body		domain.PasswordChangeRequest	true	"current and new password"
+//	@Success		200			{object}	domain.Success					"token detail"
+//	@Failure		400			{object}	domain.Error					"Bad Request"
+//	@Failure		401			{object}	domain.Error					"Unauthorized"
+//	@Failure		429			{object}	domain.Error					"Too Many Failed Logins"
+//	@Failure		500			{object}	domain.Error					"Internal Server Error"
+//	@Router			/users/me/password [post]
+//
+// @Security Bearer
+func (h *HttpPasswordHandler) Change(c *fiber.Ctx) error {
+	userId, ok := domain.UserIdFrom(c.UserContext())
+	if !ok {
+		return domain.ErrUnauthorized
+	}
+
+	passwordReq := utilities.ExtractStructFromValidator[domain.PasswordChangeRequest](c)
+
+	token, err := h.passwordSvc.Change(c.UserContext(), userId, passwordReq)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(domain.Success{
+		Code:    fiber.StatusOK,
+		Message: "password changed successfully",
+		Data:    token,
+	})
+}
+
+// CreateReset used to hand out a password reset token
+//
+//	@Summary		Create password reset token
+//	@Description	Create a token the user sets a new password with at POST /auth/password-reset. It is shown only once, expires and revokes the earlier ones
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		int				true	"user ID"
+//	@Success		201	{object}	domain.Success	"password reset token"
+//	@Failure		400	{object}	domain.Error	"Bad Request"
+//	@Failure		404	{object}	domain.Error	"Not Found"
+//	@Failure		500	{object}	domain.Error	"Internal Server Error"
+//	@Router			/users/{id}/password-reset [post]
+//
+// @Security Bearer
+func (h *HttpPasswordHandler) CreateReset(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return domain.NewValidationError("invalid user id")
+	}
+
+	reset, err := h.passwordSvc.CreateReset(c.UserContext(), uint(id))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(domain.Success{
+		Code:    fiber.StatusCreated,
+		Message: "success",
+		Data:    reset,
+	})
+}
+
+// Reset used to set a new password with a reset token
+//
+//	@Summary		Reset password
+//	@Description	Set a new password with a reset token handed out by an admin, the token can be used once
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			password	body		domain.PasswordResetRequest	true	"reset token and new password"
+//	@Success		200			{object}	domain.Success				"password reset"
+//	@Failure		400			{object}	domain.Error				"Bad Request"
+//	@Failure		500			{object}	domain.Error				"Internal Server Error"
+//	@Router			/auth/password-reset [post]
+func (h *HttpPasswordHandler) Reset(c *fiber.Ctx) error {
+	resetReq := utilities.ExtractStructFromValidator[domain.PasswordResetRequest](c)
+
+	if err := h.passwordSvc.Reset(c.UserContext(), resetReq); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(domain.Success{
+		Code:    fiber.StatusOK,
+		Message: "password reset successfully",
+	})
+}
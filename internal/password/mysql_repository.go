@@ -0,0 +1,67 @@
+package password
+
+import (
+	"book-store/internal/domain"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type mysqlPasswordResetRepository struct {
+	db *gorm.DB
+}
+
+// Store
+func (m *mysqlPasswordResetRepository) Store(ctx context.Context, token *domain.PasswordResetToken) error {
+	return m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := revoke(tx, token.UserId, time.Now()); err != nil {
+			return err
+		}
+
+		return tx.Create(token).Error
+	})
+}
+
+// Redeem locks the token so it can't be used twice at once
+func (m *mysqlPasswordResetRepository) Redeem(ctx context.Context, tokenHash string, passwordHash string, now time.Time) (*domain.PasswordResetToken, error) {
+	var token domain.PasswordResetToken
+
+	err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", tokenHash, now).
+			First(&token).Error; err != nil {
+			return err
+		}
+
+		user := &domain.User{Model: gorm.Model{ID: token.UserId}, Password: passwordHash, PasswordChangedAt: &now}
+		result := tx.Select(domain.UserPasswordColumns).Updates(user)
+		if result.Error != nil {
+			return result.Error
+		}
+		// the user was deleted since the token was handed out
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		return revoke(tx, token.UserId, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	token.UsedAt = &now
+	return &token, nil
+}
+
+// revoke uses up the tokens of the user still unused
+func revoke(tx *gorm.DB, userId uint, now time.Time) error {
+	return tx.Model(&domain.PasswordResetToken{}).
+		Where("user_id = ? AND used_at IS NULL", userId).
+		Update("used_at", now).Error
+}
+
+func NewMysqlPasswordResetRepository(db *gorm.DB) domain.PasswordResetRepository {
+	return &mysqlPasswordResetRepository{db: db}
+}
@@ -0,0 +1,209 @@
+package password
+
+import (
+	"book-store/internal/config"
+	"book-store/internal/domain"
+	"book-store/internal/metrics"
+	"book-store/internal/tracing"
+	"book-store/internal/utilities"
+	"book-store/pkg/xlogger"
+	"context"
+	"errors"
+	"strconv"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+// maxBytes is as much of a password as bcrypt uses, it refuses longer ones
+const maxBytes = 72
+
+type passwordService struct {
+	userRepo     domain.UserRepository
+	resetRepo    domain.PasswordResetRepository
+	throttleRepo domain.LoginThrottleRepository
+	jwtService   utilities.JwtTokenService
+	cfg          config.Password
+	loginCfg     config.Login
+}
+
+// Hash
+func (p *passwordService) Hash(password string) (string, error) {
+	if err := p.validate(password); err != nil {
+		return "", err
+	}
+
+	return utilities.HashPassword(password)
+}
+
+// Change
+func (p *passwordService) Change(ctx context.Context, userId uint, req *domain.PasswordChangeRequest) (domain.Token, error) {
+	ctx, span := tracing.Start(ctx, "PasswordService.Change")
+	defer span.End()
+
+	user, err := p.userRepo.GetById(ctx, userId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.Token{}, domain.ErrUserNotFound
+		}
+		return domain.Token{}, err
+	}
+
+	if err := p.VerifyCurrent(ctx, user, req.CurrentPassword); err != nil {
+		return domain.Token{}, err
+	}
+	if req.NewPassword == req.CurrentPassword {
+		return domain.Token{}, domain.ErrWeakPassword.WithDetails("new password must differ from the current one")
+	}
+
+	hash, err := p.Hash(req.NewPassword)
+	if err != nil {
+		return domain.Token{}, err
+	}
+
+	now := time.Now()
+	user.Password, user.MustChangePassword, user.PasswordChangedAt = hash, false, &now
+	if err := p.userRepo.UpdatePassword(ctx, user); err != nil {
+		return domain.Token{}, err
+	}
+
+	xlogger.Ctx(ctx).Info().Uint("user_id", user.ID).Msg("password changed")
+	return p.jwtService.GenerateToken(user)
+}
+
+// VerifyCurrent counts wrong passwords as failed logins of the email, so a stolen token can't be used to guess it
+func (p *passwordService) VerifyCurrent(ctx context.Context, user *domain.User, password string) error {
+	key := domain.AccountThrottleKey(user.Email)
+	throttle, err := p.throttleRepo.Get(ctx, key)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if throttle != nil && throttle.Locked(time.Now()) {
+		return domain.ErrLoginLocked
+	}
+
+	if isMatch, _ := utilities.ComparePassword(password, user.Password); isMatch {
+		return nil
+	}
+
+	metrics.FailedLogins.WithLabelValues("invalid_current_password").Inc()
+	xlogger.Ctx(ctx).Warn().Uint("user_id", user.ID).Str("reason", "invalid_current_password").Msg("current password check failed")
+	throttle, err = p.throttleRepo.Update(ctx, key, func(throttle *domain.LoginThrottle) {
+		throttle.Fail(time.Now(), p.loginCfg.FailureWindow, p.loginCfg.MaxFailures, p.loginCfg.Lockout)
+	})
+	if err != nil {
+		return err
+	}
+	if throttle.Locked(time.Now()) {
+		xlogger.Ctx(ctx).Warn().Str("key", key).Time("locked_until", *throttle.LockedUntil).Msg("login locked out")
+	}
+	return domain.ErrInvalidCurrentPassword
+}
+
+// CreateReset hands out a token for the user to set a new password with, revoking the earlier ones
+func (p *passwordService) CreateReset(ctx context.Context, userId uint) (*domain.PasswordReset, error) {
+	ctx, span := tracing.Start(ctx, "PasswordService.CreateReset")
+	defer span.End()
+
+	if _, err := p.userRepo.GetById(ctx, userId); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	token, err := utilities.NewToken()
+	if err != nil {
+		return nil, err
+	}
+
+	resetToken := &domain.PasswordResetToken{
+		UserId:    userId,
+		TokenHash: utilities.HashToken(token),
+		ExpiresAt: time.Now().Add(p.cfg.ResetTokenTTL),
+		CreatedBy: domain.ActorFrom(ctx),
+	}
+	if err := p.resetRepo.Store(ctx, resetToken); err != nil {
+		return nil, err
+	}
+
+	xlogger.Ctx(ctx).Info().Uint("user_id", userId).Time("expires_at", resetToken.ExpiresAt).Msg("password reset created")
+	return &domain.PasswordReset{UserId: userId, Token: token, ExpiresAt: resetToken.ExpiresAt}, nil
+}
+
+// Reset
+func (p *passwordService) Reset(ctx context.Context, req *domain.PasswordResetRequest) error {
+	ctx, span := tracing.Start(ctx, "PasswordService.Reset")
+	defer span.End()
+
+	hash, err := p.Hash(req.NewPassword)
+	if err != nil {
+		return err
+	}
+
+	resetToken, err := p.resetRepo.Redeem(ctx, utilities.HashToken(req.Token), hash, time.Now())
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrInvalidResetToken
+		}
+		return err
+	}
+
+	xlogger.Ctx(ctx).Info().Uint("user_id", resetToken.UserId).Msg("password reset")
+	return nil
+}
+
+// validate lists every rule of the policy the password breaks
+func (p *passwordService) validate(password string) error {
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			symbol = true
+		}
+	}
+
+	var details []string
+	if utf8.RuneCountInString(password) < p.cfg.MinLength {
+		details = append(details, "password must be at least "+strconv.Itoa(p.cfg.MinLength)+" characters long")
+	}
+	if len(password) > maxBytes {
+		details = append(details, "password must be at most "+strconv.Itoa(maxBytes)+" bytes long")
+	}
+	if p.cfg.RequireUpper && !upper {
+		details = append(details, "password must contain an upper case letter")
+	}
+	if p.cfg.RequireLower && !lower {
+		details = append(details, "password must contain a lower case letter")
+	}
+	if p.cfg.RequireDigit && !digit {
+		details = append(details, "password must contain a digit")
+	}
+	if p.cfg.RequireSymbol && !symbol {
+		details = append(details, "password must contain a symbol")
+	}
+
+	if len(details) > 0 {
+		return domain.ErrWeakPassword.WithDetails(details...)
+	}
+	return nil
+}
+
+func NewPasswordService(userRepo domain.UserRepository, resetRepo domain.PasswordResetRepository, throttleRepo domain.LoginThrottleRepository, jwtService utilities.JwtTokenService, cfg config.Password, loginCfg config.Login) domain.PasswordService {
+	return &passwordService{
+		userRepo:     userRepo,
+		resetRepo:    resetRepo,
+		throttleRepo: throttleRepo,
+		jwtService:   jwtService,
+		cfg:          cfg,
+		loginCfg:     loginCfg,
+	}
+}
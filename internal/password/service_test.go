@@ -0,0 +1,149 @@
+package password
+
+import (
+	"book-store/internal/config"
+	"book-store/internal/domain"
+	"book-store/internal/utilities"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func TestValidate(t *testing.T) {
+	strict := config.Password{MinLength: 10, RequireUpper: true, RequireLower: true, RequireDigit: true, RequireSymbol: true}
+
+	tests := []struct {
+		name     string
+		cfg      config.Password
+		password string
+		want     []string
+	}{
+		{name: "valid", cfg: strict, password: "Sup3r-secret"},
+		{name: "too short", cfg: strict, password: "Sh0rt-pw", want: []string{
+			"password must be at least 10 characters long",
+		}},
+		{name: "no upper case letter", cfg: strict, password: "sup3r-secret", want: []string{
+			"password must contain an upper case letter",
+		}},
+		{name: "no lower case letter", cfg: strict, password: "SUP3R-SECRET", want: []string{
+			"password must contain a lower case letter",
+		}},
+		{name: "no digit", cfg: strict, password: "Super-secret", want: []string{
+			"password must contain a digit",
+		}},
+		{name: "no symbol", cfg: strict, password: "Sup3rsecret", want: []string{
+			"password must contain a symbol",
+		}},
+		{name: "every rule broken", cfg: strict, password: "", want: []string{
+			"password must be at least 10 characters long",
+			"password must contain an upper case letter",
+			"password must contain a lower case letter",
+			"password must contain a digit",
+			"password must contain a symbol",
+		}},
+		{name: "longer than bcrypt uses", cfg: strict, password: "Sup3r-" + strings.Repeat("s", 67), want: []string{
+			"password must be at most 72 bytes long",
+		}},
+		{name: "exactly as long as bcrypt uses", cfg: strict, password: "Sup3r-" + strings.Repeat("s", 66)},
+		{name: "length counts characters not bytes", cfg: config.Password{MinLength: 4}, password: "äöüß"},
+		{name: "rules off", cfg: config.Password{MinLength: 1}, password: "x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&passwordService{cfg: tt.cfg}).validate(tt.password)
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("validate(%q) = %v, want nil", tt.password, err)
+				}
+				return
+			}
+
+			var appErr *domain.AppError
+			if !errors.As(err, &appErr) || appErr.Code != domain.ErrWeakPassword.Code {
+				t.Fatalf("validate(%q) = %v, want %v", tt.password, err, domain.ErrWeakPassword)
+			}
+			if !reflect.DeepEqual(appErr.Details, tt.want) {
+				t.Errorf("validate(%q) details = %q, want %q", tt.password, appErr.Details, tt.want)
+			}
+		})
+	}
+}
+
+type throttles map[string]*domain.LoginThrottle
+
+func (t throttles) Get(_ context.Context, key string) (*domain.LoginThrottle, error) {
+	throttle, ok := t[key]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return throttle, nil
+}
+
+func (t throttles) Update(_ context.Context, key string, fn func(throttle *domain.LoginThrottle)) (*domain.LoginThrottle, error) {
+	throttle, ok := t[key]
+	if !ok {
+		throttle = &domain.LoginThrottle{Key: key}
+		t[key] = throttle
+	}
+	fn(throttle)
+	return throttle, nil
+}
+
+func (t throttles) Delete(_ context.Context, key string) error {
+	delete(t, key)
+	return nil
+}
+
+func (t throttles) Purge(context.Context, time.Time, time.Time) (int64, error) {
+	return 0, nil
+}
+
+func TestVerifyCurrent(t *testing.T) {
+	hash, err := utilities.HashPassword("Sup3r-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &domain.User{Model: gorm.Model{ID: 1}, Email: "Jane@Mail.com", Password: hash}
+	cfg := config.Login{MaxFailures: 3, FailureWindow: time.Minute, Lockout: time.Minute}
+
+	tests := []struct {
+		name         string
+		passwords    []string
+		wantErr      error
+		wantFailures int
+	}{
+		{name: "current password", passwords: []string{"Sup3r-secret"}},
+		{name: "wrong password counts as a failed login", passwords: []string{"guess"}, wantErr: domain.ErrInvalidCurrentPassword, wantFailures: 1},
+		{name: "current password after wrong ones", passwords: []string{"guess", "guess", "Sup3r-secret"}, wantFailures: 2},
+		{name: "locked out after as many wrong passwords as failed logins", passwords: []string{"guess", "guess", "guess", "Sup3r-secret"}, wantErr: domain.ErrLoginLocked, wantFailures: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			throttleRepo := throttles{}
+			svc := &passwordService{throttleRepo: throttleRepo, loginCfg: cfg}
+
+			var err error
+			for _, password := range tt.passwords {
+				err = svc.VerifyCurrent(context.Background(), user, password)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("VerifyCurrent() = %v, want %v", err, tt.wantErr)
+			}
+
+			var failures int
+			if throttle, ok := throttleRepo["account:jane@mail.com"]; ok {
+				failures = throttle.Failures
+			}
+			if failures != tt.wantFailures {
+				t.Errorf("failures = %d, want %d", failures, tt.wantFailures)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package payment
+
+import (
+	"book-store/internal/domain"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// fakePaymentGateway approves every charge locally, used until a real provider is wired in
+type fakePaymentGateway struct {
+	mu      sync.Mutex
+	charges map[string]int
+}
+
+// Charge
+func (f *fakePaymentGateway) Charge(ctx context.Context, payment *domain.Payment) (string, error) {
+	if payment.Amount <= 0 {
+		return "", errors.New("invalid charge amount")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	reference := fmt.Sprintf("FAKE-%s", uuid.NewString())
+	f.charges[reference] = payment.Amount
+
+	return reference, nil
+}
+
+// Refund
+func (f *fakePaymentGateway) Refund(ctx context.Context, payment *domain.Payment) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.charges[payment.Reference]; !ok {
+		return fmt.Errorf("unknown charge reference: %s", payment.Reference)
+	}
+	delete(f.charges, payment.Reference)
+
+	return nil
+}
+
+func NewFakePaymentGateway() domain.PaymentGateway {
+	return &fakePaymentGateway{
+		charges: make(map[string]int),
+	}
+}
@@ -0,0 +1,32 @@
+package payment
+
+import (
+	"book-store/internal/domain"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type mysqlPaymentRepository struct {
+	db *gorm.DB
+}
+
+// FetchByTransactionId
+func (m *mysqlPaymentRepository) FetchByTransactionId(ctx context.Context, transactionId uint) ([]*domain.Payment, error) {
+	var payments []*domain.Payment
+
+	if err := m.db.WithContext(ctx).Where("transaction_id = ?", transactionId).Order("created_at ASC").Find(&payments).Error; err != nil {
+		return nil, err
+	}
+
+	return payments, nil
+}
+
+// Store
+func (m *mysqlPaymentRepository) Store(ctx context.Context, payment *domain.Payment) error {
+	return m.db.WithContext(ctx).Create(payment).Error
+}
+
+func NewMysqlPaymentRepository(db *gorm.DB) domain.PaymentRepository {
+	return &mysqlPaymentRepository{db: db}
+}
@@ -0,0 +1,109 @@
+package payment
+
+import (
+	"book-store/internal/domain"
+	"book-store/internal/tracing"
+	"book-store/pkg/xlogger"
+	"context"
+)
+
+type paymentService struct {
+	paymentRepo domain.PaymentRepository
+	gateway     domain.PaymentGateway
+}
+
+// FetchByTransactionId
+func (p *paymentService) FetchByTransactionId(ctx context.Context, transactionId uint) ([]*domain.Payment, error) {
+	ctx, span := tracing.Start(ctx, "PaymentService.FetchByTransactionId")
+	defer span.End()
+
+	return p.paymentRepo.FetchByTransactionId(ctx, transactionId)
+}
+
+// Process applies the tenders to the outstanding amount. Non-cash tenders are
+// charged through the gateway and can't exceed what is owed, cash is applied
+// last so any surplus becomes change. The returned payments are not persisted.
+func (p *paymentService) Process(ctx context.Context, outstanding int, paymentReqs []*domain.PaymentStoreRequest) ([]*domain.Payment, error) {
+	ctx, span := tracing.Start(ctx, "PaymentService.Process")
+	defer span.End()
+
+	var nonCash int
+	for _, req := range paymentReqs {
+		if req.Method != domain.PaymentMethodCash {
+			nonCash += req.Amount
+		}
+	}
+
+	if nonCash > outstanding {
+		return nil, domain.ErrPaymentExceedsTotal
+	}
+
+	payments := make([]*domain.Payment, 0, len(paymentReqs))
+	remaining := outstanding - nonCash
+
+	for _, req := range paymentReqs {
+		payment := &domain.Payment{
+			Method:   req.Method,
+			Amount:   req.Amount,
+			Tendered: req.Amount,
+			Status:   domain.PaymentStatusPaid,
+		}
+
+		switch req.Method {
+		case domain.PaymentMethodCash:
+			// cash covers what is left, anything above it is handed back
+			payment.Amount = min(req.Amount, remaining)
+			payment.Change = req.Amount - payment.Amount
+			remaining -= payment.Amount
+		case domain.PaymentMethodCard, domain.PaymentMethodTransfer:
+			reference, err := p.gateway.Charge(ctx, payment)
+			if err != nil {
+				xlogger.Ctx(ctx).Warn().Err(err).Str("method", string(payment.Method)).Int("amount", payment.Amount).Msg("payment charge failed")
+				// roll back the tenders charged so far
+				if refundErr := p.Refund(ctx, payments); refundErr != nil {
+					return nil, refundErr
+				}
+				return nil, domain.ErrPaymentDeclined.Wrap(err)
+			}
+			payment.Reference = reference
+		case domain.PaymentMethodLoyalty, domain.PaymentMethodStoreCredit:
+			// the points and the credit are taken off the balances of the customer with the sale
+		default:
+			if refundErr := p.Refund(ctx, payments); refundErr != nil {
+				return nil, refundErr
+			}
+			return nil, domain.ErrUnsupportedPaymentMethod
+		}
+
+		payments = append(payments, payment)
+	}
+
+	return payments, nil
+}
+
+// Refund
+func (p *paymentService) Refund(ctx context.Context, payments []*domain.Payment) error {
+	ctx, span := tracing.Start(ctx, "PaymentService.Refund")
+	defer span.End()
+
+	for _, payment := range payments {
+		if payment.Reference == "" || payment.Status == domain.PaymentStatusRefunded {
+			continue
+		}
+
+		if err := p.gateway.Refund(ctx, payment); err != nil {
+			xlogger.Ctx(ctx).Error().Err(err).Str("reference", payment.Reference).Msg("payment refund failed")
+			return err
+		}
+		payment.Status = domain.PaymentStatusRefunded
+	}
+
+	return nil
+}
+
+func NewPaymentService(paymentRepo domain.PaymentRepository, gateway domain.PaymentGateway) domain.PaymentService {
+	return &paymentService{
+		paymentRepo: paymentRepo,
+		gateway:     gateway,
+	}
+}
@@ -0,0 +1,122 @@
+package purge
+
+import (
+	"book-store/internal/config"
+	"book-store/internal/domain"
+	"book-store/internal/tracing"
+	"book-store/pkg/xlogger"
+	"context"
+	"time"
+)
+
+const purgeJobType = "purge_deleted"
+
+type purgeService struct {
+	transactionRepo domain.TransactionRepository
+	bookRepo        domain.BookRepository
+	customerRepo    domain.CustomerRepository
+	userRepo        domain.UserRepository
+	throttleRepo    domain.LoginThrottleRepository
+	jobSvc          domain.JobService
+	cfg             config.Purge
+	loginCfg        config.Login
+}
+
+// Purge runs over transactions first so the books, customers and users only they referenced go in the same run
+func (s *purgeService) Purge(ctx context.Context) (*domain.PurgeResult, error) {
+	ctx, span := tracing.Start(ctx, "PurgeService.Purge")
+	defer span.End()
+
+	result := &domain.PurgeResult{DeletedBefore: time.Now().Add(-s.cfg.Retention)}
+
+	steps := []struct {
+		purge func(ctx context.Context, deletedBefore time.Time) (int64, error)
+		count *int64
+	}{
+		{s.transactionRepo.Purge, &result.Transactions},
+		{s.bookRepo.Purge, &result.Books},
+		{s.customerRepo.Purge, &result.Customers},
+		{s.userRepo.Purge, &result.Users},
+	}
+	for _, step := range steps {
+		count, err := step.purge(ctx, result.DeletedBefore)
+		if err != nil {
+			return nil, err
+		}
+		*step.count = count
+	}
+
+	// throttles only matter within the failure window, expired ones are never deleted by a successful login
+	now := time.Now()
+	throttles, err := s.throttleRepo.Purge(ctx, now.Add(-s.loginCfg.FailureWindow), now)
+	if err != nil {
+		return nil, err
+	}
+	result.LoginThrottles = throttles
+
+	xlogger.Ctx(ctx).Info().
+		Time("deleted_before", result.DeletedBefore).
+		Int64("transactions", result.Transactions).
+		Int64("books", result.Books).
+		Int64("customers", result.Customers).
+		Int64("users", result.Users).
+		Int64("login_throttles", result.LoginThrottles).
+		Msg("purged deleted records")
+	return result, nil
+}
+
+// Schedule
+func (s *purgeService) Schedule(ctx context.Context) error {
+	if s.cfg.Retention <= 0 {
+		return nil
+	}
+
+	return s.schedule(ctx, time.Now())
+}
+
+// schedule enqueues a purge at runAt unless one is pending, so restarts and retries don't pile them up
+func (s *purgeService) schedule(ctx context.Context, runAt time.Time) error {
+	pending, err := s.jobSvc.Count(ctx, &domain.Job{Type: purgeJobType, Status: domain.JobStatusPending})
+	if err != nil || pending > 0 {
+		return err
+	}
+
+	_, err = s.jobSvc.Enqueue(ctx, purgeJobType, nil, &domain.JobOptions{RunAt: runAt})
+	return err
+}
+
+// runPurgeJob queues the next purge before this one so a failing purge doesn't stop the schedule
+func (s *purgeService) runPurgeJob(ctx context.Context, _ *domain.Job) (any, error) {
+	if err := s.schedule(ctx, time.Now().Add(s.cfg.Interval)); err != nil {
+		return nil, err
+	}
+
+	return s.Purge(ctx)
+}
+
+func NewPurgeService(
+	transactionRepo domain.TransactionRepository,
+	bookRepo domain.BookRepository,
+	customerRepo domain.CustomerRepository,
+	userRepo domain.UserRepository,
+	throttleRepo domain.LoginThrottleRepository,
+	jobSvc domain.JobService,
+	jobRunner domain.JobRunner,
+	cfg config.Purge,
+	loginCfg config.Login,
+) domain.PurgeService {
+	s := &purgeService{
+		transactionRepo: transactionRepo,
+		bookRepo:        bookRepo,
+		customerRepo:    customerRepo,
+		userRepo:        userRepo,
+		throttleRepo:    throttleRepo,
+		jobSvc:          jobSvc,
+		cfg:             cfg,
+		loginCfg:        loginCfg,
+	}
+
+	jobRunner.Register(purgeJobType, 1, s.runPurgeJob)
+
+	return s
+}
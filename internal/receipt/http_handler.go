@@ -0,0 +1,76 @@
+package receipt
+
+import (
+	"book-store/internal/domain"
+	"book-store/internal/middleware/jwt"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type HttpReceiptHandler struct {
+	receiptSvc     domain.ReceiptService
+	authMiddleware jwt.AuthMiddleware
+}
+
+func NewHttpHandler(r fiber.Router, receiptSvc domain.ReceiptService, authMiddleware jwt.AuthMiddleware) {
+	handler := &HttpReceiptHandler{
+		receiptSvc:     receiptSvc,
+		authMiddleware: authMiddleware,
+	}
+
+	r.Get("/:id/receipt", authMiddleware.RequireRole("admin", "employee"), handler.GetReceipt)
+}
+
+// GetReceipt used to print the receipt of a transaction
+//
+//	@Summary		Get transaction receipt
+//	@Description	Render the receipt of a paid transaction, the invoice number is assigned on first print
+//	@Tags			transactions
+//	@Produce		html
+//	@Produce		plain
+//	@Produce		application/pdf
+//	@Param			id		path		int				true	"transaction ID"
+//	@Param			format	query		string			false	"Receipt format (html, text, pdf), default html"
+//	@Success		200		{string}	string			"receipt"
+//	@Failure		400		{object}	domain.Error	"Bad Request"
+//	@Failure		404		{object}	domain.Error	"Not Found"
+//	@Failure		409		{object}	domain.Error	"Conflict"
+//	@Failure		500		{object}	domain.Error	"Internal Server Error"
+//	@Router			/transactions/{id}/receipt [get]
+//
+// @Security Bearer
+func (h *HttpReceiptHandler) GetReceipt(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return domain.NewValidationError("invalid transaction id")
+	}
+
+	// checked ahead of Generate, an invoice number assigned for a receipt that can't be rendered leaves a gap
+	format := domain.ReceiptFormat(c.Query("format", string(domain.ReceiptFormatHTML)))
+	if !format.Valid() {
+		return domain.ErrInvalidReceiptFormat
+	}
+
+	receipt, err := h.receiptSvc.Generate(c.UserContext(), uint(id))
+	if err != nil {
+		return err
+	}
+
+	body, err := h.receiptSvc.Render(c.UserContext(), receipt, format)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case domain.ReceiptFormatPDF:
+		c.Set(fiber.HeaderContentType, "application/pdf")
+		c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("inline; filename=%q", receipt.InvoiceNumber+".pdf"))
+	case domain.ReceiptFormatText:
+		c.Set(fiber.HeaderContentType, fiber.MIMETextPlainCharsetUTF8)
+	default:
+		c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	}
+
+	return c.Status(fiber.StatusOK).Send(body)
+}
@@ -0,0 +1,61 @@
+package receipt
+
+import (
+	"book-store/internal/domain"
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type mysqlReceiptRepository struct {
+	db *gorm.DB
+}
+
+// AssignInvoiceNumber takes the next number of the store sequence and stores it on the
+// transaction, an already numbered transaction keeps its invoice number
+func (m *mysqlReceiptRepository) AssignInvoiceNumber(ctx context.Context, transactionId uint, storeCode string) (string, error) {
+	var invoiceNumber string
+
+	err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var transaction domain.Transaction
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&transaction, transactionId).Error; err != nil {
+			return err
+		}
+
+		if transaction.InvoiceNumber != nil {
+			invoiceNumber = *transaction.InvoiceNumber
+			return nil
+		}
+
+		// the first number of a store creates its sequence, one statement keeps concurrent first prints from
+		// both inserting it. The row stays locked until the transaction is numbered
+		sequence := domain.InvoiceSequence{StoreCode: storeCode, LastNumber: 1}
+		if err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "store_code"}},
+			DoUpdates: clause.Assignments(map[string]any{
+				"last_number": gorm.Expr("last_number + 1"),
+				"updated_at":  time.Now(),
+			}),
+		}).Create(&sequence).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("store_code = ?", storeCode).First(&sequence).Error; err != nil {
+			return err
+		}
+
+		invoiceNumber = fmt.Sprintf("%s-%06d", storeCode, sequence.LastNumber)
+		return tx.Model(&transaction).Update("invoice_number", invoiceNumber).Error
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return invoiceNumber, nil
+}
+
+func NewMysqlReceiptRepository(db *gorm.DB) domain.ReceiptRepository {
+	return &mysqlReceiptRepository{db: db}
+}
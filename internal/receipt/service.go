@@ -0,0 +1,160 @@
+package receipt
+
+import (
+	"book-store/internal/config"
+	"book-store/internal/domain"
+	"book-store/internal/tracing"
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	htmlTemplate "html/template"
+	"strings"
+	textTemplate "text/template"
+
+	"github.com/go-pdf/fpdf"
+	"gorm.io/gorm"
+)
+
+//go:embed templates
+var templates embed.FS
+
+type receiptService struct {
+	transactionRepo domain.TransactionRepository
+	receiptRepo     domain.ReceiptRepository
+	store           config.Store
+	html            *htmlTemplate.Template
+	text            *textTemplate.Template
+}
+
+// Generate
+func (r *receiptService) Generate(ctx context.Context, transactionId uint) (*domain.Receipt, error) {
+	ctx, span := tracing.Start(ctx, "ReceiptService.Generate")
+	defer span.End()
+
+	transaction, err := r.transactionRepo.GetById(ctx, transactionId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrTransactionNotFound
+		}
+		return nil, err
+	}
+
+	if transaction.Status != domain.TransactionStatusPaid {
+		return nil, domain.ErrTransactionNotPaid
+	}
+
+	invoiceNumber, err := r.receiptRepo.AssignInvoiceNumber(ctx, transaction.ID, r.store.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	receipt := &domain.Receipt{
+		InvoiceNumber: invoiceNumber,
+		StoreName:     r.store.Name,
+		StoreAddress:  r.store.Address,
+		IssuedAt:      transaction.CreatedAt,
+		Discount:      transaction.Discount,
+		TotalPrice:    transaction.TotalPrice,
+		PaidAmount:    transaction.PaidAmount,
+		ChangeAmount:  transaction.ChangeAmount,
+		Payments:      transaction.Payments,
+	}
+
+	if transaction.User != nil {
+		receipt.Cashier = transaction.User.Name
+	}
+	if transaction.Customer != nil {
+		receipt.Customer = transaction.Customer.Name
+	}
+
+	for _, detail := range transaction.TransactionDetails {
+		line := &domain.ReceiptLine{
+			Quantity: detail.Quantity,
+			SubTotal: detail.SubTotal,
+		}
+		if detail.Quantity > 0 {
+			line.UnitPrice = detail.SubTotal / detail.Quantity
+		}
+		if detail.Book != nil {
+			line.Title = detail.Book.Title
+			line.Isbn = detail.Book.Isbn
+		}
+
+		receipt.Lines = append(receipt.Lines, line)
+	}
+
+	return receipt, nil
+}
+
+// Render
+func (r *receiptService) Render(ctx context.Context, receipt *domain.Receipt, format domain.ReceiptFormat) ([]byte, error) {
+	ctx, span := tracing.Start(ctx, "ReceiptService.Render")
+	defer span.End()
+
+	var buf bytes.Buffer
+
+	switch format {
+	case domain.ReceiptFormatHTML:
+		if err := r.html.Execute(&buf, receipt); err != nil {
+			return nil, err
+		}
+	case domain.ReceiptFormatText:
+		if err := r.text.Execute(&buf, receipt); err != nil {
+			return nil, err
+		}
+	case domain.ReceiptFormatPDF:
+		if err := r.text.Execute(&buf, receipt); err != nil {
+			return nil, err
+		}
+		return renderPDF(buf.String())
+	default:
+		return nil, domain.ErrInvalidReceiptFormat
+	}
+
+	return buf.Bytes(), nil
+}
+
+// renderPDF lays the plain text receipt out on a narrow page in a monospaced font,
+// so the PDF matches what the thermal printer produces
+func renderPDF(text string) ([]byte, error) {
+	const (
+		pageWidth  = 80.0
+		margin     = 4.0
+		lineHeight = 3.6
+	)
+
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	pageHeight := float64(len(lines))*lineHeight + 2*margin
+
+	pdf := fpdf.NewCustom(&fpdf.InitType{
+		UnitStr: "mm",
+		Size:    fpdf.SizeType{Wd: pageWidth, Ht: pageHeight},
+	})
+	pdf.SetMargins(margin, margin, margin)
+	pdf.SetAutoPageBreak(false, margin)
+	pdf.AddPage()
+	pdf.SetFont("Courier", "", 8)
+
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	for _, line := range lines {
+		pdf.CellFormat(0, lineHeight, tr(line), "", 1, "L", false, 0, "")
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func NewReceiptService(transactionRepo domain.TransactionRepository, receiptRepo domain.ReceiptRepository, store config.Store) domain.ReceiptService {
+	return &receiptService{
+		transactionRepo: transactionRepo,
+		receiptRepo:     receiptRepo,
+		store:           store,
+		html:            htmlTemplate.Must(htmlTemplate.New("receipt.html.tmpl").Funcs(htmlTemplate.FuncMap(templateFuncs)).ParseFS(templates, "templates/receipt.html.tmpl")),
+		text:            textTemplate.Must(textTemplate.New("receipt.txt.tmpl").Funcs(templateFuncs).ParseFS(templates, "templates/receipt.txt.tmpl")),
+	}
+}
@@ -0,0 +1,66 @@
+package receipt
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// receiptWidth is the number of characters a thermal printer fits on one line
+const receiptWidth = 40
+
+var templateFuncs = map[string]any{
+	"money":  money,
+	"neg":    func(amount int) int { return -amount },
+	"left":   left,
+	"right":  right,
+	"center": center,
+	"rule":   func() string { return strings.Repeat("-", receiptWidth) },
+	"upper":  func(v any) string { return strings.ToUpper(fmt.Sprint(v)) },
+}
+
+// money formats an amount with thousand separators, e.g. 150000 becomes 150.000
+func money(amount int) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	digits := fmt.Sprint(amount)
+	var b strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(d)
+	}
+
+	return sign + b.String()
+}
+
+// left pads s with spaces on the right, cutting it when longer than width
+func left(width int, s string) string {
+	s = truncate(width, s)
+	return s + strings.Repeat(" ", width-utf8.RuneCountInString(s))
+}
+
+// right pads s with spaces on the left, cutting it when longer than width
+func right(width int, s string) string {
+	s = truncate(width, s)
+	return strings.Repeat(" ", width-utf8.RuneCountInString(s)) + s
+}
+
+// center places s in the middle of a receipt line
+func center(s string) string {
+	s = truncate(receiptWidth, s)
+	return strings.Repeat(" ", (receiptWidth-utf8.RuneCountInString(s))/2) + s
+}
+
+func truncate(width int, s string) string {
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+
+	return string([]rune(s)[:width])
+}
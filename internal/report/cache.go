@@ -0,0 +1,109 @@
+package report
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+type cacheEntry struct {
+	key       string
+	value     any
+	expiresAt time.Time
+}
+
+// cache keeps computed reports in memory until they expire or are cleared, past maxEntries
+// the least recently used report is evicted
+type cache struct {
+	mu         sync.Mutex
+	maxEntries int
+	// order holds the entries from the most to the least recently used
+	order   *list.List
+	entries map[string]*list.Element
+	// generation counts the clears, a report computed before one is not stored
+	generation uint64
+}
+
+func (c *cache) get(key string) (any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := element.Value.(*cacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.remove(element)
+		return nil, false
+	}
+
+	c.order.MoveToFront(element)
+	return entry.value, true
+}
+
+// set stores the value computed in the generation, unless the cache was cleared since
+func (c *cache) set(key string, value any, ttl time.Duration, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, value: value, expiresAt: time.Now().Add(ttl)})
+
+	for c.order.Len() > c.maxEntries {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *cache) currentGeneration() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.generation
+}
+
+func (c *cache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	c.order.Init()
+	clear(c.entries)
+}
+
+func (c *cache) remove(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.entries, element.Value.(*cacheEntry).key)
+}
+
+func newCache(maxEntries int) *cache {
+	return &cache{maxEntries: maxEntries, order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+// cached returns the value stored under key or computes it, only reports of closed
+// ranges are stored since the open ones still change with every sale
+func cached[T any](c *cache, key string, ttl time.Duration, closed bool, compute func() (T, error)) (T, error) {
+	if value, ok := c.get(key); ok {
+		return value.(T), nil
+	}
+
+	generation := c.currentGeneration()
+	value, err := compute()
+	if err != nil {
+		return value, err
+	}
+
+	if closed && ttl > 0 && c.maxEntries > 0 {
+		c.set(key, value, ttl, generation)
+	}
+
+	return value, nil
+}
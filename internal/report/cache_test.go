@@ -0,0 +1,93 @@
+package report
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxEntries int
+		set        []string
+		get        []string
+		setAfter   []string
+		want       map[string]bool
+	}{
+		{
+			name:       "keeps entries up to the max",
+			maxEntries: 2,
+			set:        []string{"a", "b"},
+			want:       map[string]bool{"a": true, "b": true},
+		},
+		{
+			name:       "evicts the least recently set",
+			maxEntries: 2,
+			set:        []string{"a", "b", "c"},
+			want:       map[string]bool{"a": false, "b": true, "c": true},
+		},
+		{
+			name:       "a read entry is recently used",
+			maxEntries: 2,
+			set:        []string{"a", "b"},
+			get:        []string{"a"},
+			setAfter:   []string{"c"},
+			want:       map[string]bool{"a": true, "b": false, "c": true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newCache(tt.maxEntries)
+			for _, key := range tt.set {
+				c.set(key, key, time.Hour, 0)
+			}
+			for _, key := range tt.get {
+				c.get(key)
+			}
+			for _, key := range tt.setAfter {
+				c.set(key, key, time.Hour, 0)
+			}
+
+			for key, want := range tt.want {
+				if _, ok := c.get(key); ok != want {
+					t.Errorf("get(%q) found = %v, want %v", key, ok, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCacheExpiry(t *testing.T) {
+	c := newCache(10)
+	c.set("a", 1, -time.Second, 0)
+
+	if _, ok := c.get("a"); ok {
+		t.Error("expired entry was returned")
+	}
+	if c.order.Len() != 0 {
+		t.Errorf("expired entry was kept, %d entries left", c.order.Len())
+	}
+}
+
+func TestCacheClear(t *testing.T) {
+	c := newCache(10)
+	stale := c.currentGeneration()
+	c.set("a", 1, time.Hour, stale)
+
+	c.clear()
+	if _, ok := c.get("a"); ok {
+		t.Error("cleared entry was returned")
+	}
+
+	// a report computed before the clear would bring back what it dropped
+	c.set("b", 2, time.Hour, stale)
+	if _, ok := c.get("b"); ok {
+		t.Error("entry computed before the clear was stored")
+	}
+
+	c.set("c", 3, time.Hour, c.currentGeneration())
+	if _, ok := c.get("c"); !ok {
+		t.Error("entry computed after the clear was not stored")
+	}
+}
@@ -0,0 +1,267 @@
+package report
+
+import (
+	"book-store/internal/config"
+	"book-store/internal/domain"
+	"book-store/internal/middleware/jwt"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxRangeYears bounds the range of the sales report by the size of its buckets, so a request can't ask for
+// thousands of them. Top reports have no buckets and are bounded by the longest range
+var maxRangeYears = map[domain.ReportPeriod]int{
+	domain.ReportPeriodDay:   1,
+	domain.ReportPeriodWeek:  5,
+	domain.ReportPeriodMonth: 10,
+}
+
+// maxLimit bounds the rows of the top reports
+const maxLimit = 100
+
+type HttpReportHandler struct {
+	reportSvc       domain.ReportService
+	authMiddleware  jwt.AuthMiddleware
+	defaultLocation *time.Location
+	cacheTTL        time.Duration
+}
+
+func NewHttpHandler(r fiber.Router, reportSvc domain.ReportService, authMiddleware jwt.AuthMiddleware, cfg config.Report) {
+	defaultLocation, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		panic(err)
+	}
+
+	handler := &HttpReportHandler{
+		reportSvc:       reportSvc,
+		authMiddleware:  authMiddleware,
+		defaultLocation: defaultLocation,
+		cacheTTL:        cfg.CacheTTL,
+	}
+
+	r.Use(authMiddleware.RequireRole("admin"))
+	r.Get("/sales", handler.Sales)
+	r.Get("/top-books", handler.TopBooks)
+	r.Get("/top-authors", handler.TopAuthors)
+	r.Get("/top-customers", handler.TopCustomers)
+}
+
+// Sales used to get revenue, units sold and average basket by period
+//
+//	@Summary		Get sales report
+//	@Description	Get revenue, units sold, orders and average basket of transactions bucketed by the day, week or month they were paid. Revenue is after the loyalty discount
+//	@Tags			reports
+//	@Accept			json
+//	@Produce		json
+//	@Param			from	query		string			false	"Start date (dd-mm-yyyy), default 30 days ago"
+//	@Param			to		query		string			false	"End date inclusive (dd-mm-yyyy), default today"
+//	@Param			period	query		string			false	"Bucket size (day, week, month), default day. Ranges up to 1, 5 and 10 years"
+//	@Param			tz		query		string			false	"IANA timezone of the buckets, e.g. Asia/Jakarta"
+//	@Success		200		{object}	domain.Success	"sales report"
+//	@Failure		400		{object}	domain.Error	"Bad Request"
+//	@Failure		500		{object}	domain.Error	"Internal Server Error"
+//	@Router			/reports/sales [get]
+//
+// @Security Bearer
+func (h *HttpReportHandler) Sales(c *fiber.Ctx) error {
+	filter, err := h.parseFilter(c, true)
+	if err != nil {
+		return err
+	}
+
+	report, err := h.reportSvc.Sales(c.UserContext(), filter)
+	if err != nil {
+		return err
+	}
+
+	h.setCacheControl(c, filter)
+	return c.Status(fiber.StatusOK).JSON(domain.Success{
+		Code:    fiber.StatusOK,
+		Message: "success",
+		Data:    report,
+	})
+}
+
+// TopBooks used to get best selling books
+//
+//	@Summary		Get top books
+//	@Description	Get best selling books by units sold. Revenue is after the loyalty discount, taken off the books in proportion to their sub total
+//	@Tags			reports
+//	@Accept			json
+//	@Produce		json
+//	@Param			from	query		string			false	"Start date (dd-mm-yyyy), default 30 days ago"
+//	@Param			to		query		string			false	"End date inclusive (dd-mm-yyyy), default today"
+//	@Param			tz		query		string			false	"IANA timezone of the dates, e.g. Asia/Jakarta"
+//	@Param			limit	query		int				false	"Number of books (default 10, max 100)"
+//	@Success		200		{object}	domain.Success	"top books"
+//	@Failure		400		{object}	domain.Error	"Bad Request"
+//	@Failure		500		{object}	domain.Error	"Internal Server Error"
+//	@Router			/reports/top-books [get]
+//
+// @Security Bearer
+func (h *HttpReportHandler) TopBooks(c *fiber.Ctx) error {
+	filter, err := h.parseFilter(c, false)
+	if err != nil {
+		return err
+	}
+
+	books, err := h.reportSvc.TopBooks(c.UserContext(), filter)
+	if err != nil {
+		return err
+	}
+
+	h.setCacheControl(c, filter)
+	return c.Status(fiber.StatusOK).JSON(domain.Success{
+		Code:    fiber.StatusOK,
+		Message: "success",
+		Data:    books,
+	})
+}
+
+// TopAuthors used to get best selling authors
+//
+//	@Summary		Get top authors
+//	@Description	Get best selling authors by units sold. Revenue is after the loyalty discount, taken off the books in proportion to their sub total
+//	@Tags			reports
+//	@Accept			json
+//	@Produce		json
+//	@Param			from	query		string			false	"Start date (dd-mm-yyyy), default 30 days ago"
+//	@Param			to		query		string			false	"End date inclusive (dd-mm-yyyy), default today"
+//	@Param			tz		query		string			false	"IANA timezone of the dates, e.g. Asia/Jakarta"
+//	@Param			limit	query		int				false	"Number of authors (default 10, max 100)"
+//	@Success		200		{object}	domain.Success	"top authors"
+//	@Failure		400		{object}	domain.Error	"Bad Request"
+//	@Failure		500		{object}	domain.Error	"Internal Server Error"
+//	@Router			/reports/top-authors [get]
+//
+// @Security Bearer
+func (h *HttpReportHandler) TopAuthors(c *fiber.Ctx) error {
+	filter, err := h.parseFilter(c, false)
+	if err != nil {
+		return err
+	}
+
+	authors, err := h.reportSvc.TopAuthors(c.UserContext(), filter)
+	if err != nil {
+		return err
+	}
+
+	h.setCacheControl(c, filter)
+	return c.Status(fiber.StatusOK).JSON(domain.Success{
+		Code:    fiber.StatusOK,
+		Message: "success",
+		Data:    authors,
+	})
+}
+
+// TopCustomers used to get customers who spent the most
+//
+//	@Summary		Get top customers
+//	@Description	Get customers with the highest revenue, after the loyalty discount
+//	@Tags			reports
+//	@Accept			json
+//	@Produce		json
+//	@Param			from	query		string			false	"Start date (dd-mm-yyyy), default 30 days ago"
+//	@Param			to		query		string			false	"End date inclusive (dd-mm-yyyy), default today"
+//	@Param			tz		query		string			false	"IANA timezone of the dates, e.g. Asia/Jakarta"
+//	@Param			limit	query		int				false	"Number of customers (default 10, max 100)"
+//	@Success		200		{object}	domain.Success	"top customers"
+//	@Failure		400		{object}	domain.Error	"Bad Request"
+//	@Failure		500		{object}	domain.Error	"Internal Server Error"
+//	@Router			/reports/top-customers [get]
+//
+// @Security Bearer
+func (h *HttpReportHandler) TopCustomers(c *fiber.Ctx) error {
+	filter, err := h.parseFilter(c, false)
+	if err != nil {
+		return err
+	}
+
+	customers, err := h.reportSvc.TopCustomers(c.UserContext(), filter)
+	if err != nil {
+		return err
+	}
+
+	h.setCacheControl(c, filter)
+	return c.Status(fiber.StatusOK).JSON(domain.Success{
+		Code:    fiber.StatusOK,
+		Message: "success",
+		Data:    customers,
+	})
+}
+
+// parseFilter reads the date range as whole local days, to is inclusive. The range of bucketed reports is bounded
+// by their period
+func (h *HttpReportHandler) parseFilter(c *fiber.Ctx, bucketed bool) (*domain.ReportFilter, error) {
+	loc := h.defaultLocation
+	if tz := c.Query("tz"); tz != "" {
+		var err error
+		if loc, err = time.LoadLocation(tz); err != nil {
+			return nil, domain.NewValidationError("invalid timezone: " + tz)
+		}
+	}
+
+	now := time.Now().In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	from, to := today.AddDate(0, 0, -29), today
+
+	if value := c.Query("from"); value != "" {
+		date, err := time.ParseInLocation("02-01-2006", value, loc)
+		if err != nil {
+			return nil, domain.NewValidationError("invalid from format, should be dd-mm-yyyy")
+		}
+		from = date
+	}
+
+	if value := c.Query("to"); value != "" {
+		date, err := time.ParseInLocation("02-01-2006", value, loc)
+		if err != nil {
+			return nil, domain.NewValidationError("invalid to format, should be dd-mm-yyyy")
+		}
+		to = date
+	}
+
+	if to.Before(from) {
+		return nil, domain.NewValidationError("to must not be before from")
+	}
+
+	period := domain.ReportPeriod(c.Query("period", string(domain.ReportPeriodDay)))
+	maxYears, ok := maxRangeYears[period]
+	if !ok {
+		return nil, domain.NewValidationError("period must be day, week or month")
+	}
+	if !bucketed {
+		maxYears = maxRangeYears[domain.ReportPeriodMonth]
+	}
+	if to.After(from.AddDate(maxYears, 0, 0)) {
+		if bucketed {
+			return nil, domain.NewValidationError(fmt.Sprintf("range by %s must not be longer than %d years", period, maxYears))
+		}
+		return nil, domain.NewValidationError(fmt.Sprintf("range must not be longer than %d years", maxYears))
+	}
+
+	limit := c.QueryInt("limit", 10)
+	if limit <= 0 || limit > maxLimit {
+		return nil, domain.NewValidationError(fmt.Sprintf("limit must be between 1 and %d", maxLimit))
+	}
+
+	return &domain.ReportFilter{
+		From:     from,
+		To:       to.AddDate(0, 0, 1),
+		Period:   period,
+		Location: loc,
+		Limit:    limit,
+	}, nil
+}
+
+// setCacheControl lets clients keep reports of ranges that are over
+func (h *HttpReportHandler) setCacheControl(c *fiber.Ctx, filter *domain.ReportFilter) {
+	if filter.Closed(time.Now()) && h.cacheTTL > 0 {
+		c.Set(fiber.HeaderCacheControl, fmt.Sprintf("private, max-age=%d", int(h.cacheTTL.Seconds())))
+		return
+	}
+
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+}
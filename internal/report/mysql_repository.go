@@ -0,0 +1,116 @@
+package report
+
+import (
+	"book-store/internal/domain"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type mysqlReportRepository struct {
+	db *gorm.DB
+}
+
+// EachSale streams the transactions paid in the range, one row per transaction with its units sold
+func (m *mysqlReportRepository) EachSale(ctx context.Context, from time.Time, to time.Time, fn func(row *domain.SaleRow) error) error {
+	rows, err := m.db.WithContext(ctx).Model(&domain.Transaction{}).
+		Select("transactions.paid_at, transactions.total_price, COALESCE(SUM(transaction_details.quantity), 0)").
+		Joins("LEFT JOIN transaction_details ON transaction_details.transaction_id = transactions.id AND transaction_details.deleted_at IS NULL").
+		Scopes(paidIn(from, to)).
+		Group("transactions.id, transactions.paid_at, transactions.total_price").
+		Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var row domain.SaleRow
+	for rows.Next() {
+		if err := rows.Scan(&row.PaidAt, &row.TotalPrice, &row.Units); err != nil {
+			return err
+		}
+		if err := fn(&row); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// TopBooks
+func (m *mysqlReportRepository) TopBooks(ctx context.Context, from time.Time, to time.Time, limit int) ([]*domain.TopBook, error) {
+	var books []*domain.TopBook
+
+	if err := m.soldDetails(ctx, from, to).
+		Select("books.id AS book_id, books.title, books.author, SUM(transaction_details.quantity) AS units, " + lineRevenue + " AS revenue").
+		Joins("JOIN books ON books.id = transaction_details.book_id").
+		Group("books.id, books.title, books.author").
+		Order("units DESC").
+		Limit(limit).
+		Scan(&books).Error; err != nil {
+		return nil, err
+	}
+
+	return books, nil
+}
+
+// TopAuthors
+func (m *mysqlReportRepository) TopAuthors(ctx context.Context, from time.Time, to time.Time, limit int) ([]*domain.TopAuthor, error) {
+	var authors []*domain.TopAuthor
+
+	if err := m.soldDetails(ctx, from, to).
+		Select("books.author, SUM(transaction_details.quantity) AS units, " + lineRevenue + " AS revenue").
+		Joins("JOIN books ON books.id = transaction_details.book_id").
+		Group("books.author").
+		Order("units DESC").
+		Limit(limit).
+		Scan(&authors).Error; err != nil {
+		return nil, err
+	}
+
+	return authors, nil
+}
+
+// TopCustomers
+func (m *mysqlReportRepository) TopCustomers(ctx context.Context, from time.Time, to time.Time, limit int) ([]*domain.TopCustomer, error) {
+	var customers []*domain.TopCustomer
+
+	if err := m.db.WithContext(ctx).Model(&domain.Transaction{}).
+		Select("customers.id AS customer_id, customers.name, COUNT(transactions.id) AS orders, SUM(transactions.total_price) AS revenue").
+		Joins("JOIN customers ON customers.id = transactions.customer_id").
+		Scopes(paidIn(from, to)).
+		Group("customers.id, customers.name").
+		Order("revenue DESC").
+		Limit(limit).
+		Scan(&customers).Error; err != nil {
+		return nil, err
+	}
+
+	return customers, nil
+}
+
+// lineRevenue sums what the line items brought in after the discount of their transaction, which is taken off
+// them in proportion to their sub total, so books and authors add up to the revenue of the sales report
+const lineRevenue = "CAST(ROUND(COALESCE(SUM(transaction_details.sub_total * transactions.total_price / " +
+	"NULLIF(transactions.total_price + transactions.discount, 0)), 0)) AS SIGNED)"
+
+// soldDetails scopes the line items of the transactions paid in the range, deleted books are still counted
+func (m *mysqlReportRepository) soldDetails(ctx context.Context, from time.Time, to time.Time) *gorm.DB {
+	return m.db.WithContext(ctx).Model(&domain.TransactionDetail{}).
+		Joins("JOIN transactions ON transactions.id = transaction_details.transaction_id AND transactions.deleted_at IS NULL").
+		Scopes(paidIn(from, to))
+}
+
+// paidIn scopes the transactions paid in [from, to), a sale counts when it was paid rather than created so a
+// pending transaction paid later doesn't change a range that is over
+func paidIn(from time.Time, to time.Time) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("transactions.status = ?", domain.TransactionStatusPaid).
+			Where("transactions.paid_at >= ? AND transactions.paid_at < ?", from, to)
+	}
+}
+
+func NewMysqlReportRepository(db *gorm.DB) domain.ReportRepository {
+	return &mysqlReportRepository{db: db}
+}
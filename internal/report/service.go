@@ -0,0 +1,148 @@
+package report
+
+import (
+	"book-store/internal/config"
+	"book-store/internal/domain"
+	"book-store/internal/tracing"
+	"context"
+	"fmt"
+	"time"
+)
+
+type reportService struct {
+	reportRepo domain.ReportRepository
+	cfg        config.Report
+	cache      *cache
+}
+
+// Sales buckets revenue, units and orders of the range by day, week or month in the filter timezone
+func (r *reportService) Sales(ctx context.Context, filter *domain.ReportFilter) (*domain.SalesReport, error) {
+	ctx, span := tracing.Start(ctx, "ReportService.Sales")
+	defer span.End()
+
+	return cached(r.cache, r.key("sales", filter), r.cfg.CacheTTL, filter.Closed(time.Now()), func() (*domain.SalesReport, error) {
+		report := &domain.SalesReport{
+			From:     filter.From.In(filter.Location),
+			To:       filter.To.In(filter.Location),
+			Period:   filter.Period,
+			Timezone: filter.Location.String(),
+			Totals:   &domain.SalesBucket{PeriodStart: filter.From.In(filter.Location)},
+		}
+
+		// lay out every bucket upfront so periods without sales are reported as zero
+		buckets := make(map[time.Time]*domain.SalesBucket)
+		for start := periodStart(filter.From, filter.Period, filter.Location); start.Before(filter.To); start = nextPeriod(start, filter.Period) {
+			bucket := &domain.SalesBucket{PeriodStart: start}
+			buckets[start] = bucket
+			report.Buckets = append(report.Buckets, bucket)
+		}
+
+		err := r.reportRepo.EachSale(ctx, filter.From, filter.To, func(row *domain.SaleRow) error {
+			bucket, ok := buckets[periodStart(row.PaidAt, filter.Period, filter.Location)]
+			if !ok {
+				return fmt.Errorf("sale at %s is outside of the report range", row.PaidAt)
+			}
+
+			for _, b := range []*domain.SalesBucket{bucket, report.Totals} {
+				b.Revenue += row.TotalPrice
+				b.Units += row.Units
+				b.Orders++
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, bucket := range report.Buckets {
+			setAverageBasket(bucket)
+		}
+		setAverageBasket(report.Totals)
+
+		return report, nil
+	})
+}
+
+// TopBooks
+func (r *reportService) TopBooks(ctx context.Context, filter *domain.ReportFilter) ([]*domain.TopBook, error) {
+	ctx, span := tracing.Start(ctx, "ReportService.TopBooks")
+	defer span.End()
+
+	return cached(r.cache, r.key("top-books", filter), r.cfg.CacheTTL, filter.Closed(time.Now()), func() ([]*domain.TopBook, error) {
+		return r.reportRepo.TopBooks(ctx, filter.From, filter.To, filter.Limit)
+	})
+}
+
+// TopAuthors
+func (r *reportService) TopAuthors(ctx context.Context, filter *domain.ReportFilter) ([]*domain.TopAuthor, error) {
+	ctx, span := tracing.Start(ctx, "ReportService.TopAuthors")
+	defer span.End()
+
+	return cached(r.cache, r.key("top-authors", filter), r.cfg.CacheTTL, filter.Closed(time.Now()), func() ([]*domain.TopAuthor, error) {
+		return r.reportRepo.TopAuthors(ctx, filter.From, filter.To, filter.Limit)
+	})
+}
+
+// TopCustomers
+func (r *reportService) TopCustomers(ctx context.Context, filter *domain.ReportFilter) ([]*domain.TopCustomer, error) {
+	ctx, span := tracing.Start(ctx, "ReportService.TopCustomers")
+	defer span.End()
+
+	return cached(r.cache, r.key("top-customers", filter), r.cfg.CacheTTL, filter.Closed(time.Now()), func() ([]*domain.TopCustomer, error) {
+		return r.reportRepo.TopCustomers(ctx, filter.From, filter.To, filter.Limit)
+	})
+}
+
+// Invalidate
+func (r *reportService) Invalidate(ctx context.Context) {
+	_, span := tracing.Start(ctx, "ReportService.Invalidate")
+	defer span.End()
+
+	r.cache.clear()
+}
+
+func (r *reportService) key(report string, filter *domain.ReportFilter) string {
+	return fmt.Sprintf("%s|%d|%d|%s|%s|%d", report, filter.From.Unix(), filter.To.Unix(), filter.Period, filter.Location, filter.Limit)
+}
+
+func setAverageBasket(bucket *domain.SalesBucket) {
+	if bucket.Orders > 0 {
+		bucket.AverageBasket = bucket.Revenue / bucket.Orders
+	}
+}
+
+// periodStart returns the local midnight opening the period t falls in, weeks start on monday
+func periodStart(t time.Time, period domain.ReportPeriod, loc *time.Location) time.Time {
+	t = t.In(loc)
+	year, month, day := t.Date()
+
+	switch period {
+	case domain.ReportPeriodWeek:
+		offset := (int(t.Weekday()) + 6) % 7
+		return time.Date(year, month, day-offset, 0, 0, 0, 0, loc)
+	case domain.ReportPeriodMonth:
+		return time.Date(year, month, 1, 0, 0, 0, 0, loc)
+	default:
+		return time.Date(year, month, day, 0, 0, 0, 0, loc)
+	}
+}
+
+// nextPeriod moves by calendar days rather than fixed durations so DST changes are respected
+func nextPeriod(start time.Time, period domain.ReportPeriod) time.Time {
+	switch period {
+	case domain.ReportPeriodWeek:
+		return start.AddDate(0, 0, 7)
+	case domain.ReportPeriodMonth:
+		return start.AddDate(0, 1, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+func NewReportService(reportRepo domain.ReportRepository, cfg config.Report) domain.ReportService {
+	return &reportService{
+		reportRepo: reportRepo,
+		cfg:        cfg,
+		cache:      newCache(cfg.CacheMaxEntries),
+	}
+}
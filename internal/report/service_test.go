@@ -0,0 +1,127 @@
+package report
+
+import (
+	"book-store/internal/domain"
+	"testing"
+	"time"
+)
+
+func TestPeriodStart(t *testing.T) {
+	jakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		t      time.Time
+		period domain.ReportPeriod
+		loc    *time.Location
+		want   time.Time
+	}{
+		{
+			name:   "day",
+			t:      time.Date(2024, 3, 14, 15, 30, 0, 0, time.UTC),
+			period: domain.ReportPeriodDay,
+			loc:    time.UTC,
+			want:   time.Date(2024, 3, 14, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "day is cut at local midnight",
+			t:      time.Date(2024, 3, 14, 18, 0, 0, 0, time.UTC),
+			period: domain.ReportPeriodDay,
+			loc:    jakarta,
+			want:   time.Date(2024, 3, 15, 0, 0, 0, 0, jakarta),
+		},
+		{
+			name:   "week starts on monday",
+			t:      time.Date(2024, 3, 14, 12, 0, 0, 0, time.UTC),
+			period: domain.ReportPeriodWeek,
+			loc:    time.UTC,
+			want:   time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "sunday belongs to the week before",
+			t:      time.Date(2024, 3, 17, 12, 0, 0, 0, time.UTC),
+			period: domain.ReportPeriodWeek,
+			loc:    time.UTC,
+			want:   time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "week across months",
+			t:      time.Date(2024, 3, 2, 12, 0, 0, 0, time.UTC),
+			period: domain.ReportPeriodWeek,
+			loc:    time.UTC,
+			want:   time.Date(2024, 2, 26, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "month",
+			t:      time.Date(2024, 3, 31, 23, 59, 0, 0, time.UTC),
+			period: domain.ReportPeriodMonth,
+			loc:    time.UTC,
+			want:   time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "month is cut at local midnight",
+			t:      time.Date(2024, 3, 31, 20, 0, 0, 0, time.UTC),
+			period: domain.ReportPeriodMonth,
+			loc:    jakarta,
+			want:   time.Date(2024, 4, 1, 0, 0, 0, 0, jakarta),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := periodStart(tt.t, tt.period, tt.loc); !got.Equal(tt.want) {
+				t.Errorf("periodStart() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextPeriod(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		start  time.Time
+		period domain.ReportPeriod
+		want   time.Time
+	}{
+		{
+			name:   "day",
+			start:  time.Date(2024, 2, 28, 0, 0, 0, 0, time.UTC),
+			period: domain.ReportPeriodDay,
+			want:   time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "day across the DST change is 23 hours",
+			start:  time.Date(2024, 3, 31, 0, 0, 0, 0, berlin),
+			period: domain.ReportPeriodDay,
+			want:   time.Date(2024, 4, 1, 0, 0, 0, 0, berlin),
+		},
+		{
+			name:   "week",
+			start:  time.Date(2024, 12, 30, 0, 0, 0, 0, time.UTC),
+			period: domain.ReportPeriodWeek,
+			want:   time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "month",
+			start:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			period: domain.ReportPeriodMonth,
+			want:   time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextPeriod(tt.start, tt.period); !got.Equal(tt.want) {
+				t.Errorf("nextPeriod() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
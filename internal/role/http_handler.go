@@ -1,12 +1,10 @@
 package role
 
 import (
-	"errors"
 	"book-store/internal/domain"
 	"strconv"
 
 	"github.com/gofiber/fiber/v2"
-	"gorm.io/gorm"
 )
 
 type HttpRoleHandler struct {
@@ -40,41 +38,26 @@ func NewHttpHandler(r fiber.Router, roleSvc domain.RoleService) {
 func (h *HttpRoleHandler) Fetch(c *fiber.Ctx) error {
 	page, size, query := c.QueryInt("page", 1), c.QueryInt("size", 10), c.Query("q")
 	if page <= 0 {
-		return c.Status(fiber.StatusBadRequest).JSON(domain.Error{
-			Code:    fiber.StatusBadRequest,
-			Message: "page must be a positive integer",
-		})
+		return domain.NewValidationError("page must be a positive integer")
 	}
 	if size <= 0 {
-		return c.Status(fiber.StatusBadRequest).JSON(domain.Error{
-			Code:    fiber.StatusBadRequest,
-			Message: "size must be a positive integer",
-		})
+		return domain.NewValidationError("size must be a positive integer")
 	}
 
 	filter := &domain.Role{Name: query}
 
-	roles, nextPage, err := h.roleSvc.Fetch(page, size, filter)
+	roles, nextPage, err := h.roleSvc.Fetch(c.UserContext(), page, size, filter)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(domain.Error{
-			Code:    fiber.StatusInternalServerError,
-			Message: err.Error(),
-		})
+		return err
 	}
 
 	if roles == nil {
-		return c.Status(fiber.StatusNotFound).JSON(domain.Error{
-			Code:    fiber.StatusNotFound,
-			Message: "roles not found",
-		})
+		return domain.NewNotFoundError("roles")
 	}
 
-	totalItem, err := h.roleSvc.Count(filter)
+	totalItem, err := h.roleSvc.Count(c.UserContext(), filter)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(domain.Error{
-			Code:    fiber.StatusInternalServerError,
-			Message: err.Error(),
-		})
+		return err
 	}
 
 	maxPage := int(totalItem) / size
@@ -108,24 +91,12 @@ func (h *HttpRoleHandler) Fetch(c *fiber.Ctx) error {
 func (h *HttpRoleHandler) GetById(c *fiber.Ctx) error {
 	id, err := c.ParamsInt("id")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(domain.Error{
-			Code:    fiber.StatusBadRequest,
-			Message: "invalid role id",
-		})
+		return domain.NewValidationError("invalid role id")
 	}
 
-	role, err := h.roleSvc.GetById(uint(id))
+	role, err := h.roleSvc.GetById(c.UserContext(), uint(id))
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return c.Status(fiber.StatusNotFound).JSON(domain.Error{
-				Code:    fiber.StatusNotFound,
-				Message: err.Error(),
-			})
-		}
-		return c.Status(fiber.StatusInternalServerError).JSON(domain.Error{
-			Code:    fiber.StatusInternalServerError,
-			Message: err.Error(),
-		})
+		return err
 	}
 
 	return c.Status(fiber.StatusOK).JSON(domain.Success{
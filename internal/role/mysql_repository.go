@@ -2,6 +2,7 @@ package role
 
 import (
 	"book-store/internal/domain"
+	"context"
 
 	"gorm.io/gorm"
 )
@@ -11,9 +12,9 @@ type mysqlRoleRepository struct {
 }
 
 // Count
-func (m *mysqlRoleRepository) Count(filter *domain.Role) (int64, error) {
+func (m *mysqlRoleRepository) Count(ctx context.Context, filter *domain.Role) (int64, error) {
 	var count int64
-	query := m.db.Model(&domain.Role{})
+	query := m.db.WithContext(ctx).Model(&domain.Role{})
 
 	if filter.Name != "" {
 		query = query.Where("name LIKE ?", "%"+filter.Name+"%")
@@ -27,11 +28,11 @@ func (m *mysqlRoleRepository) Count(filter *domain.Role) (int64, error) {
 }
 
 // Fetch
-func (m *mysqlRoleRepository) Fetch(page int, size int, filter *domain.Role) ([]*domain.Role, int, error) {
+func (m *mysqlRoleRepository) Fetch(ctx context.Context, page int, size int, filter *domain.Role) ([]*domain.Role, int, error) {
 	var roles []*domain.Role
 
 	offset := (page - 1) * size
-	query := m.db
+	query := m.db.WithContext(ctx)
 
 	if filter.Name != "" {
 		query = query.Where("name LIKE ?", "%"+filter.Name+"%")
@@ -50,10 +51,10 @@ func (m *mysqlRoleRepository) Fetch(page int, size int, filter *domain.Role) ([]
 }
 
 // GetById
-func (m *mysqlRoleRepository) GetById(id uint) (*domain.Role, error) {
+func (m *mysqlRoleRepository) GetById(ctx context.Context, id uint) (*domain.Role, error) {
 	var role *domain.Role
 
-	if err := m.db.First(&role, id).Error; err != nil {
+	if err := m.db.WithContext(ctx).First(&role, id).Error; err != nil {
 		return nil, err
 	}
 
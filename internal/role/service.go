@@ -1,10 +1,11 @@
 package role
 
 import (
-	"errors"
 	"book-store/internal/domain"
+	"book-store/internal/tracing"
+	"context"
+	"errors"
 
-	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 )
 
@@ -13,8 +14,11 @@ type roleService struct {
 }
 
 // Count
-func (r *roleService) Count(filter *domain.Role) (int64, error) {
-	count, err := r.roleRepo.Count(filter)
+func (r *roleService) Count(ctx context.Context, filter *domain.Role) (int64, error) {
+	ctx, span := tracing.Start(ctx, "RoleService.Count")
+	defer span.End()
+
+	count, err := r.roleRepo.Count(ctx, filter)
 	if err != nil {
 		return 0, err
 	}
@@ -22,8 +26,11 @@ func (r *roleService) Count(filter *domain.Role) (int64, error) {
 }
 
 // Fetch
-func (r *roleService) Fetch(page int, size int, filter *domain.Role) ([]*domain.Role, int, error) {
-	roles, nextCursor, err := r.roleRepo.Fetch(page, size, filter)
+func (r *roleService) Fetch(ctx context.Context, page int, size int, filter *domain.Role) ([]*domain.Role, int, error) {
+	ctx, span := tracing.Start(ctx, "RoleService.Fetch")
+	defer span.End()
+
+	roles, nextCursor, err := r.roleRepo.Fetch(ctx, page, size, filter)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -32,11 +39,14 @@ func (r *roleService) Fetch(page int, size int, filter *domain.Role) ([]*domain.
 }
 
 // GetById
-func (r *roleService) GetById(id uint) (*domain.Role, error) {
-	role, err := r.roleRepo.GetById(id)
+func (r *roleService) GetById(ctx context.Context, id uint) (*domain.Role, error) {
+	ctx, span := tracing.Start(ctx, "RoleService.GetById")
+	defer span.End()
+
+	role, err := r.roleRepo.GetById(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, fiber.ErrNotFound
+			return nil, domain.ErrRoleNotFound
 		}
 		return nil, err
 	}
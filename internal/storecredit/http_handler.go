@@ -0,0 +1,121 @@
+package storecredit
+
+import (
+	"book-store/internal/domain"
+	"book-store/internal/middleware/jwt"
+	"book-store/internal/middleware/validation"
+	"book-store/internal/utilities"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type HttpStoreCreditHandler struct {
+	storeCreditSvc domain.StoreCreditService
+	authMiddleware jwt.AuthMiddleware
+}
+
+func NewHttpHandler(r fiber.Router, storeCreditSvc domain.StoreCreditService, authMiddleware jwt.AuthMiddleware) {
+	handler := &HttpStoreCreditHandler{
+		storeCreditSvc: storeCreditSvc,
+		authMiddleware: authMiddleware,
+	}
+
+	r.Get("/:id/store-credit", authMiddleware.RequireRole("admin", "employee"), handler.GetSummary)
+	r.Post("/:id/store-credit", authMiddleware.RequireRole("admin"), validation.New[domain.StoreCreditIssueRequest](), handler.Issue)
+}
+
+// GetSummary used to get store credit balance and ledger of a customer
+//
+//	@Summary		Get customer store credit
+//	@Description	Get store credit balance and ledger of a customer
+//	@Tags			customers
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int				true	"customer ID"
+//	@Param			page	query		int				false	"Ledger page number (default 1)"
+//	@Param			size	query		int				false	"Ledger size of page (default 10)"
+//	@Header			200		{string}	X-Cursor		"Next page"
+//	@Header			200		{string}	X-Total-Count	"Total ledger entry"
+//	@Header			200		{string}	X-Max-Page		"Max page"
+//	@Success		200		{object}	domain.Success	"store credit summary"
+//	@Failure		400		{object}	domain.Error	"Bad Request"
+//	@Failure		404		{object}	domain.Error	"Not Found"
+//	@Failure		500		{object}	domain.Error	"Internal Server Error"
+//	@Router			/customers/{id}/store-credit [get]
+//
+// @Security Bearer
+func (h *HttpStoreCreditHandler) GetSummary(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return domain.NewValidationError("invalid customer id")
+	}
+
+	page, size := c.QueryInt("page", 1), c.QueryInt("size", 10)
+	if page <= 0 {
+		return domain.NewValidationError("page must be a positive integer")
+	}
+	if size <= 0 {
+		return domain.NewValidationError("size must be a positive integer")
+	}
+
+	summary, nextPage, err := h.storeCreditSvc.GetSummary(c.UserContext(), uint(id), page, size)
+	if err != nil {
+		return err
+	}
+
+	totalItem, err := h.storeCreditSvc.CountLedger(c.UserContext(), uint(id))
+	if err != nil {
+		return err
+	}
+
+	maxPage := int(totalItem) / size
+
+	if nextPage > 0 && nextPage <= maxPage {
+		c.Set("X-Cursor", strconv.Itoa(nextPage))
+	}
+	c.Set("X-Total-Count", strconv.Itoa(int(totalItem)))
+	c.Set("X-Max-Page", strconv.Itoa(maxPage))
+
+	return c.Status(fiber.StatusOK).JSON(domain.Success{
+		Code:    fiber.StatusOK,
+		Message: "success",
+		Data:    summary,
+	})
+}
+
+// Issue used to add store credit to a customer
+//
+//	@Summary		Issue store credit
+//	@Description	Add store credit to the balance of a customer, e.g. for a returned book. It can be spent with the store_credit tender
+//	@Tags			customers
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int								true	"customer ID"
+//	@Param			credit	body		domain.StoreCreditIssueRequest	true	"Store credit to issue"
+//	@Success		201		{object}	domain.Success					"store credit ledger entry"
+//	@Failure		400		{object}	domain.Error					"Bad Request"
+//	@Failure		404		{object}	domain.Error					"Not Found"
+//	@Failure		500		{object}	domain.Error					"Internal Server Error"
+//	@Router			/customers/{id}/store-credit [post]
+//
+// @Security Bearer
+func (h *HttpStoreCreditHandler) Issue(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return domain.NewValidationError("invalid customer id")
+	}
+
+	issueReq := utilities.ExtractStructFromValidator[domain.StoreCreditIssueRequest](c)
+
+	entry, err := h.storeCreditSvc.Issue(c.UserContext(), uint(id), issueReq)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(domain.Success{
+		Code:    fiber.StatusCreated,
+		Message: "success",
+		Data:    entry,
+	})
+}
@@ -0,0 +1,65 @@
+package storecredit
+
+import (
+	"book-store/internal/domain"
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type mysqlStoreCreditRepository struct {
+	db *gorm.DB
+}
+
+// FetchLedger
+func (m *mysqlStoreCreditRepository) FetchLedger(ctx context.Context, customerId uint, page int, size int) ([]*domain.StoreCreditLedger, int, error) {
+	var entries []*domain.StoreCreditLedger
+
+	offset := (page - 1) * size
+	if err := m.db.WithContext(ctx).Where("customer_id = ?", customerId).Order("created_at DESC").Offset(offset).Limit(size).Find(&entries).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var nextCursor int
+	if len(entries) > 0 {
+		nextCursor = page + 1 // Next page
+	}
+
+	return entries, nextCursor, nil
+}
+
+// CountLedger
+func (m *mysqlStoreCreditRepository) CountLedger(ctx context.Context, customerId uint) (int64, error) {
+	var count int64
+
+	if err := m.db.WithContext(ctx).Model(&domain.StoreCreditLedger{}).Where("customer_id = ?", customerId).Count(&count).Error; err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// Issue
+func (m *mysqlStoreCreditRepository) Issue(ctx context.Context, customerId uint, entry *domain.StoreCreditLedger) error {
+	return m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var customer domain.Customer
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&customer, customerId).Error; err != nil {
+			return err
+		}
+
+		if err := customer.AddStoreCredit(entry); err != nil {
+			return err
+		}
+
+		if err := tx.Model(&customer).Update("store_credit", customer.StoreCredit).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(entry).Error
+	})
+}
+
+func NewMysqlStoreCreditRepository(db *gorm.DB) domain.StoreCreditRepository {
+	return &mysqlStoreCreditRepository{db: db}
+}
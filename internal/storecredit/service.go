@@ -0,0 +1,94 @@
+package storecredit
+
+import (
+	"book-store/internal/domain"
+	"book-store/internal/tracing"
+	"book-store/pkg/xlogger"
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+type storeCreditService struct {
+	storeCreditRepo domain.StoreCreditRepository
+	customerRepo    domain.CustomerRepository
+}
+
+// GetSummary
+func (s *storeCreditService) GetSummary(ctx context.Context, customerId uint, page int, size int) (*domain.StoreCreditSummary, int, error) {
+	ctx, span := tracing.Start(ctx, "StoreCreditService.GetSummary")
+	defer span.End()
+
+	customer, err := s.customerRepo.GetById(ctx, customerId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, 0, domain.ErrCustomerNotFound
+		}
+		return nil, 0, err
+	}
+
+	ledger, nextCursor, err := s.storeCreditRepo.FetchLedger(ctx, customerId, page, size)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return &domain.StoreCreditSummary{
+		CustomerId: customer.ID,
+		Balance:    customer.StoreCredit,
+		Ledger:     ledger,
+	}, nextCursor, nil
+}
+
+// CountLedger
+func (s *storeCreditService) CountLedger(ctx context.Context, customerId uint) (int64, error) {
+	ctx, span := tracing.Start(ctx, "StoreCreditService.CountLedger")
+	defer span.End()
+
+	return s.storeCreditRepo.CountLedger(ctx, customerId)
+}
+
+// Issue adds store credit to the balance of the customer, e.g. for a returned book
+func (s *storeCreditService) Issue(ctx context.Context, customerId uint, req *domain.StoreCreditIssueRequest) (*domain.StoreCreditLedger, error) {
+	ctx, span := tracing.Start(ctx, "StoreCreditService.Issue")
+	defer span.End()
+
+	entry := &domain.StoreCreditLedger{
+		Type:        domain.StoreCreditEntryIssue,
+		Amount:      req.Amount,
+		Description: req.Description,
+	}
+	if err := s.storeCreditRepo.Issue(ctx, customerId, entry); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrCustomerNotFound
+		}
+		return nil, err
+	}
+
+	xlogger.Ctx(ctx).Info().Uint("customer_id", customerId).Int("amount", req.Amount).Msg("store credit issued")
+	return entry, nil
+}
+
+// CheckSpendable
+func (s *storeCreditService) CheckSpendable(ctx context.Context, customerId uint, amount int) error {
+	ctx, span := tracing.Start(ctx, "StoreCreditService.CheckSpendable")
+	defer span.End()
+
+	customer, err := s.customerRepo.GetById(ctx, customerId)
+	if err != nil {
+		return err
+	}
+
+	if customer.StoreCredit < amount {
+		return domain.ErrInsufficientStoreCredit
+	}
+
+	return nil
+}
+
+func NewStoreCreditService(storeCreditRepo domain.StoreCreditRepository, customerRepo domain.CustomerRepository) domain.StoreCreditService {
+	return &storeCreditService{
+		storeCreditRepo: storeCreditRepo,
+		customerRepo:    customerRepo,
+	}
+}
@@ -1,9 +1,9 @@
 package transaction
 
 import (
-	"errors"
 	"book-store/internal/domain"
 	"book-store/internal/middleware/jwt"
+	"book-store/internal/middleware/softdelete"
 	"book-store/internal/middleware/validation"
 	"book-store/internal/utilities"
 	"strconv"
@@ -23,11 +23,14 @@ func NewHttpHandler(r fiber.Router, transactionSvc domain.TransactionService, au
 		authMiddleware: authMiddleware,
 	}
 
-	r.Get("/", handler.Fetch)
-	r.Get("/:id", handler.GetById)
+	r.Get("/", softdelete.New(handler.authMiddleware), handler.Fetch)
+	r.Get("/export", handler.authMiddleware.RequireRole("admin", "employee"), handler.Export)
+	r.Get("/:id", softdelete.New(handler.authMiddleware), handler.GetById)
 	r.Post("/", handler.authMiddleware.RequireRole("admin", "employee"), validation.New[domain.TransactionStoreRequest](), handler.Store)
+	r.Post("/:id/payments", handler.authMiddleware.RequireRole("admin", "employee"), validation.New[domain.TransactionPaymentRequest](), handler.Pay)
 	r.Put("/:id", handler.authMiddleware.RequireRole("admin", "employee"), validation.New[domain.TransactionUpdateRequest](), handler.Update)
 	r.Delete("/:id", handler.authMiddleware.RequireRole("admin"), handler.Delete)
+	r.Post("/:id/restore", handler.authMiddleware.RequireRole("admin"), handler.Restore)
 }
 
 // Fetch used to get list of transaction
@@ -40,6 +43,7 @@ func NewHttpHandler(r fiber.Router, transactionSvc domain.TransactionService, au
 //	@Param			page	query		int				false	"Page number (default 1)"
 //	@Param			size	query		int				false	"Size of page (default 10)"
 //	@Param			q		query		string			false	"Customer Id"
+//	@Param			include_deleted	query		bool			false	"Include soft-deleted records, admin only"
 //	@Header			200		{string}	X-Cursor		"Next page"
 //	@Header			200		{string}	X-Total-Count	"Total item"
 //	@Header			200		{string}	X-Max-Page		"Max page"
@@ -50,40 +54,25 @@ func NewHttpHandler(r fiber.Router, transactionSvc domain.TransactionService, au
 func (h *HttpTransactionHandler) Fetch(c *fiber.Ctx) error {
 	page, size, query := c.QueryInt("page", 1), c.QueryInt("size", 10), c.QueryInt("q")
 	if page <= 0 {
-		return c.Status(fiber.StatusBadRequest).JSON(domain.Error{
-			Code:    fiber.StatusBadRequest,
-			Message: "page must be a positive integer",
-		})
+		return domain.NewValidationError("page must be a positive integer")
 	}
 	if size <= 0 {
-		return c.Status(fiber.StatusBadRequest).JSON(domain.Error{
-			Code:    fiber.StatusBadRequest,
-			Message: "size must be a positive integer",
-		})
+		return domain.NewValidationError("size must be a positive integer")
 	}
 
 	filter := &domain.Transaction{CustomerId: uint(query)}
-	transactions, nextPage, err := h.transactionSvc.Fetch(page, size, filter)
+	transactions, nextPage, err := h.transactionSvc.Fetch(c.UserContext(), page, size, filter)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(domain.Error{
-			Code:    fiber.StatusInternalServerError,
-			Message: err.Error(),
-		})
+		return err
 	}
 
 	if transactions == nil {
-		return c.Status(fiber.StatusNotFound).JSON(domain.Error{
-			Code:    fiber.StatusNotFound,
-			Message: "transactions not found",
-		})
+		return domain.NewNotFoundError("transactions")
 	}
 
-	totalItem, err := h.transactionSvc.Count(filter)
+	totalItem, err := h.transactionSvc.Count(c.UserContext(), filter)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(domain.Error{
-			Code:    fiber.StatusInternalServerError,
-			Message: err.Error(),
-		})
+		return err
 	}
 
 	maxPage := int(totalItem) / size
@@ -101,6 +90,53 @@ func (h *HttpTransactionHandler) Fetch(c *fiber.Ctx) error {
 	})
 }
 
+// Export used to download transactions as csv or xlsx
+//
+//	@Summary		Export transactions
+//	@Description	Stream the transactions matching the list filters as csv or xlsx, one row per line item
+//	@Tags			transactions
+//	@Produce		text/csv
+//	@Produce		application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+//	@Param			format	query		string			false	"Export format (csv, xlsx), default csv"
+//	@Param			q		query		string			false	"Customer Id"
+//	@Success		200		{file}		file			"transactions export"
+//	@Failure		400		{object}	domain.Error	"Bad Request"
+//	@Router			/transactions/export [get]
+//
+// @Security Bearer
+func (h *HttpTransactionHandler) Export(c *fiber.Ctx) error {
+	format, query := domain.ExportFormat(c.Query("format", string(domain.ExportFormatCSV))), c.QueryInt("q")
+
+	filter := &domain.Transaction{CustomerId: uint(query)}
+	header := []string{"transaction_id", "invoice_number", "created_at", "status", "user_id", "customer_id", "customer_name", "discount", "total_price", "book_id", "isbn", "title", "author", "quantity", "sub_total"}
+	ctx := c.UserContext()
+	return utilities.StreamExport(c, format, "transactions", header, func(write func(values ...any) error) error {
+		return h.transactionSvc.Each(ctx, filter, func(transaction *domain.Transaction) error {
+			var invoiceNumber, customerName string
+			if transaction.InvoiceNumber != nil {
+				invoiceNumber = *transaction.InvoiceNumber
+			}
+			if transaction.Customer != nil {
+				customerName = transaction.Customer.Name
+			}
+
+			// flatten the line items, every row repeats the transaction columns
+			for _, detail := range transaction.TransactionDetails {
+				var isbn, title, author string
+				if detail.Book != nil {
+					isbn, title, author = detail.Book.Isbn, detail.Book.Title, detail.Book.Author
+				}
+
+				if err := write(transaction.ID, invoiceNumber, transaction.CreatedAt, transaction.Status, transaction.UserId, transaction.CustomerId, customerName, transaction.Discount, transaction.TotalPrice,
+					detail.BookId, isbn, title, author, detail.Quantity, detail.SubTotal); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+}
+
 // GetByID used to get transaction by id
 //
 //	@Summary		Get transaction by id
@@ -109,6 +145,7 @@ func (h *HttpTransactionHandler) Fetch(c *fiber.Ctx) error {
 //	@Accept			json
 //	@Produce		json
 //	@Param			id	path		int				true	"transaction ID"
+//	@Param			include_deleted	query		bool			false	"Include soft-deleted records, admin only"
 //	@Success		200	{object}	domain.Success	"transaction detail"
 //	@Failure		400	{object}	domain.Error	"Bad Request"
 //	@Failure		404	{object}	domain.Error	"Not Found"
@@ -117,24 +154,12 @@ func (h *HttpTransactionHandler) Fetch(c *fiber.Ctx) error {
 func (h *HttpTransactionHandler) GetById(c *fiber.Ctx) error {
 	id, err := c.ParamsInt("id")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(domain.Error{
-			Code:    fiber.StatusBadRequest,
-			Message: "invalid transaction id",
-		})
+		return domain.NewValidationError("invalid transaction id")
 	}
 
-	transaction, err := h.transactionSvc.GetById(uint(id))
+	transaction, err := h.transactionSvc.GetById(c.UserContext(), uint(id))
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return c.Status(fiber.StatusNotFound).JSON(domain.Error{
-				Code:    fiber.StatusNotFound,
-				Message: err.Error(),
-			})
-		}
-		return c.Status(fiber.StatusInternalServerError).JSON(domain.Error{
-			Code:    fiber.StatusInternalServerError,
-			Message: err.Error(),
-		})
+		return err
 	}
 
 	return c.Status(fiber.StatusOK).JSON(domain.Success{
@@ -147,13 +172,14 @@ func (h *HttpTransactionHandler) GetById(c *fiber.Ctx) error {
 // Store used to store transaction
 //
 //	@Summary		Store transaction
-//	@Description	Store transaction
+//	@Description	Store transaction, stays pending until the payments cover the total price. redeem_points takes loyalty points off the total price as a discount
 //	@Tags			transactions
 //	@Accept			json
 //	@Produce		json
 //	@Param			transaction	body		domain.TransactionStoreRequest	true	"transaction data"
 //	@Success		201		{object}	domain.Success				"transaction detail"
 //	@Failure		400		{object}	domain.Error				"Bad Request"
+//	@Failure		422		{object}	domain.Error				"Insufficient stock or payment declined"
 //	@Failure		500		{object}	domain.Error				"Internal Server Error"
 //	@Router			/transactions [post]
 //
@@ -161,17 +187,14 @@ func (h *HttpTransactionHandler) GetById(c *fiber.Ctx) error {
 func (h *HttpTransactionHandler) Store(c *fiber.Ctx) error {
 	transactionReq := utilities.ExtractStructFromValidator[domain.TransactionStoreRequest](c)
 
-	transaction := &domain.TransactionStoreRequest{
+	transaction, err := h.transactionSvc.Store(c.UserContext(), &domain.TransactionStoreRequest{
 		UserId:             transactionReq.UserId,
 		CustomerId:         transactionReq.CustomerId,
 		TransactionDetails: transactionReq.TransactionDetails,
-	}
-
-	if err := h.transactionSvc.Store(transaction); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(domain.Error{
-			Code:    fiber.StatusInternalServerError,
-			Message: err.Error(),
-		})
+		Payments:           transactionReq.Payments,
+	})
+	if err != nil {
+		return err
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(domain.Success{
@@ -181,6 +204,44 @@ func (h *HttpTransactionHandler) Store(c *fiber.Ctx) error {
 	})
 }
 
+// Pay used to add payments to a pending transaction
+//
+//	@Summary		Pay transaction
+//	@Description	Record one or more tenders against a pending transaction, the sale is finalized once fully paid
+//	@Tags			transactions
+//	@Accept			json
+//	@Produce		json
+//	@Param			id			path		int									true	"transaction ID"
+//	@Param			payments	body		domain.TransactionPaymentRequest	true	"payment data"
+//	@Success		200			{object}	domain.Success						"transaction detail"
+//	@Failure		400			{object}	domain.Error						"Bad Request"
+//	@Failure		404			{object}	domain.Error						"Not Found"
+//	@Failure		409			{object}	domain.Error						"Conflict"
+//	@Failure		422			{object}	domain.Error						"Insufficient stock or payment declined"
+//	@Failure		500			{object}	domain.Error						"Internal Server Error"
+//	@Router			/transactions/{id}/payments [post]
+//
+// @Security Bearer
+func (h *HttpTransactionHandler) Pay(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return domain.NewValidationError("invalid transaction id")
+	}
+
+	paymentReq := utilities.ExtractStructFromValidator[domain.TransactionPaymentRequest](c)
+
+	transaction, err := h.transactionSvc.Pay(c.UserContext(), uint(id), paymentReq.Payments)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(domain.Success{
+		Code:    fiber.StatusOK,
+		Message: "success",
+		Data:    transaction,
+	})
+}
+
 // Update used to update transaction
 //
 //	@Summary		Update transaction
@@ -200,10 +261,7 @@ func (h *HttpTransactionHandler) Store(c *fiber.Ctx) error {
 func (h *HttpTransactionHandler) Update(c *fiber.Ctx) error {
 	id, err := c.ParamsInt("id")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(domain.Error{
-			Code:    fiber.StatusBadRequest,
-			Message: "invalid transaction id",
-		})
+		return domain.NewValidationError("invalid transaction id")
 	}
 
 	transactionReq := utilities.ExtractStructFromValidator[domain.TransactionUpdateRequest](c)
@@ -215,17 +273,8 @@ func (h *HttpTransactionHandler) Update(c *fiber.Ctx) error {
 		TransactionDetails: transactionReq.TransactionDetails,
 	}
 
-	if err := h.transactionSvc.Update(transaction); err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return c.Status(fiber.StatusNotFound).JSON(domain.Error{
-				Code:    fiber.StatusNotFound,
-				Message: err.Error(),
-			})
-		}
-		return c.Status(fiber.StatusInternalServerError).JSON(domain.Error{
-			Code:    fiber.StatusInternalServerError,
-			Message: err.Error(),
-		})
+	if err := h.transactionSvc.Update(c.UserContext(), transaction); err != nil {
+		return err
 	}
 
 	return c.Status(fiber.StatusOK).JSON(domain.Success{
@@ -245,6 +294,7 @@ func (h *HttpTransactionHandler) Update(c *fiber.Ctx) error {
 //	@Param			id	path		int				true	"transaction ID"
 //	@Success		200	{object}	domain.Success	"Success delete transaction"
 //	@Failure		400	{object}	domain.Error	"Bad Request"
+//	@Failure		404	{object}	domain.Error	"Not Found"
 //	@Failure		500	{object}	domain.Error	"Internal Server Error"
 //	@Router			/transactions/{id} [delete]
 //
@@ -252,17 +302,11 @@ func (h *HttpTransactionHandler) Update(c *fiber.Ctx) error {
 func (h *HttpTransactionHandler) Delete(c *fiber.Ctx) error {
 	id, err := c.ParamsInt("id")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(domain.Error{
-			Code:    fiber.StatusBadRequest,
-			Message: "invalid transaction id",
-		})
+		return domain.NewValidationError("invalid transaction id")
 	}
 
-	if err := h.transactionSvc.Delete(uint(id)); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(domain.Error{
-			Code:    fiber.StatusInternalServerError,
-			Message: err.Error(),
-		})
+	if err := h.transactionSvc.Delete(c.UserContext(), uint(id)); err != nil {
+		return err
 	}
 
 	return c.Status(fiber.StatusOK).JSON(domain.Success{
@@ -270,3 +314,37 @@ func (h *HttpTransactionHandler) Delete(c *fiber.Ctx) error {
 		Message: "success",
 	})
 }
+
+// Restore used to restore a deleted transaction
+//
+//	@Summary		Restore transaction
+//	@Description	Restore a soft-deleted transaction
+//	@Tags			transactions
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		int				true	"Transaction ID"
+//	@Success		200	{object}	domain.Success	"transaction detail"
+//	@Failure		400	{object}	domain.Error	"Bad Request"
+//	@Failure		404	{object}	domain.Error	"Not Found"
+//	@Failure		409	{object}	domain.Error	"Not Deleted"
+//	@Failure		500	{object}	domain.Error	"Internal Server Error"
+//	@Router			/transactions/{id}/restore [post]
+//
+// @Security Bearer
+func (h *HttpTransactionHandler) Restore(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return domain.NewValidationError("invalid transaction id")
+	}
+
+	transaction, err := h.transactionSvc.Restore(c.UserContext(), uint(id))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(domain.Success{
+		Code:    fiber.StatusOK,
+		Message: "transaction restored successfully",
+		Data:    transaction,
+	})
+}
@@ -2,8 +2,14 @@ package transaction
 
 import (
 	"book-store/internal/domain"
+	"book-store/internal/utilities"
+	"cmp"
+	"context"
+	"slices"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type mysqlTransactionRepository struct {
@@ -11,14 +17,18 @@ type mysqlTransactionRepository struct {
 }
 
 // Count implements domain.TransactionRepository.
-func (m *mysqlTransactionRepository) Count(filter *domain.Transaction) (int64, error) {
+func (m *mysqlTransactionRepository) Count(ctx context.Context, filter *domain.Transaction) (int64, error) {
 	var count int64
-	query := m.db.Model(&domain.Transaction{})
+	query := utilities.Scoped(ctx, m.db).Model(&domain.Transaction{})
 
 	if filter.CustomerId > 0 {
 		query = query.Where("customer_id = ?", filter.CustomerId)
 	}
 
+	if filter.UserId > 0 {
+		query = query.Where("user_id = ?", filter.UserId)
+	}
+
 	if err := query.Count(&count).Error; err != nil {
 		return 0, err
 	}
@@ -26,22 +36,27 @@ func (m *mysqlTransactionRepository) Count(filter *domain.Transaction) (int64, e
 	return count, nil
 }
 
-// Delete
-func (m *mysqlTransactionRepository) Delete(id uint) error {
-	return m.db.Delete(&domain.Transaction{}, id).Error
+// Delete returns gorm.ErrRecordNotFound when there is no such transaction
+func (m *mysqlTransactionRepository) Delete(ctx context.Context, id uint) error {
+	db := m.db.WithContext(ctx)
+	return utilities.RequireRows(db, db.Delete(&domain.Transaction{}, id), &domain.Transaction{}, id)
 }
 
 // Fetch
-func (m *mysqlTransactionRepository) Fetch(page int, size int, filter *domain.Transaction) ([]*domain.Transaction, int, error) {
+func (m *mysqlTransactionRepository) Fetch(ctx context.Context, page int, size int, filter *domain.Transaction) ([]*domain.Transaction, int, error) {
 	var transactions []*domain.Transaction
 
 	offset := (page - 1) * size
-	query := m.db.Preload("TransactionDetails")
+	query := utilities.Scoped(ctx, m.db).Preload("TransactionDetails").Preload("Payments")
 
 	if filter.CustomerId > 0 {
 		query = query.Where("customer_id = ?", filter.CustomerId)
 	}
 
+	if filter.UserId > 0 {
+		query = query.Where("user_id = ?", filter.UserId)
+	}
+
 	if err := query.Order("created_at DESC").Offset(offset).Limit(size).Find(&transactions).Error; err != nil {
 		return nil, 0, err
 	}
@@ -54,39 +69,253 @@ func (m *mysqlTransactionRepository) Fetch(page int, size int, filter *domain.Tr
 	return transactions, nextCursor, nil
 }
 
-// GetById
-func (m *mysqlTransactionRepository) GetById(id uint) (*domain.Transaction, error) {
-	var transaction *domain.Transaction
+// FetchByCustomer returns the transactions of a customer with line items and their books
+func (m *mysqlTransactionRepository) FetchByCustomer(ctx context.Context, customerId uint, page int, size int) ([]*domain.Transaction, int, error) {
+	var transactions []*domain.Transaction
 
-	if err := m.db.Preload("TransactionDetails").Preload("User").Preload("Customer").First(&transaction, id).Error; err != nil {
-		return nil, err
+	offset := (page - 1) * size
+	query := m.db.WithContext(ctx).Preload("TransactionDetails.Book", utilities.Unscoped).Preload("Payments").Where("customer_id = ?", customerId)
+
+	if err := query.Order("created_at DESC").Offset(offset).Limit(size).Find(&transactions).Error; err != nil {
+		return nil, 0, err
 	}
 
-	// get book details
-	transactionDetails := make([]*domain.TransactionDetail, len(transaction.TransactionDetails))
-	for _, detail := range transaction.TransactionDetails {
-		var book *domain.Book
-		if err := m.db.First(&book, detail.BookId).Error; err != nil {
-			return nil, err
+	var nextCursor int
+	if len(transactions) > 0 {
+		nextCursor = page + 1 // Next page
+	}
+
+	return transactions, nextCursor, nil
+}
+
+// Each walks every transaction matching the filter in batches, with line items, books and customer, deleted ones included
+func (m *mysqlTransactionRepository) Each(ctx context.Context, filter *domain.Transaction, fn func(transaction *domain.Transaction) error) error {
+	var transactions []*domain.Transaction
+
+	query := m.db.WithContext(ctx).Preload("TransactionDetails.Book", utilities.Unscoped).Preload("Customer", utilities.Unscoped)
+	if filter.CustomerId > 0 {
+		query = query.Where("customer_id = ?", filter.CustomerId)
+	}
+
+	if filter.UserId > 0 {
+		query = query.Where("user_id = ?", filter.UserId)
+	}
+
+	return query.FindInBatches(&transactions, 200, func(tx *gorm.DB, batch int) error {
+		for _, transaction := range transactions {
+			if err := fn(transaction); err != nil {
+				return err
+			}
 		}
-		detail.Book = book
+		return nil
+	}).Error
+}
 
-		// set transactionDetails
-		transactionDetails = append(transactionDetails, detail)
+// GetById loads the books, cashier and customer even when they were deleted since
+func (m *mysqlTransactionRepository) GetById(ctx context.Context, id uint) (*domain.Transaction, error) {
+	var transaction *domain.Transaction
+
+	if err := utilities.Scoped(ctx, m.db).
+		Preload("TransactionDetails.Book", utilities.Unscoped).
+		Preload("Payments").
+		Preload("User", utilities.Unscoped).
+		Preload("Customer", utilities.Unscoped).
+		First(&transaction, id).Error; err != nil {
+		return nil, err
 	}
 
-	transaction.TransactionDetails = transactionDetails
 	return transaction, nil
 }
 
 // Store
-func (m *mysqlTransactionRepository) Store(transaction *domain.Transaction) error {
-	return m.db.Create(transaction).Error
+func (m *mysqlTransactionRepository) Store(ctx context.Context, transaction *domain.Transaction, loyaltyEntries []*domain.LoyaltyLedger) error {
+	return m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if transaction.Status == domain.TransactionStatusPaid {
+			if err := deductStock(tx, transaction.TransactionDetails); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Create(transaction).Error; err != nil {
+			return err
+		}
+
+		return chargeCustomer(tx, transaction, transaction.Payments, loyaltyEntries)
+	})
+}
+
+// Pay holds the lock on the transaction while fn charges the tenders, so concurrent payments of it wait
+// for each other instead of both charging what is owed
+func (m *mysqlTransactionRepository) Pay(ctx context.Context, id uint, fn func(transaction *domain.Transaction) ([]*domain.Payment, []*domain.LoyaltyLedger, error)) (*domain.Transaction, error) {
+	var transaction domain.Transaction
+
+	err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("TransactionDetails").Preload("Payments").First(&transaction, id).Error; err != nil {
+			return err
+		}
+
+		payments, loyaltyEntries, err := fn(&transaction)
+		if err != nil {
+			return err
+		}
+
+		if transaction.Status == domain.TransactionStatusPaid {
+			if err := deductStock(tx, transaction.TransactionDetails); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&domain.Transaction{}).Where("id = ?", transaction.ID).Updates(map[string]any{
+			"paid_amount":   transaction.PaidAmount,
+			"change_amount": transaction.ChangeAmount,
+			"status":        transaction.Status,
+			"paid_at":       transaction.PaidAt,
+		}).Error; err != nil {
+			return err
+		}
+
+		if len(payments) > 0 {
+			for _, payment := range payments {
+				payment.TransactionId = transaction.ID
+			}
+			if err := tx.Create(&payments).Error; err != nil {
+				return err
+			}
+			transaction.Payments = append(transaction.Payments, payments...)
+		}
+
+		return chargeCustomer(tx, &transaction, payments, loyaltyEntries)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &transaction, nil
 }
 
-// Update
-func (m *mysqlTransactionRepository) Update(transaction *domain.Transaction) error {
-	return m.db.Updates(transaction).Error
+// Update returns gorm.ErrRecordNotFound when there is no such transaction
+func (m *mysqlTransactionRepository) Update(ctx context.Context, transaction *domain.Transaction) error {
+	db := m.db.WithContext(ctx)
+	return utilities.RequireRows(db, db.Updates(transaction), &domain.Transaction{}, transaction.ID)
+}
+
+// Restore
+func (m *mysqlTransactionRepository) Restore(ctx context.Context, id uint) error {
+	return utilities.Restore(m.db.WithContext(ctx), &domain.Transaction{}, id)
+}
+
+// Purge removes the transactions with their line items and payments, loyalty and store credit entries keep
+// their amounts but lose the link to the transaction
+func (m *mysqlTransactionRepository) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	var purged int64
+
+	err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var ids []uint
+		if err := tx.Unscoped().Model(&domain.Transaction{}).Where("deleted_at < ?", deletedBefore).Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		if err := tx.Unscoped().Model(&domain.LoyaltyLedger{}).Where("transaction_id IN ?", ids).Update("transaction_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&domain.StoreCreditLedger{}).Where("transaction_id IN ?", ids).Update("transaction_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("transaction_id IN ?", ids).Delete(&domain.Payment{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("transaction_id IN ?", ids).Delete(&domain.TransactionDetail{}).Error; err != nil {
+			return err
+		}
+
+		result := tx.Unscoped().Delete(&domain.Transaction{}, ids)
+		purged = result.RowsAffected
+		return result.Error
+	})
+
+	return purged, err
+}
+
+// deductStock takes the quantities out of stock in one statement per book, so concurrent sales can't sell the
+// same copies. Books are updated in the order of their ids so sales sharing books lock them alike
+func deductStock(tx *gorm.DB, transactionDetails []*domain.TransactionDetail) error {
+	details := slices.Clone(transactionDetails)
+	slices.SortFunc(details, func(a, b *domain.TransactionDetail) int {
+		return cmp.Compare(a.BookId, b.BookId)
+	})
+
+	for _, detail := range details {
+		result := tx.Model(&domain.Book{}).
+			Where("id = ? AND stock >= ?", detail.BookId, detail.Quantity).
+			Update("stock", gorm.Expr("stock - ?", detail.Quantity))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return domain.ErrInsufficientStock
+		}
+	}
+
+	return nil
+}
+
+// chargeCustomer books the loyalty entries and the store credit the payments spend on the customer of the
+// transaction under a lock, so concurrent sales can't redeem the same points or spend the same credit
+func chargeCustomer(tx *gorm.DB, transaction *domain.Transaction, payments []*domain.Payment, loyaltyEntries []*domain.LoyaltyLedger) error {
+	var creditEntries []*domain.StoreCreditLedger
+	for _, payment := range payments {
+		if payment.Method == domain.PaymentMethodStoreCredit {
+			creditEntries = append(creditEntries, &domain.StoreCreditLedger{
+				TransactionId: &transaction.ID,
+				Type:          domain.StoreCreditEntrySpend,
+				Amount:        -payment.Amount,
+				Description:   "spent as payment",
+			})
+		}
+	}
+	if len(loyaltyEntries) == 0 && len(creditEntries) == 0 {
+		return nil
+	}
+
+	var customer domain.Customer
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&customer, transaction.CustomerId).Error; err != nil {
+		return err
+	}
+
+	for _, entry := range loyaltyEntries {
+		if err := customer.AddLoyalty(entry); err != nil {
+			return err
+		}
+		entry.CustomerId = customer.ID
+		entry.TransactionId = &transaction.ID
+	}
+	for _, entry := range creditEntries {
+		if err := customer.AddStoreCredit(entry); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Model(&customer).Updates(map[string]any{
+		"loyalty_points":  customer.LoyaltyPoints,
+		"lifetime_points": customer.LifetimePoints,
+		"loyalty_tier":    customer.LoyaltyTier,
+		"store_credit":    customer.StoreCredit,
+	}).Error; err != nil {
+		return err
+	}
+
+	if len(loyaltyEntries) > 0 {
+		if err := tx.Create(&loyaltyEntries).Error; err != nil {
+			return err
+		}
+	}
+	if len(creditEntries) > 0 {
+		return tx.Create(&creditEntries).Error
+	}
+	return nil
 }
 
 func NewMysqlTransactionRepository(db *gorm.DB) domain.TransactionRepository {
@@ -1,21 +1,32 @@
 package transaction
 
 import (
-	"errors"
 	"book-store/internal/domain"
+	"book-store/internal/metrics"
+	"book-store/internal/tracing"
+	"book-store/pkg/xlogger"
+	"context"
+	"errors"
+	"time"
 
-	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 )
 
 type transactionService struct {
 	transactionRepo domain.TransactionRepository
 	bookRepo        domain.BookRepository
+	paymentSvc      domain.PaymentService
+	loyaltySvc      domain.LoyaltyService
+	storeCreditSvc  domain.StoreCreditService
+	reportSvc       domain.ReportService
 }
 
 // Count implements domain.TransactionService.
-func (t *transactionService) Count(filter *domain.Transaction) (int64, error) {
-	count, err := t.transactionRepo.Count(filter)
+func (t *transactionService) Count(ctx context.Context, filter *domain.Transaction) (int64, error) {
+	ctx, span := tracing.Start(ctx, "TransactionService.Count")
+	defer span.End()
+
+	count, err := t.transactionRepo.Count(ctx, filter)
 	if err != nil {
 		return 0, err
 	}
@@ -24,13 +35,42 @@ func (t *transactionService) Count(filter *domain.Transaction) (int64, error) {
 }
 
 // Delete
-func (t *transactionService) Delete(id uint) error {
-	return t.transactionRepo.Delete(id)
+func (t *transactionService) Delete(ctx context.Context, id uint) error {
+	ctx, span := tracing.Start(ctx, "TransactionService.Delete")
+	defer span.End()
+
+	if err := t.transactionRepo.Delete(ctx, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrTransactionNotFound
+		}
+		return err
+	}
+	t.reportSvc.Invalidate(ctx)
+	return nil
+}
+
+// Restore
+func (t *transactionService) Restore(ctx context.Context, id uint) (*domain.Transaction, error) {
+	ctx, span := tracing.Start(ctx, "TransactionService.Restore")
+	defer span.End()
+
+	if err := t.transactionRepo.Restore(ctx, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrTransactionNotFound
+		}
+		return nil, err
+	}
+	t.reportSvc.Invalidate(ctx)
+
+	return t.GetById(ctx, id)
 }
 
 // Fetch
-func (t *transactionService) Fetch(page int, size int, filter *domain.Transaction) ([]*domain.Transaction, int, error) {
-	transaction, nextCursor, err := t.transactionRepo.Fetch(page, size, filter)
+func (t *transactionService) Fetch(ctx context.Context, page int, size int, filter *domain.Transaction) ([]*domain.Transaction, int, error) {
+	ctx, span := tracing.Start(ctx, "TransactionService.Fetch")
+	defer span.End()
+
+	transaction, nextCursor, err := t.transactionRepo.Fetch(ctx, page, size, filter)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -39,11 +79,14 @@ func (t *transactionService) Fetch(page int, size int, filter *domain.Transactio
 }
 
 // GetById
-func (t *transactionService) GetById(id uint) (*domain.Transaction, error) {
-	transaction, err := t.transactionRepo.GetById(id)
+func (t *transactionService) GetById(ctx context.Context, id uint) (*domain.Transaction, error) {
+	ctx, span := tracing.Start(ctx, "TransactionService.GetById")
+	defer span.End()
+
+	transaction, err := t.transactionRepo.GetById(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, fiber.ErrNotFound
+			return nil, domain.ErrTransactionNotFound
 		}
 		return nil, err
 	}
@@ -52,26 +95,26 @@ func (t *transactionService) GetById(id uint) (*domain.Transaction, error) {
 }
 
 // Store
-func (t *transactionService) Store(transactionReq *domain.TransactionStoreRequest) error {
+func (t *transactionService) Store(ctx context.Context, transactionReq *domain.TransactionStoreRequest) (*domain.Transaction, error) {
+	ctx, span := tracing.Start(ctx, "TransactionService.Store")
+	defer span.End()
+
 	var totalPrice int
 	transactionDetails := make([]*domain.TransactionDetail, len(transactionReq.TransactionDetails))
 
 	for i, detail := range transactionReq.TransactionDetails {
 		// get book information
-		book, err := t.bookRepo.GetById(detail.BookId)
+		book, err := t.bookRepo.GetById(ctx, detail.BookId)
 		if err != nil {
-			return err
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, domain.ErrBookNotFound
+			}
+			return nil, err
 		}
 
 		// check stock
 		if book.Stock < detail.Quantity {
-			return errors.New("stock not enough")
-		}
-
-		// update stock
-		book.Stock -= detail.Quantity
-		if err := t.bookRepo.Update(book); err != nil {
-			return err
+			return nil, domain.ErrInsufficientStock
 		}
 
 		// set transactionDetails
@@ -84,23 +127,214 @@ func (t *transactionService) Store(transactionReq *domain.TransactionStoreReques
 		totalPrice += book.Price * detail.Quantity
 	}
 
+	// the discount comes off before the tenders, so they only have to cover what is left
+	var discount int
+	var discountEntry *domain.LoyaltyLedger
+	if transactionReq.RedeemPoints > 0 {
+		discountEntry, discount = t.loyaltySvc.DiscountEntry(transactionReq.RedeemPoints)
+		if discount > totalPrice {
+			return nil, domain.ErrDiscountExceedsTotal
+		}
+		totalPrice -= discount
+	}
+
+	if amount := tendered(transactionReq.Payments, domain.PaymentMethodLoyalty) + discount; amount > 0 {
+		if err := t.loyaltySvc.CheckRedeemable(ctx, transactionReq.CustomerId, amount); err != nil {
+			return nil, err
+		}
+	}
+	if amount := tendered(transactionReq.Payments, domain.PaymentMethodStoreCredit); amount > 0 {
+		if err := t.storeCreditSvc.CheckSpendable(ctx, transactionReq.CustomerId, amount); err != nil {
+			return nil, err
+		}
+	}
+
+	payments, err := t.paymentSvc.Process(ctx, totalPrice, transactionReq.Payments)
+	if err != nil {
+		return nil, err
+	}
+
 	transaction := &domain.Transaction{
 		UserId:             transactionReq.UserId,
 		CustomerId:         transactionReq.CustomerId,
 		TotalPrice:         totalPrice,
+		Discount:           discount,
+		Status:             domain.TransactionStatusPending,
 		TransactionDetails: transactionDetails,
+		Payments:           payments,
+	}
+	applyPayments(transaction, payments, time.Now())
+
+	loyaltyEntries, err := t.loyaltyEntries(ctx, transaction, payments)
+	if err != nil {
+		return nil, errors.Join(err, t.paymentSvc.Refund(ctx, payments))
+	}
+	if discountEntry != nil {
+		loyaltyEntries = append([]*domain.LoyaltyLedger{discountEntry}, loyaltyEntries...)
+	}
+
+	// the sale is only final once fully paid, until then stock is left untouched
+	if err := t.transactionRepo.Store(ctx, transaction, loyaltyEntries); err != nil {
+		return nil, errors.Join(err, t.paymentSvc.Refund(ctx, payments))
+	}
+	metrics.TransactionsCreated.Inc()
+	xlogger.Ctx(ctx).Info().
+		Uint("transaction_id", transaction.ID).
+		Int("total_price", transaction.TotalPrice).
+		Str("status", string(transaction.Status)).
+		Msg("transaction created")
+
+	if transaction.Status == domain.TransactionStatusPaid {
+		t.recordSale(ctx, transactionDetails)
+	}
+
+	return transaction, nil
+}
+
+// Pay records additional tenders against a pending transaction and finalizes it once fully paid
+func (t *transactionService) Pay(ctx context.Context, id uint, paymentReqs []*domain.PaymentStoreRequest) (*domain.Transaction, error) {
+	ctx, span := tracing.Start(ctx, "TransactionService.Pay")
+	defer span.End()
+
+	// payments are only set once the tenders were charged, they are refunded when the transaction can't be saved
+	var payments []*domain.Payment
+	transaction, err := t.transactionRepo.Pay(ctx, id, func(transaction *domain.Transaction) ([]*domain.Payment, []*domain.LoyaltyLedger, error) {
+		if transaction.Status == domain.TransactionStatusPaid {
+			return nil, nil, domain.ErrTransactionAlreadyPaid
+		}
+
+		if amount := tendered(paymentReqs, domain.PaymentMethodLoyalty); amount > 0 {
+			if err := t.loyaltySvc.CheckRedeemable(ctx, transaction.CustomerId, amount); err != nil {
+				return nil, nil, err
+			}
+		}
+		if amount := tendered(paymentReqs, domain.PaymentMethodStoreCredit); amount > 0 {
+			if err := t.storeCreditSvc.CheckSpendable(ctx, transaction.CustomerId, amount); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		charged, err := t.paymentSvc.Process(ctx, transaction.TotalPrice-transaction.PaidAmount, paymentReqs)
+		if err != nil {
+			return nil, nil, err
+		}
+		payments = charged
+
+		applyPayments(transaction, payments, time.Now())
+		loyaltyEntries, err := t.loyaltyEntries(ctx, transaction, payments)
+		return payments, loyaltyEntries, err
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = domain.ErrTransactionNotFound
+		}
+		return nil, errors.Join(err, t.paymentSvc.Refund(ctx, payments))
 	}
-	return t.transactionRepo.Store(transaction)
+
+	if transaction.Status == domain.TransactionStatusPaid {
+		t.recordSale(ctx, transaction.TransactionDetails)
+	}
+
+	return t.GetById(ctx, id)
 }
 
 // Update
-func (t *transactionService) Update(transaction *domain.Transaction) error {
-	return t.transactionRepo.Update(transaction)
+func (t *transactionService) Update(ctx context.Context, transaction *domain.Transaction) error {
+	ctx, span := tracing.Start(ctx, "TransactionService.Update")
+	defer span.End()
+
+	if err := t.transactionRepo.Update(ctx, transaction); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrTransactionNotFound
+		}
+		return err
+	}
+	t.reportSvc.Invalidate(ctx)
+	return nil
+}
+
+// recordSale counts the units sold and warns about the books the sale took the last copies of
+func (t *transactionService) recordSale(ctx context.Context, transactionDetails []*domain.TransactionDetail) {
+	for _, detail := range transactionDetails {
+		metrics.UnitsSold.Add(float64(detail.Quantity))
+
+		book, err := t.bookRepo.GetById(ctx, detail.BookId)
+		if err == nil && book.Stock == 0 {
+			metrics.StockOuts.Inc()
+			xlogger.Ctx(ctx).Warn().Uint("book_id", book.ID).Str("isbn", book.Isbn).Msg("book out of stock")
+		}
+	}
+}
+
+// loyaltyEntries are the points the payments redeem and, once the transaction is paid, the points it earns
+func (t *transactionService) loyaltyEntries(ctx context.Context, transaction *domain.Transaction, payments []*domain.Payment) ([]*domain.LoyaltyLedger, error) {
+	var entries []*domain.LoyaltyLedger
+
+	var redeemed int
+	for _, payment := range payments {
+		if payment.Method == domain.PaymentMethodLoyalty {
+			redeemed += payment.Amount
+		}
+	}
+	if redeemed > 0 {
+		entries = append(entries, t.loyaltySvc.RedeemEntry(redeemed))
+	}
+
+	if transaction.Status != domain.TransactionStatusPaid {
+		return entries, nil
+	}
+
+	earned, err := t.loyaltySvc.EarnEntry(ctx, transaction.CustomerId, transaction.TotalPrice)
+	if err != nil {
+		return nil, err
+	}
+	if earned != nil {
+		entries = append(entries, earned)
+	}
+
+	return entries, nil
+}
+
+// tendered sums the tenders paid with the method
+func tendered(paymentReqs []*domain.PaymentStoreRequest, method domain.PaymentMethod) int {
+	var amount int
+	for _, req := range paymentReqs {
+		if req.Method == method {
+			amount += req.Amount
+		}
+	}
+
+	return amount
+}
+
+// applyPayments adds the tenders to the running totals and marks the transaction paid when covered
+func applyPayments(transaction *domain.Transaction, payments []*domain.Payment, now time.Time) {
+	for _, payment := range payments {
+		transaction.PaidAmount += payment.Amount
+		transaction.ChangeAmount += payment.Change
+	}
+
+	if transaction.PaidAmount >= transaction.TotalPrice {
+		transaction.Status = domain.TransactionStatusPaid
+		transaction.PaidAt = &now
+	}
+}
+
+// Each
+func (t *transactionService) Each(ctx context.Context, filter *domain.Transaction, fn func(transaction *domain.Transaction) error) error {
+	ctx, span := tracing.Start(ctx, "TransactionService.Each")
+	defer span.End()
+
+	return t.transactionRepo.Each(ctx, filter, fn)
 }
 
-func NewTransactionService(transactionRepo domain.TransactionRepository, bookRepo domain.BookRepository) domain.TransactionService {
+func NewTransactionService(transactionRepo domain.TransactionRepository, bookRepo domain.BookRepository, paymentSvc domain.PaymentService, loyaltySvc domain.LoyaltyService, storeCreditSvc domain.StoreCreditService, reportSvc domain.ReportService) domain.TransactionService {
 	return &transactionService{
 		transactionRepo: transactionRepo,
 		bookRepo:        bookRepo,
+		paymentSvc:      paymentSvc,
+		loyaltySvc:      loyaltySvc,
+		storeCreditSvc:  storeCreditSvc,
+		reportSvc:       reportSvc,
 	}
 }
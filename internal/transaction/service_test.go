@@ -0,0 +1,63 @@
+package transaction
+
+import (
+	"book-store/internal/config"
+	"book-store/internal/domain"
+	"book-store/internal/report"
+	"book-store/internal/testdb"
+	"context"
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestTransactionServiceNotFound(t *testing.T) {
+	db := testdb.Open(t, &domain.Transaction{})
+	svc := &transactionService{
+		transactionRepo: NewMysqlTransactionRepository(db),
+		reportSvc:       report.NewReportService(report.NewMysqlReportRepository(db), config.Report{}),
+	}
+	ctx := context.Background()
+
+	deleted := &domain.Transaction{UserId: 1, CustomerId: 1, TotalPrice: 100, Status: domain.TransactionStatusPending}
+	if err := db.Create(deleted).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Delete(deleted).Error; err != nil {
+		t.Fatal(err)
+	}
+	const missing = 404
+
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{name: "update missing", call: func() error {
+			return svc.Update(ctx, &domain.Transaction{Model: gorm.Model{ID: missing}, CustomerId: 2})
+		}},
+		{name: "update deleted", call: func() error {
+			return svc.Update(ctx, &domain.Transaction{Model: gorm.Model{ID: deleted.ID}, CustomerId: 2})
+		}},
+		{name: "delete missing", call: func() error { return svc.Delete(ctx, missing) }},
+		{name: "delete deleted", call: func() error { return svc.Delete(ctx, deleted.ID) }},
+		{name: "restore missing", call: func() error {
+			_, err := svc.Restore(ctx, missing)
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			if !errors.Is(err, domain.ErrTransactionNotFound) {
+				t.Fatalf("err = %v, want %v", err, domain.ErrTransactionNotFound)
+			}
+
+			var appErr *domain.AppError
+			if !errors.As(err, &appErr) || appErr.Status() != 404 {
+				t.Errorf("err = %v, want a 404", err)
+			}
+		})
+	}
+}
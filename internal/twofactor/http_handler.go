@@ -0,0 +1,206 @@
+package twofactor
+
+import (
+	"book-store/internal/domain"
+	"book-store/internal/middleware/jwt"
+	"book-store/internal/middleware/validation"
+	"book-store/internal/utilities"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type HttpTwoFactorHandler struct {
+	twoFactorSvc   domain.TwoFactorService
+	authMiddleware jwt.AuthMiddleware
+}
+
+// NewHttpHandler serves the two-factor authentication of the user of the token under /auth/me, users who have to
+// set it up can reach it
+func NewHttpHandler(r fiber.Router, twoFactorSvc domain.TwoFactorService, authMiddleware jwt.AuthMiddleware) {
+	handler := &HttpTwoFactorHandler{twoFactorSvc: twoFactorSvc, authMiddleware: authMiddleware}
+
+	r.Post("/2fa", authMiddleware.RequireUser(), handler.Enroll)
+	r.Post("/2fa/confirm", authMiddleware.RequireUser(), validation.New[domain.TwoFactorCodeRequest](), handler.Confirm)
+	r.Delete("/2fa", authMiddleware.RequireUser(), validation.New[domain.TwoFactorCodeRequest](), handler.Disable)
+	r.Post("/2fa/recovery-codes", authMiddleware.RequireUser(), validation.New[domain.TwoFactorCodeRequest](), handler.RegenerateRecoveryCodes)
+}
+
+// NewResetHttpHandler serves the reset of two-factor authentication of users under /users
+func NewResetHttpHandler(r fiber.Router, twoFactorSvc domain.TwoFactorService, authMiddleware jwt.AuthMiddleware) {
+	handler := &HttpTwoFactorHandler{twoFactorSvc: twoFactorSvc, authMiddleware: authMiddleware}
+
+	r.Delete("/:id/2fa", authMiddleware.RequireRole("admin"), handler.Reset)
+}
+
+// Enroll used to start setting up two-factor authentication
+//
+//	@Summary		Enroll two-factor authentication
+//	@Description	Generate a TOTP secret and its otpauth provisioning uri to show as a QR code to authenticator apps. Enrolling again replaces a secret not confirmed yet
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Success		201	{object}	domain.Success	"two-factor enrollment"
+//	@Failure		401	{object}	domain.Error	"Unauthorized"
+//	@Failure		409	{object}	domain.Error	"Already Enabled"
+//	@Failure		500	{object}	domain.Error	"Internal Server Error"
+//	@Router			/auth/me/2fa [post]
+//
+// @Security Bearer
+func (h *HttpTwoFactorHandler) Enroll(c *fiber.Ctx) error {
+	userId, ok := domain.UserIdFrom(c.UserContext())
+	if !ok {
+		return domain.ErrUnauthorized
+	}
+
+	enrollment, err := h.twoFactorSvc.Enroll(c.UserContext(), userId)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(domain.Success{
+		Code:    fiber.StatusCreated,
+		Message: "success",
+		Data:    enrollment,
+	})
+}
+
+// Confirm used to enable two-factor authentication
+//
+//	@Summary		Confirm two-factor authentication
+//	@Description	Enable two-factor authentication with the first code of the authenticator app. Answers with the recovery codes, shown only once, and a new token
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			code	body		domain.TwoFactorCodeRequest	true	"code of the authenticator app"
+//	@Success		200		{object}	domain.Success				"recovery codes and token"
+//	@Failure		400		{object}	domain.Error				"Bad Request"
+//	@Failure		401		{object}	domain.Error				"Unauthorized"
+//	@Failure		409		{object}	domain.Error				"Not Enrolled or Already Enabled"
+//	@Failure		500		{object}	domain.Error				"Internal Server Error"
+//	@Router			/auth/me/2fa/confirm [post]
+//
+// @Security Bearer
+func (h *HttpTwoFactorHandler) Confirm(c *fiber.Ctx) error {
+	userId, ok := domain.UserIdFrom(c.UserContext())
+	if !ok {
+		return domain.ErrUnauthorized
+	}
+
+	codeReq := utilities.ExtractStructFromValidator[domain.TwoFactorCodeRequest](c)
+
+	confirmation, err := h.twoFactorSvc.Confirm(c.UserContext(), userId, codeReq)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(domain.Success{
+		Code:    fiber.StatusOK,
+		Message: "two-factor authentication enabled successfully",
+		Data:    confirmation,
+	})
+}
+
+// Disable used to turn off two-factor authentication
+//
+//	@Summary		Disable two-factor authentication
+//	@Description	Disable two-factor authentication with a code or a recovery code, refused to roles requiring it. Wrong codes count as failed logins
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			code	body		domain.TwoFactorCodeRequest	true	"code or recovery code"
+//	@Success		200		{object}	domain.Success				"two-factor authentication disabled"
+//	@Failure		400		{object}	domain.Error				"Bad Request"
+//	@Failure		401		{object}	domain.Error				"Unauthorized"
+//	@Failure		403		{object}	domain.Error				"Required for Role"
+//	@Failure		409		{object}	domain.Error				"Not Enabled"
+//	@Failure		429		{object}	domain.Error				"Too Many Failed Logins"
+//	@Failure		500		{object}	domain.Error				"Internal Server Error"
+//	@Router			/auth/me/2fa [delete]
+//
+// @Security Bearer
+func (h *HttpTwoFactorHandler) Disable(c *fiber.Ctx) error {
+	userId, ok := domain.UserIdFrom(c.UserContext())
+	if !ok {
+		return domain.ErrUnauthorized
+	}
+
+	codeReq := utilities.ExtractStructFromValidator[domain.TwoFactorCodeRequest](c)
+
+	if err := h.twoFactorSvc.Disable(c.UserContext(), userId, codeReq); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(domain.Success{
+		Code:    fiber.StatusOK,
+		Message: "two-factor authentication disabled successfully",
+	})
+}
+
+// RegenerateRecoveryCodes used to replace the recovery codes
+//
+//	@Summary		Regenerate recovery codes
+//	@Description	Replace every recovery code, used or not, with new ones shown only once. Wrong codes count as failed logins
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			code	body		domain.TwoFactorCodeRequest	true	"code or recovery code"
+//	@Success		200		{object}	domain.Success				"recovery codes"
+//	@Failure		400		{object}	domain.Error				"Bad Request"
+//	@Failure		401		{object}	domain.Error				"Unauthorized"
+//	@Failure		409		{object}	domain.Error				"Not Enabled"
+//	@Failure		429		{object}	domain.Error				"Too Many Failed Logins"
+//	@Failure		500		{object}	domain.Error				"Internal Server Error"
+//	@Router			/auth/me/2fa/recovery-codes [post]
+//
+// @Security Bearer
+func (h *HttpTwoFactorHandler) RegenerateRecoveryCodes(c *fiber.Ctx) error {
+	userId, ok := domain.UserIdFrom(c.UserContext())
+	if !ok {
+		return domain.ErrUnauthorized
+	}
+
+	codeReq := utilities.ExtractStructFromValidator[domain.TwoFactorCodeRequest](c)
+
+	codes, err := h.twoFactorSvc.RegenerateRecoveryCodes(c.UserContext(), userId, codeReq)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(domain.Success{
+		Code:    fiber.StatusOK,
+		Message: "success",
+		Data:    codes,
+	})
+}
+
+// Reset used to remove two-factor authentication of a user
+//
+//	@Summary		Reset two-factor authentication of user
+//	@Description	Remove two-factor authentication of a user who lost their authenticator app and recovery codes. Roles requiring it set it up again on their next login
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		int				true	"user ID"
+//	@Success		200	{object}	domain.Success	"two-factor authentication reset"
+//	@Failure		400	{object}	domain.Error	"Bad Request"
+//	@Failure		404	{object}	domain.Error	"Not Found"
+//	@Failure		409	{object}	domain.Error	"Not Enabled"
+//	@Failure		500	{object}	domain.Error	"Internal Server Error"
+//	@Router			/users/{id}/2fa [delete]
+//
+// @Security Bearer
+func (h *HttpTwoFactorHandler) Reset(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return domain.NewValidationError("invalid user id")
+	}
+
+	if err := h.twoFactorSvc.Reset(c.UserContext(), uint(id)); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(domain.Success{
+		Code:    fiber.StatusOK,
+		Message: "two-factor authentication reset successfully",
+	})
+}
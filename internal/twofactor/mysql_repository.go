@@ -0,0 +1,158 @@
+package twofactor
+
+import (
+	"book-store/internal/domain"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type mysqlTwoFactorRepository struct {
+	db *gorm.DB
+}
+
+// Get
+func (m *mysqlTwoFactorRepository) Get(ctx context.Context, userId uint) (*domain.TwoFactor, error) {
+	var twoFactor *domain.TwoFactor
+
+	if err := m.db.WithContext(ctx).Where("user_id = ?", userId).First(&twoFactor).Error; err != nil {
+		return nil, err
+	}
+
+	return twoFactor, nil
+}
+
+// Enroll
+func (m *mysqlTwoFactorRepository) Enroll(ctx context.Context, twoFactor *domain.TwoFactor) error {
+	return m.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"secret", "confirmed_at", "last_used_step", "updated_at"}),
+	}).Create(twoFactor).Error
+}
+
+// Confirm
+func (m *mysqlTwoFactorRepository) Confirm(ctx context.Context, userId uint, step int64, codeHashes []string) error {
+	return m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&domain.TwoFactor{}).
+			Where("user_id = ? AND confirmed_at IS NULL", userId).
+			Updates(map[string]any{"confirmed_at": time.Now(), "last_used_step": step})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		if err := tx.Model(&domain.User{Model: gorm.Model{ID: userId}}).Update("two_factor_enabled", true).Error; err != nil {
+			return err
+		}
+
+		return replaceRecoveryCodes(tx, userId, codeHashes)
+	})
+}
+
+// Disable
+func (m *mysqlTwoFactorRepository) Disable(ctx context.Context, userId uint) error {
+	return m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("user_id = ?", userId).Delete(&domain.TwoFactor{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		if err := tx.Where("user_id = ?", userId).Delete(&domain.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&domain.User{Model: gorm.Model{ID: userId}}).Update("two_factor_enabled", false).Error
+	})
+}
+
+// UseStep moves the last used step forward in one statement so a code can't be accepted twice at once
+func (m *mysqlTwoFactorRepository) UseStep(ctx context.Context, userId uint, step int64) error {
+	result := m.db.WithContext(ctx).Model(&domain.TwoFactor{}).
+		Where("user_id = ? AND last_used_step < ?", userId, step).
+		Update("last_used_step", step)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// UseRecoveryCode
+func (m *mysqlTwoFactorRepository) UseRecoveryCode(ctx context.Context, userId uint, codeHash string) error {
+	result := m.db.WithContext(ctx).Model(&domain.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userId, codeHash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// ReplaceRecoveryCodes
+func (m *mysqlTwoFactorRepository) ReplaceRecoveryCodes(ctx context.Context, userId uint, codeHashes []string) error {
+	return m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return replaceRecoveryCodes(tx, userId, codeHashes)
+	})
+}
+
+// StoreChallenge
+func (m *mysqlTwoFactorRepository) StoreChallenge(ctx context.Context, challenge *domain.TwoFactorChallenge) error {
+	return m.db.WithContext(ctx).Create(challenge).Error
+}
+
+// AttemptChallenge locks the challenge so concurrent attempts are all counted
+func (m *mysqlTwoFactorRepository) AttemptChallenge(ctx context.Context, tokenHash string, now time.Time, maxAttempts int) (*domain.TwoFactorChallenge, error) {
+	var challenge domain.TwoFactorChallenge
+
+	err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ? AND used_at IS NULL AND expires_at > ? AND attempts < ?", tokenHash, now, maxAttempts).
+			First(&challenge).Error; err != nil {
+			return err
+		}
+
+		challenge.Attempts++
+		return tx.Model(&challenge).Update("attempts", challenge.Attempts).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &challenge, nil
+}
+
+// CompleteChallenge
+func (m *mysqlTwoFactorRepository) CompleteChallenge(ctx context.Context, id uint, now time.Time) error {
+	return m.db.WithContext(ctx).Model(&domain.TwoFactorChallenge{}).Where("id = ?", id).Update("used_at", now).Error
+}
+
+// replaceRecoveryCodes drops the codes of the user, used or not, before storing the new ones
+func replaceRecoveryCodes(tx *gorm.DB, userId uint, codeHashes []string) error {
+	if err := tx.Where("user_id = ?", userId).Delete(&domain.RecoveryCode{}).Error; err != nil {
+		return err
+	}
+
+	codes := make([]*domain.RecoveryCode, 0, len(codeHashes))
+	for _, codeHash := range codeHashes {
+		codes = append(codes, &domain.RecoveryCode{UserId: userId, CodeHash: codeHash})
+	}
+	return tx.Create(&codes).Error
+}
+
+func NewMysqlTwoFactorRepository(db *gorm.DB) domain.TwoFactorRepository {
+	return &mysqlTwoFactorRepository{db: db}
+}
@@ -0,0 +1,332 @@
+package twofactor
+
+import (
+	"book-store/internal/config"
+	"book-store/internal/domain"
+	"book-store/internal/metrics"
+	"book-store/internal/tracing"
+	"book-store/internal/utilities"
+	"book-store/pkg/xlogger"
+	"context"
+	"crypto/rand"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// recoveryCodes is how many recovery codes a user gets at once
+const recoveryCodes = 10
+
+type twoFactorService struct {
+	twoFactorRepo domain.TwoFactorRepository
+	userRepo      domain.UserRepository
+	throttleRepo  domain.LoginThrottleRepository
+	jwtService    utilities.JwtTokenService
+	secretBox     *utilities.SecretBox
+	cfg           config.TwoFactor
+	loginCfg      config.Login
+}
+
+// Enroll
+func (t *twoFactorService) Enroll(ctx context.Context, userId uint) (*domain.TwoFactorEnrollment, error) {
+	ctx, span := tracing.Start(ctx, "TwoFactorService.Enroll")
+	defer span.End()
+
+	user, err := t.user(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactorEnabled {
+		return nil, domain.ErrTwoFactorEnabled
+	}
+
+	secret, err := newSecret()
+	if err != nil {
+		return nil, err
+	}
+	twoFactor := &domain.TwoFactor{UserId: userId}
+	if err := SealSecret(t.secretBox, twoFactor, secret); err != nil {
+		return nil, err
+	}
+	if err := t.twoFactorRepo.Enroll(ctx, twoFactor); err != nil {
+		return nil, err
+	}
+
+	xlogger.Ctx(ctx).Info().Uint("user_id", userId).Msg("two-factor enrollment started")
+	return &domain.TwoFactorEnrollment{
+		Secret:          secret,
+		ProvisioningUri: provisioningUri(t.cfg.Issuer, user.Email, secret),
+	}, nil
+}
+
+// Confirm enables two-factor authentication once the authenticator app shows a valid code
+func (t *twoFactorService) Confirm(ctx context.Context, userId uint, req *domain.TwoFactorCodeRequest) (*domain.TwoFactorConfirmation, error) {
+	ctx, span := tracing.Start(ctx, "TwoFactorService.Confirm")
+	defer span.End()
+
+	user, err := t.user(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactorEnabled {
+		return nil, domain.ErrTwoFactorEnabled
+	}
+
+	twoFactor, err := t.twoFactorRepo.Get(ctx, userId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrTwoFactorNotEnrolled
+		}
+		return nil, err
+	}
+	secret, err := openSecret(t.secretBox, twoFactor)
+	if err != nil {
+		return nil, err
+	}
+
+	step, ok := match(secret, normalize(req.Code), time.Now())
+	if !ok {
+		return nil, domain.ErrInvalidTwoFactorCode
+	}
+
+	codes, codeHashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := t.twoFactorRepo.Confirm(ctx, userId, step, codeHashes); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrTwoFactorNotEnrolled
+		}
+		return nil, err
+	}
+
+	user.TwoFactorEnabled = true
+	token, err := t.jwtService.GenerateToken(user)
+	if err != nil {
+		return nil, err
+	}
+
+	xlogger.Ctx(ctx).Info().Uint("user_id", userId).Msg("two-factor authentication enabled")
+	return &domain.TwoFactorConfirmation{RecoveryCodes: codes, Token: token.Token}, nil
+}
+
+// Disable is refused to roles requiring two-factor authentication
+func (t *twoFactorService) Disable(ctx context.Context, userId uint, req *domain.TwoFactorCodeRequest) error {
+	ctx, span := tracing.Start(ctx, "TwoFactorService.Disable")
+	defer span.End()
+
+	user, err := t.user(ctx, userId)
+	if err != nil {
+		return err
+	}
+	if user.Role != nil && t.cfg.Requires(user.Role.Name) {
+		return domain.ErrTwoFactorRequired
+	}
+
+	if err := t.verifyCode(ctx, user, req.Code); err != nil {
+		return err
+	}
+
+	if err := t.twoFactorRepo.Disable(ctx, userId); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrTwoFactorNotEnabled
+		}
+		return err
+	}
+
+	xlogger.Ctx(ctx).Info().Uint("user_id", userId).Msg("two-factor authentication disabled")
+	return nil
+}
+
+// Reset removes two-factor authentication of a user who lost their authenticator app and recovery codes, roles
+// requiring it have to set it up again on their next login
+func (t *twoFactorService) Reset(ctx context.Context, userId uint) error {
+	ctx, span := tracing.Start(ctx, "TwoFactorService.Reset")
+	defer span.End()
+
+	if _, err := t.user(ctx, userId); err != nil {
+		return err
+	}
+
+	if err := t.twoFactorRepo.Disable(ctx, userId); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrTwoFactorNotEnabled
+		}
+		return err
+	}
+
+	xlogger.Ctx(ctx).Info().Uint("user_id", userId).Msg("two-factor authentication reset")
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces every recovery code of the user, used or not
+func (t *twoFactorService) RegenerateRecoveryCodes(ctx context.Context, userId uint, req *domain.TwoFactorCodeRequest) ([]string, error) {
+	ctx, span := tracing.Start(ctx, "TwoFactorService.RegenerateRecoveryCodes")
+	defer span.End()
+
+	user, err := t.user(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.verifyCode(ctx, user, req.Code); err != nil {
+		return nil, err
+	}
+
+	codes, codeHashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := t.twoFactorRepo.ReplaceRecoveryCodes(ctx, userId, codeHashes); err != nil {
+		return nil, err
+	}
+
+	xlogger.Ctx(ctx).Info().Uint("user_id", userId).Msg("recovery codes regenerated")
+	return codes, nil
+}
+
+// Verify takes codes of the authenticator app once, anything else is tried as a recovery code
+func (t *twoFactorService) Verify(ctx context.Context, userId uint, code string) error {
+	ctx, span := tracing.Start(ctx, "TwoFactorService.Verify")
+	defer span.End()
+
+	twoFactor, err := t.twoFactorRepo.Get(ctx, userId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrTwoFactorNotEnabled
+		}
+		return err
+	}
+	if twoFactor.ConfirmedAt == nil {
+		return domain.ErrTwoFactorNotEnabled
+	}
+	secret, err := openSecret(t.secretBox, twoFactor)
+	if err != nil {
+		return err
+	}
+
+	code = normalize(code)
+	if step, ok := match(secret, code, time.Now()); ok {
+		if err := t.twoFactorRepo.UseStep(ctx, userId, step); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return domain.ErrInvalidTwoFactorCode
+			}
+			return err
+		}
+		return nil
+	}
+
+	if err := t.twoFactorRepo.UseRecoveryCode(ctx, userId, utilities.HashToken(code)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrInvalidTwoFactorCode
+		}
+		return err
+	}
+
+	xlogger.Ctx(ctx).Warn().Uint("user_id", userId).Msg("recovery code used")
+	return nil
+}
+
+// verifyCode counts wrong codes as failed logins of the email, so a stolen token can't be used to guess codes
+func (t *twoFactorService) verifyCode(ctx context.Context, user *domain.User, code string) error {
+	key := domain.AccountThrottleKey(user.Email)
+	throttle, err := t.throttleRepo.Get(ctx, key)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if throttle != nil && throttle.Locked(time.Now()) {
+		return domain.ErrLoginLocked
+	}
+
+	err = t.Verify(ctx, user.ID, code)
+	if !errors.Is(err, domain.ErrInvalidTwoFactorCode) {
+		return err
+	}
+
+	metrics.FailedLogins.WithLabelValues("invalid_two_factor_code").Inc()
+	xlogger.Ctx(ctx).Warn().Uint("user_id", user.ID).Str("reason", "invalid_two_factor_code").Msg("two-factor code check failed")
+	throttle, updateErr := t.throttleRepo.Update(ctx, key, func(throttle *domain.LoginThrottle) {
+		throttle.Fail(time.Now(), t.loginCfg.FailureWindow, t.loginCfg.MaxFailures, t.loginCfg.Lockout)
+	})
+	if updateErr != nil {
+		return updateErr
+	}
+	if throttle.Locked(time.Now()) {
+		xlogger.Ctx(ctx).Warn().Str("key", key).Time("locked_until", *throttle.LockedUntil).Msg("login locked out")
+	}
+	return err
+}
+
+// user
+func (t *twoFactorService) user(ctx context.Context, userId uint) (*domain.User, error) {
+	user, err := t.userRepo.GetById(ctx, userId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// newRecoveryCodes are shown as xxxxx-xxxxx, only their hashes are stored
+func newRecoveryCodes() ([]string, []string, error) {
+	// crockford base32 leaves out letters mistaken for digits, 32 symbols keep every one as likely
+	const alphabet = "0123456789abcdefghjkmnpqrstvwxyz"
+
+	codes, codeHashes := make([]string, 0, recoveryCodes), make([]string, 0, recoveryCodes)
+	random := make([]byte, 10)
+	for i := 0; i < recoveryCodes; i++ {
+		if _, err := rand.Read(random); err != nil {
+			return nil, nil, err
+		}
+
+		code := make([]byte, len(random))
+		for j, b := range random {
+			code[j] = alphabet[int(b)%len(alphabet)]
+		}
+		codes = append(codes, string(code[:5])+"-"+string(code[5:]))
+		codeHashes = append(codeHashes, utilities.HashToken(string(code)))
+	}
+	return codes, codeHashes, nil
+}
+
+// SealSecret encrypts the key rather than its base32 encoding, sealed it still fits the column of the plaintext secret
+func SealSecret(secretBox *utilities.SecretBox, twoFactor *domain.TwoFactor, secret string) error {
+	key, err := encoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return err
+	}
+
+	twoFactor.Secret, err = secretBox.Seal(key, twoFactor.SecretContext())
+	return err
+}
+
+// openSecret is the base32 secret SealSecret encrypted
+func openSecret(secretBox *utilities.SecretBox, twoFactor *domain.TwoFactor) (string, error) {
+	key, err := secretBox.Open(twoFactor.Secret, twoFactor.SecretContext())
+	if err != nil {
+		return "", err
+	}
+
+	return encoding.EncodeToString(key), nil
+}
+
+// normalize lets codes be typed with spaces and recovery codes with or without dashes, in any case
+func normalize(code string) string {
+	return strings.ToLower(strings.NewReplacer(" ", "", "-", "").Replace(code))
+}
+
+func NewTwoFactorService(twoFactorRepo domain.TwoFactorRepository, userRepo domain.UserRepository, throttleRepo domain.LoginThrottleRepository, jwtService utilities.JwtTokenService, secretBox *utilities.SecretBox, cfg config.TwoFactor, loginCfg config.Login) domain.TwoFactorService {
+	return &twoFactorService{
+		twoFactorRepo: twoFactorRepo,
+		userRepo:      userRepo,
+		throttleRepo:  throttleRepo,
+		jwtService:    jwtService,
+		secretBox:     secretBox,
+		cfg:           cfg,
+		loginCfg:      loginCfg,
+	}
+}
@@ -0,0 +1,126 @@
+package twofactor
+
+import (
+	"book-store/internal/config"
+	"book-store/internal/domain"
+	"book-store/internal/utilities"
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type twoFactors struct {
+	domain.TwoFactorRepository
+	twoFactor *domain.TwoFactor
+}
+
+func (t twoFactors) Get(context.Context, uint) (*domain.TwoFactor, error) {
+	if t.twoFactor == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return t.twoFactor, nil
+}
+
+func (t twoFactors) UseStep(context.Context, uint, int64) error {
+	return nil
+}
+
+func (t twoFactors) UseRecoveryCode(context.Context, uint, string) error {
+	return gorm.ErrRecordNotFound
+}
+
+type throttles map[string]*domain.LoginThrottle
+
+func (t throttles) Get(_ context.Context, key string) (*domain.LoginThrottle, error) {
+	throttle, ok := t[key]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return throttle, nil
+}
+
+func (t throttles) Update(_ context.Context, key string, fn func(throttle *domain.LoginThrottle)) (*domain.LoginThrottle, error) {
+	throttle, ok := t[key]
+	if !ok {
+		throttle = &domain.LoginThrottle{Key: key}
+		t[key] = throttle
+	}
+	fn(throttle)
+	return throttle, nil
+}
+
+func (t throttles) Delete(_ context.Context, key string) error {
+	delete(t, key)
+	return nil
+}
+
+func (t throttles) Purge(context.Context, time.Time, time.Time) (int64, error) {
+	return 0, nil
+}
+
+func TestVerifyCode(t *testing.T) {
+	secret, err := newSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, _ := encoding.DecodeString(secret)
+	valid := generate(key, step(time.Now()))
+	wrong := strings.Repeat("0", digits)
+	if wrong == valid {
+		wrong = strings.Repeat("1", digits)
+	}
+
+	secretBox, err := utilities.NewSecretBox(base64.StdEncoding.EncodeToString(make([]byte, utilities.SecretBoxKeySize)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	confirmedAt := time.Now()
+	enabled := &domain.TwoFactor{UserId: 1, ConfirmedAt: &confirmedAt}
+	if err := SealSecret(secretBox, enabled, secret); err != nil {
+		t.Fatal(err)
+	}
+	user := &domain.User{Model: gorm.Model{ID: 1}, Email: "Jane@Mail.com"}
+	cfg := config.Login{MaxFailures: 3, FailureWindow: time.Minute, Lockout: time.Minute}
+
+	tests := []struct {
+		name         string
+		twoFactor    *domain.TwoFactor
+		codes        []string
+		wantErr      error
+		wantFailures int
+	}{
+		{name: "valid code", twoFactor: enabled, codes: []string{valid}},
+		{name: "wrong code counts as a failed login", twoFactor: enabled, codes: []string{wrong}, wantErr: domain.ErrInvalidTwoFactorCode, wantFailures: 1},
+		{name: "valid code after wrong ones", twoFactor: enabled, codes: []string{wrong, wrong, valid}, wantFailures: 2},
+		{name: "locked out after as many wrong codes as failed logins", twoFactor: enabled, codes: []string{wrong, wrong, wrong, valid}, wantErr: domain.ErrLoginLocked, wantFailures: 3},
+		{name: "not enabled doesn't count", codes: []string{wrong}, wantErr: domain.ErrTwoFactorNotEnabled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			throttleRepo := throttles{}
+			svc := &twoFactorService{twoFactorRepo: twoFactors{twoFactor: tt.twoFactor}, throttleRepo: throttleRepo, secretBox: secretBox, loginCfg: cfg}
+
+			var err error
+			for _, code := range tt.codes {
+				err = svc.verifyCode(context.Background(), user, code)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("verifyCode() = %v, want %v", err, tt.wantErr)
+			}
+
+			var failures int
+			if throttle, ok := throttleRepo["account:jane@mail.com"]; ok {
+				failures = throttle.Failures
+			}
+			if failures != tt.wantFailures {
+				t.Errorf("failures = %d, want %d", failures, tt.wantFailures)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package twofactor
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TOTP parameters of RFC 6238 every authenticator app supports
+const (
+	period = 30 * time.Second
+	digits = 6
+	// skew accepts codes of as many steps before and after the current one, for clocks running apart
+	skew = 1
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// newSecret is 160 bits as recommended for HMAC-SHA1 by RFC 4226
+func newSecret() (string, error) {
+	secret := make([]byte, 20)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return encoding.EncodeToString(secret), nil
+}
+
+// provisioningUri is the otpauth uri of the key uri format authenticator apps read from QR codes
+func provisioningUri(issuer string, account string, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(digits))
+	query.Set("period", fmt.Sprint(int(period.Seconds())))
+
+	label := url.PathEscape(issuer) + ":" + url.PathEscape(account)
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// step is the time step of t
+func step(t time.Time) int64 {
+	return t.Unix() / int64(period.Seconds())
+}
+
+// match is the step within the skew the code is for, false when it isn't a code of the secret
+func match(secret string, code string, now time.Time) (int64, bool) {
+	key, err := encoding.DecodeString(strings.ToUpper(secret))
+	if err != nil || len(code) != digits {
+		return 0, false
+	}
+
+	current := step(now)
+	for s := current - skew; s <= current+skew; s++ {
+		if hmac.Equal([]byte(generate(key, s)), []byte(code)) {
+			return s, true
+		}
+	}
+	return 0, false
+}
+
+// generate is the HOTP (RFC 4226) of the step
+func generate(key []byte, step int64) string {
+	counter := make([]byte, 8)
+	binary.BigEndian.PutUint64(counter, uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	modulo := uint32(1)
+	for i := 0; i < digits; i++ {
+		modulo *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, value%modulo)
+}
@@ -0,0 +1,76 @@
+package twofactor
+
+import (
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA1 seed of the RFC 6238 test vectors, "12345678901234567890" in base32
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestGenerate(t *testing.T) {
+	key, err := encoding.DecodeString(rfcSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the last 6 digits of the 8 digit codes of RFC 6238 appendix B
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1111111111, want: "050471"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+		{unix: 20000000000, want: "353130"},
+	}
+
+	for _, tt := range tests {
+		t.Run(time.Unix(tt.unix, 0).UTC().Format(time.RFC3339), func(t *testing.T) {
+			if got := generate(key, step(time.Unix(tt.unix, 0))); got != tt.want {
+				t.Errorf("generate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	key, err := encoding.DecodeString(rfcSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1234567890, 0)
+	current := step(now)
+	code := func(step int64) string {
+		return generate(key, step)
+	}
+
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		wantStep int64
+		wantOk   bool
+	}{
+		{name: "current step", secret: rfcSecret, code: code(current), wantStep: current, wantOk: true},
+		{name: "step before", secret: rfcSecret, code: code(current - 1), wantStep: current - 1, wantOk: true},
+		{name: "step after", secret: rfcSecret, code: code(current + 1), wantStep: current + 1, wantOk: true},
+		{name: "two steps before", secret: rfcSecret, code: code(current - 2)},
+		{name: "two steps after", secret: rfcSecret, code: code(current + 2)},
+		{name: "lower case secret", secret: "gezdgnbvgy3tqojqgezdgnbvgy3tqojq", code: code(current), wantStep: current, wantOk: true},
+		{name: "too short", secret: rfcSecret, code: code(current)[1:]},
+		{name: "too long", secret: rfcSecret, code: code(current) + "0"},
+		{name: "malformed secret", secret: "not base32!", code: code(current)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotStep, gotOk := match(tt.secret, tt.code, now)
+			if gotOk != tt.wantOk || gotStep != tt.wantStep {
+				t.Errorf("match() = %d, %v, want %d, %v", gotStep, gotOk, tt.wantStep, tt.wantOk)
+			}
+		})
+	}
+}
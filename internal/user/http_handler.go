@@ -1,9 +1,9 @@
 package user
 
 import (
-	"errors"
 	"book-store/internal/domain"
 	"book-store/internal/middleware/jwt"
+	"book-store/internal/middleware/softdelete"
 	"book-store/internal/middleware/validation"
 	"book-store/internal/utilities"
 	"strconv"
@@ -23,11 +23,12 @@ func NewHttpHandler(r fiber.Router, userSvc domain.UserService, authMiddleware j
 		authMiddleware: authMiddleware,
 	}
 
-	r.Get("/", handler.Fetch)
-	r.Get("/:id", handler.GetById)
+	r.Get("/", softdelete.New(authMiddleware), handler.Fetch)
+	r.Get("/:id", softdelete.New(authMiddleware), handler.GetById)
 	r.Post("/", authMiddleware.RequireRole("admin"), validation.New[domain.UserStoreRequest](), handler.Store)
 	r.Put("/:id", authMiddleware.RequireRole("admin"), validation.New[domain.UserUpdateRequest](), handler.Update)
 	r.Delete("/:id", authMiddleware.RequireRole("admin"), handler.Delete)
+	r.Post("/:id/restore", authMiddleware.RequireRole("admin"), handler.Restore)
 }
 
 // Fetch used to get list of user
@@ -40,6 +41,7 @@ func NewHttpHandler(r fiber.Router, userSvc domain.UserService, authMiddleware j
 //	@Param			page	query		int				false	"Page number (default 1)"
 //	@Param			size	query		int				false	"Size of page (default 10)"
 //	@Param			q		query		string			false	"Search query"
+//	@Param			include_deleted	query		bool			false	"Include soft-deleted records, admin only"
 //	@Header			200		{string}	X-Cursor		"Next page"
 //	@Header			200		{string}	X-Total-Count	"Total item"
 //	@Header			200		{string}	X-Max-Page		"Max page"
@@ -50,40 +52,25 @@ func NewHttpHandler(r fiber.Router, userSvc domain.UserService, authMiddleware j
 func (h *HttpUserHandler) Fetch(c *fiber.Ctx) error {
 	page, size, query := c.QueryInt("page", 1), c.QueryInt("size", 10), c.Query("q")
 	if page <= 0 {
-		return c.Status(fiber.StatusBadRequest).JSON(domain.Error{
-			Code:    fiber.StatusBadRequest,
-			Message: "page must be a positive integer",
-		})
+		return domain.NewValidationError("page must be a positive integer")
 	}
 	if size <= 0 {
-		return c.Status(fiber.StatusBadRequest).JSON(domain.Error{
-			Code:    fiber.StatusBadRequest,
-			Message: "size must be a positive integer",
-		})
+		return domain.NewValidationError("size must be a positive integer")
 	}
 
 	filter := &domain.User{Name: query}
-	users, nextPage, err := h.userSvc.Fetch(page, size, filter)
+	users, nextPage, err := h.userSvc.Fetch(c.UserContext(), page, size, filter)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(domain.Error{
-			Code:    fiber.StatusInternalServerError,
-			Message: err.Error(),
-		})
+		return err
 	}
 
 	if users == nil {
-		return c.Status(fiber.StatusNotFound).JSON(domain.Error{
-			Code:    fiber.StatusNotFound,
-			Message: "users not found",
-		})
+		return domain.NewNotFoundError("users")
 	}
 
-	totalItem, err := h.userSvc.Count(filter)
+	totalItem, err := h.userSvc.Count(c.UserContext(), filter)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(domain.Error{
-			Code:    fiber.StatusInternalServerError,
-			Message: err.Error(),
-		})
+		return err
 	}
 
 	maxPage := int(totalItem) / size
@@ -109,6 +96,7 @@ func (h *HttpUserHandler) Fetch(c *fiber.Ctx) error {
 //	@Accept			json
 //	@Produce		json
 //	@Param			id	path		int				true	"user ID"
+//	@Param			include_deleted	query		bool			false	"Include soft-deleted records, admin only"
 //	@Success		200	{object}	domain.Success	"user detail"
 //	@Failure		400	{object}	domain.Error	"Bad Request"
 //	@Failure		404	{object}	domain.Error	"Not Found"
@@ -117,24 +105,12 @@ func (h *HttpUserHandler) Fetch(c *fiber.Ctx) error {
 func (h *HttpUserHandler) GetById(c *fiber.Ctx) error {
 	id, err := c.ParamsInt("id")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(domain.Error{
-			Code:    fiber.StatusBadRequest,
-			Message: "invalid user id",
-		})
+		return domain.NewValidationError("invalid user id")
 	}
 
-	user, err := h.userSvc.GetById(uint(id))
+	user, err := h.userSvc.GetById(c.UserContext(), uint(id))
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return c.Status(fiber.StatusNotFound).JSON(domain.Error{
-				Code:    fiber.StatusNotFound,
-				Message: err.Error(),
-			})
-		}
-		return c.Status(fiber.StatusInternalServerError).JSON(domain.Error{
-			Code:    fiber.StatusInternalServerError,
-			Message: err.Error(),
-		})
+		return err
 	}
 
 	return c.Status(fiber.StatusOK).JSON(domain.Success{
@@ -168,11 +144,8 @@ func (h *HttpUserHandler) Store(c *fiber.Ctx) error {
 		RoleId:   userReq.RoleId,
 	}
 
-	if err := h.userSvc.Store(user); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(domain.Error{
-			Code:    fiber.StatusInternalServerError,
-			Message: err.Error(),
-		})
+	if err := h.userSvc.Store(c.UserContext(), user); err != nil {
+		return err
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(domain.Success{
@@ -201,10 +174,7 @@ func (h *HttpUserHandler) Store(c *fiber.Ctx) error {
 func (h *HttpUserHandler) Update(c *fiber.Ctx) error {
 	id, err := c.ParamsInt("id")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(domain.Error{
-			Code:    fiber.StatusBadRequest,
-			Message: "invalid user id",
-		})
+		return domain.NewValidationError("invalid user id")
 	}
 
 	userReq := utilities.ExtractStructFromValidator[domain.UserUpdateRequest](c)
@@ -217,17 +187,8 @@ func (h *HttpUserHandler) Update(c *fiber.Ctx) error {
 		RoleId:   userReq.RoleId,
 	}
 
-	if err := h.userSvc.Update(user); err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return c.Status(fiber.StatusNotFound).JSON(domain.Error{
-				Code:    fiber.StatusNotFound,
-				Message: err.Error(),
-			})
-		}
-		return c.Status(fiber.StatusInternalServerError).JSON(domain.Error{
-			Code:    fiber.StatusInternalServerError,
-			Message: err.Error(),
-		})
+	if err := h.userSvc.Update(c.UserContext(), user); err != nil {
+		return err
 	}
 
 	return c.Status(fiber.StatusOK).JSON(domain.Success{
@@ -247,6 +208,7 @@ func (h *HttpUserHandler) Update(c *fiber.Ctx) error {
 //	@Param			id	path		int				true	"User ID"
 //	@Success		200	{object}	domain.Success	"Success delete user"
 //	@Failure		400	{object}	domain.Error	"Bad Request"
+//	@Failure		404	{object}	domain.Error	"Not Found"
 //	@Failure		500	{object}	domain.Error	"Internal Server Error"
 //	@Router			/users/{id} [delete]
 //
@@ -254,17 +216,11 @@ func (h *HttpUserHandler) Update(c *fiber.Ctx) error {
 func (h *HttpUserHandler) Delete(c *fiber.Ctx) error {
 	id, err := c.ParamsInt("id")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(domain.Error{
-			Code:    fiber.StatusBadRequest,
-			Message: "invalid user id",
-		})
+		return domain.NewValidationError("invalid user id")
 	}
 
-	if err := h.userSvc.Delete(uint(id)); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(domain.Error{
-			Code:    fiber.StatusInternalServerError,
-			Message: err.Error(),
-		})
+	if err := h.userSvc.Delete(c.UserContext(), uint(id)); err != nil {
+		return err
 	}
 
 	return c.Status(fiber.StatusOK).JSON(domain.Success{
@@ -272,3 +228,37 @@ func (h *HttpUserHandler) Delete(c *fiber.Ctx) error {
 		Message: "success",
 	})
 }
+
+// Restore used to restore a deleted user
+//
+//	@Summary		Restore user
+//	@Description	Restore a soft-deleted user
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		int				true	"User ID"
+//	@Success		200	{object}	domain.Success	"user detail"
+//	@Failure		400	{object}	domain.Error	"Bad Request"
+//	@Failure		404	{object}	domain.Error	"Not Found"
+//	@Failure		409	{object}	domain.Error	"Not Deleted"
+//	@Failure		500	{object}	domain.Error	"Internal Server Error"
+//	@Router			/users/{id}/restore [post]
+//
+// @Security Bearer
+func (h *HttpUserHandler) Restore(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return domain.NewValidationError("invalid user id")
+	}
+
+	user, err := h.userSvc.Restore(c.UserContext(), uint(id))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(domain.Success{
+		Code:    fiber.StatusOK,
+		Message: "user restored successfully",
+		Data:    user,
+	})
+}
@@ -2,6 +2,9 @@ package user
 
 import (
 	"book-store/internal/domain"
+	"book-store/internal/utilities"
+	"context"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -11,10 +14,10 @@ type mysqlUserRepository struct {
 }
 
 // GetByEmail
-func (m *mysqlUserRepository) GetByEmail(email string) (*domain.User, error) {
+func (m *mysqlUserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
 	var user *domain.User
 
-	if err := m.db.Where("email = ?", email).Preload("Role").First(&user).Error; err != nil {
+	if err := m.db.WithContext(ctx).Where("email = ?", email).Preload("Role").First(&user).Error; err != nil {
 		return nil, err
 	}
 
@@ -22,9 +25,9 @@ func (m *mysqlUserRepository) GetByEmail(email string) (*domain.User, error) {
 }
 
 // Count
-func (m *mysqlUserRepository) Count(filter *domain.User) (int64, error) {
+func (m *mysqlUserRepository) Count(ctx context.Context, filter *domain.User) (int64, error) {
 	var count int64
-	query := m.db.Model(&domain.User{})
+	query := utilities.Scoped(ctx, m.db).Model(&domain.User{})
 
 	if filter.Name != "" {
 		query = query.Where("name LIKE ?", "%"+filter.Name+"%")
@@ -37,17 +40,18 @@ func (m *mysqlUserRepository) Count(filter *domain.User) (int64, error) {
 	return count, nil
 }
 
-// Delete
-func (m *mysqlUserRepository) Delete(id uint) error {
-	return m.db.Delete(&domain.User{}, id).Error
+// Delete returns gorm.ErrRecordNotFound when there is no such user
+func (m *mysqlUserRepository) Delete(ctx context.Context, id uint) error {
+	db := m.db.WithContext(ctx)
+	return utilities.RequireRows(db, db.Delete(&domain.User{}, id), &domain.User{}, id)
 }
 
 // Fetch
-func (m *mysqlUserRepository) Fetch(page int, size int, filter *domain.User) ([]*domain.User, int, error) {
+func (m *mysqlUserRepository) Fetch(ctx context.Context, page int, size int, filter *domain.User) ([]*domain.User, int, error) {
 	var users []*domain.User
 
 	offset := (page - 1) * size
-	query := m.db
+	query := utilities.Scoped(ctx, m.db)
 
 	if filter.Name != "" {
 		query = query.Where("name LIKE ?", "%"+filter.Name+"%")
@@ -66,10 +70,10 @@ func (m *mysqlUserRepository) Fetch(page int, size int, filter *domain.User) ([]
 }
 
 // GetById
-func (m *mysqlUserRepository) GetById(id uint) (*domain.User, error) {
+func (m *mysqlUserRepository) GetById(ctx context.Context, id uint) (*domain.User, error) {
 	var user *domain.User
 
-	if err := m.db.Preload("Role").First(&user, id).Error; err != nil {
+	if err := utilities.Scoped(ctx, m.db).Preload("Role").First(&user, id).Error; err != nil {
 		return nil, err
 	}
 
@@ -77,13 +81,63 @@ func (m *mysqlUserRepository) GetById(id uint) (*domain.User, error) {
 }
 
 // Store
-func (m *mysqlUserRepository) Store(user *domain.User) error {
-	return m.db.Create(user).Error
+func (m *mysqlUserRepository) Store(ctx context.Context, user *domain.User) error {
+	return m.db.WithContext(ctx).Create(user).Error
 }
 
-// Update
-func (m *mysqlUserRepository) Update(user *domain.User) error {
-	return m.db.Updates(user).Error
+// Update returns gorm.ErrRecordNotFound when there is no such user
+func (m *mysqlUserRepository) Update(ctx context.Context, user *domain.User) error {
+	db := m.db.WithContext(ctx)
+	return utilities.RequireRows(db, db.Updates(user), &domain.User{}, user.ID)
+}
+
+// UpdatePassword returns gorm.ErrRecordNotFound when there is no such user
+func (m *mysqlUserRepository) UpdatePassword(ctx context.Context, user *domain.User) error {
+	db := m.db.WithContext(ctx)
+	return utilities.RequireRows(db, db.Select(domain.UserPasswordColumns).Updates(user), &domain.User{}, user.ID)
+}
+
+// Restore
+func (m *mysqlUserRepository) Restore(ctx context.Context, id uint) error {
+	return utilities.Restore(m.db.WithContext(ctx), &domain.User{}, id)
+}
+
+// Purge keeps users who recorded a transaction, it still shows who sold it. The two-factor secrets, recovery
+// codes, challenges, reset tokens and email verifications of the purged users go with them
+func (m *mysqlUserRepository) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	var purged int64
+
+	err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		referenced := tx.Unscoped().Model(&domain.Transaction{}).Select("1").Where("transactions.user_id = users.id")
+
+		var ids []uint
+		if err := tx.Unscoped().Model(&domain.User{}).
+			Where("deleted_at < ? AND NOT EXISTS (?)", deletedBefore, referenced).
+			Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		for _, model := range []any{
+			&domain.TwoFactor{},
+			&domain.RecoveryCode{},
+			&domain.TwoFactorChallenge{},
+			&domain.PasswordResetToken{},
+			&domain.EmailVerification{},
+		} {
+			if err := tx.Unscoped().Where("user_id IN ?", ids).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+
+		result := tx.Unscoped().Delete(&domain.User{}, ids)
+		purged = result.RowsAffected
+		return result.Error
+	})
+
+	return purged, err
 }
 
 func NewMysqlUserRepository(db *gorm.DB) domain.UserRepository {
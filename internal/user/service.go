@@ -1,21 +1,26 @@
 package user
 
 import (
-	"errors"
 	"book-store/internal/domain"
-	"book-store/internal/utilities"
+	"book-store/internal/tracing"
+	"context"
+	"errors"
+	"time"
 
-	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 )
 
 type userService struct {
-	userRepo domain.UserRepository
+	userRepo    domain.UserRepository
+	passwordSvc domain.PasswordService
 }
 
 // Count
-func (u *userService) Count(filter *domain.User) (int64, error) {
-	count, err := u.userRepo.Count(filter)
+func (u *userService) Count(ctx context.Context, filter *domain.User) (int64, error) {
+	ctx, span := tracing.Start(ctx, "UserService.Count")
+	defer span.End()
+
+	count, err := u.userRepo.Count(ctx, filter)
 	if err != nil {
 		return 0, err
 	}
@@ -23,13 +28,25 @@ func (u *userService) Count(filter *domain.User) (int64, error) {
 }
 
 // Delete
-func (u *userService) Delete(id uint) error {
-	return u.userRepo.Delete(id)
+func (u *userService) Delete(ctx context.Context, id uint) error {
+	ctx, span := tracing.Start(ctx, "UserService.Delete")
+	defer span.End()
+
+	if err := u.userRepo.Delete(ctx, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrUserNotFound
+		}
+		return err
+	}
+	return nil
 }
 
 // Fetch
-func (u *userService) Fetch(page int, size int, filter *domain.User) ([]*domain.User, int, error) {
-	users, nextCursor, err := u.userRepo.Fetch(page, size, filter)
+func (u *userService) Fetch(ctx context.Context, page int, size int, filter *domain.User) ([]*domain.User, int, error) {
+	ctx, span := tracing.Start(ctx, "UserService.Fetch")
+	defer span.End()
+
+	users, nextCursor, err := u.userRepo.Fetch(ctx, page, size, filter)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -38,11 +55,14 @@ func (u *userService) Fetch(page int, size int, filter *domain.User) ([]*domain.
 }
 
 // GetById
-func (u *userService) GetById(id uint) (*domain.User, error) {
-	user, err := u.userRepo.GetById(id)
+func (u *userService) GetById(ctx context.Context, id uint) (*domain.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetById")
+	defer span.End()
+
+	user, err := u.userRepo.GetById(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, fiber.ErrNotFound
+			return nil, domain.ErrUserNotFound
 		}
 		return nil, err
 	}
@@ -51,24 +71,68 @@ func (u *userService) GetById(id uint) (*domain.User, error) {
 }
 
 // Store
-func (u *userService) Store(user *domain.User) error {
-	// Hash password
-	hashedPassword, err := utilities.HashPassword(user.Password)
-	if err != nil {
+func (u *userService) Store(ctx context.Context, user *domain.User) error {
+	ctx, span := tracing.Start(ctx, "UserService.Store")
+	defer span.End()
+
+	if err := u.setPassword(user); err != nil {
 		return err
 	}
-	user.Password = hashedPassword
 
-	return u.userRepo.Store(user)
+	return u.userRepo.Store(ctx, user)
 }
 
 // Update
-func (u *userService) Update(user *domain.User) error {
-	return u.userRepo.Update(user)
+func (u *userService) Update(ctx context.Context, user *domain.User) error {
+	ctx, span := tracing.Start(ctx, "UserService.Update")
+	defer span.End()
+
+	// an empty password keeps the current one
+	if user.Password != "" {
+		if err := u.setPassword(user); err != nil {
+			return err
+		}
+	}
+
+	if err := u.userRepo.Update(ctx, user); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrUserNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// Restore
+func (u *userService) Restore(ctx context.Context, id uint) (*domain.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.Restore")
+	defer span.End()
+
+	if err := u.userRepo.Restore(ctx, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	return u.GetById(ctx, id)
+}
+
+// setPassword replaces the password of user by its hash once it passes the password policy
+func (u *userService) setPassword(user *domain.User) error {
+	hashedPassword, err := u.passwordSvc.Hash(user.Password)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	user.Password, user.PasswordChangedAt = hashedPassword, &now
+	return nil
 }
 
-func NewUserService(userRepo domain.UserRepository) domain.UserService {
+func NewUserService(userRepo domain.UserRepository, passwordSvc domain.PasswordService) domain.UserService {
 	return &userService{
-		userRepo: userRepo,
+		userRepo:    userRepo,
+		passwordSvc: passwordSvc,
 	}
 }
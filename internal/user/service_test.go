@@ -0,0 +1,66 @@
+package user
+
+import (
+	"book-store/internal/domain"
+	"book-store/internal/testdb"
+	"book-store/internal/utilities"
+	"context"
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestUserServiceNotFound(t *testing.T) {
+	db := testdb.Open(t, &domain.Role{}, &domain.User{})
+	svc := &userService{userRepo: NewMysqlUserRepository(db)}
+	ctx := context.Background()
+
+	if err := db.Create(&domain.Role{Name: "employee"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	hash, err := utilities.HashPassword("Sup3r-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	deleted := &domain.User{Name: "Jane", Email: "jane@mail.com", Password: hash, RoleId: 1}
+	if err := db.Create(deleted).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Delete(deleted).Error; err != nil {
+		t.Fatal(err)
+	}
+	const missing = 404
+
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{name: "update missing", call: func() error {
+			return svc.Update(ctx, &domain.User{Model: gorm.Model{ID: missing}, Name: "Jane Doe"})
+		}},
+		{name: "update deleted", call: func() error {
+			return svc.Update(ctx, &domain.User{Model: gorm.Model{ID: deleted.ID}, Name: "Jane Doe"})
+		}},
+		{name: "delete missing", call: func() error { return svc.Delete(ctx, missing) }},
+		{name: "delete deleted", call: func() error { return svc.Delete(ctx, deleted.ID) }},
+		{name: "restore missing", call: func() error {
+			_, err := svc.Restore(ctx, missing)
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			if !errors.Is(err, domain.ErrUserNotFound) {
+				t.Fatalf("err = %v, want %v", err, domain.ErrUserNotFound)
+			}
+
+			var appErr *domain.AppError
+			if !errors.As(err, &appErr) || appErr.Status() != 404 {
+				t.Errorf("err = %v, want a 404", err)
+			}
+		})
+	}
+}
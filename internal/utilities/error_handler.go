@@ -0,0 +1,70 @@
+package utilities
+
+import (
+	"book-store/internal/domain"
+	"book-store/pkg/xlogger"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+const mimeProblemJSON = "application/problem+json"
+
+// ErrorHandler is the only place errors become responses, as RFC 7807 problem documents.
+// Only domain and fiber errors say what went wrong, anything else is logged and answered with a bare 500
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	problem := &domain.Error{
+		Type:      "about:blank",
+		Status:    fiber.StatusInternalServerError,
+		Detail:    "internal server error",
+		Code:      "internal_error",
+		Instance:  c.Path(),
+		RequestId: c.GetRespHeader(fiber.HeaderXRequestID),
+	}
+
+	var appErr *domain.AppError
+	var fiberErr *fiber.Error
+	switch {
+	case errors.As(err, &appErr):
+		problem.Status = appErr.Status()
+		problem.Detail = appErr.Message
+		problem.Code = appErr.Code
+		problem.Errors = appErr.Details
+	case errors.As(err, &fiberErr):
+		problem.Status = fiberErr.Code
+		problem.Detail = fiberErr.Message
+		problem.Code = statusCode(fiberErr.Code)
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		// a repository error a service didn't translate, still a 404 rather than a 500
+		problem.Status = fiber.StatusNotFound
+		problem.Detail = "resource not found"
+		problem.Code = "not_found"
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		problem.Status = fiber.StatusConflict
+		problem.Detail = "resource already exists"
+		problem.Code = "duplicate"
+	case errors.Is(err, gorm.ErrForeignKeyViolated):
+		problem.Status = fiber.StatusConflict
+		problem.Detail = "resource is still referenced or references a missing one"
+		problem.Code = "reference_violation"
+	}
+	problem.Title = http.StatusText(problem.Status)
+
+	if problem.Status >= fiber.StatusInternalServerError {
+		xlogger.Ctx(c.UserContext()).Error().Err(err).Int("status", problem.Status).Msg("request failed")
+	}
+
+	return c.Status(problem.Status).JSON(problem, mimeProblemJSON)
+}
+
+// statusCode turns the status text into a code, e.g. method_not_allowed
+func statusCode(status int) string {
+	text := http.StatusText(status)
+	if text == "" {
+		return "error"
+	}
+	return strings.ReplaceAll(strings.ToLower(text), " ", "_")
+}
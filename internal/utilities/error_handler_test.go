@@ -0,0 +1,58 @@
+package utilities
+
+import (
+	"book-store/internal/domain"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func TestErrorHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "domain not found", err: domain.ErrBookNotFound, wantStatus: fiber.StatusNotFound, wantCode: "book_not_found"},
+		{name: "wrapped domain error", err: fmt.Errorf("store: %w", domain.ErrCustomerNotFound), wantStatus: fiber.StatusNotFound, wantCode: "customer_not_found"},
+		{name: "validation", err: domain.NewValidationError("invalid book id"), wantStatus: fiber.StatusBadRequest, wantCode: "invalid_request"},
+		{name: "fiber error", err: fiber.ErrMethodNotAllowed, wantStatus: fiber.StatusMethodNotAllowed, wantCode: "method_not_allowed"},
+		{name: "untranslated record not found", err: gorm.ErrRecordNotFound, wantStatus: fiber.StatusNotFound, wantCode: "not_found"},
+		{name: "duplicate key", err: gorm.ErrDuplicatedKey, wantStatus: fiber.StatusConflict, wantCode: "duplicate"},
+		{name: "foreign key", err: gorm.ErrForeignKeyViolated, wantStatus: fiber.StatusConflict, wantCode: "reference_violation"},
+		{name: "anything else", err: errors.New("connection refused"), wantStatus: fiber.StatusInternalServerError, wantCode: "internal_error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+			app.Get("/", func(c *fiber.Ctx) error { return tt.err })
+
+			resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if contentType := resp.Header.Get(fiber.HeaderContentType); contentType != mimeProblemJSON {
+				t.Errorf("content type = %q, want %s", contentType, mimeProblemJSON)
+			}
+
+			var problem domain.Error
+			if err := json.NewDecoder(resp.Body).Decode(&problem); err != nil {
+				t.Fatal(err)
+			}
+			if problem.Status != tt.wantStatus || problem.Code != tt.wantCode {
+				t.Errorf("problem = %+v, want status %d and code %s", problem, tt.wantStatus, tt.wantCode)
+			}
+		})
+	}
+}
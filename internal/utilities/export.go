@@ -0,0 +1,113 @@
+package utilities
+
+import (
+	"book-store/internal/domain"
+	"book-store/pkg/xlogger"
+	"book-store/pkg/xlsx"
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RowWriter writes an export one row at a time
+type RowWriter interface {
+	WriteRow(values []any) error
+	Close() error
+}
+
+type csvRowWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvRowWriter) WriteRow(values []any) error {
+	record := make([]string, len(values))
+	for i, value := range values {
+		switch v := value.(type) {
+		case nil:
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, bool:
+			record[i] = fmt.Sprint(v)
+		case time.Time:
+			if !v.IsZero() {
+				record[i] = v.Format(time.RFC3339)
+			}
+		default:
+			// text is escaped so a cell like =HYPERLINK(...) isn't evaluated by the spreadsheet app opening the export
+			record[i] = xlsx.EscapeFormula(fmt.Sprint(v))
+		}
+	}
+
+	return c.w.Write(record)
+}
+
+func (c *csvRowWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func NewRowWriter(format domain.ExportFormat, w io.Writer, name string) (RowWriter, error) {
+	switch format {
+	case domain.ExportFormatCSV:
+		return &csvRowWriter{w: csv.NewWriter(w)}, nil
+	case domain.ExportFormatXLSX:
+		return xlsx.NewWriter(w, name)
+	default:
+		return nil, domain.ErrInvalidExportFormat
+	}
+}
+
+// StreamExport sends the rows produced by each as a csv or xlsx attachment, the body is
+// streamed after the handler returns so the export is never held in memory
+func StreamExport(c *fiber.Ctx, format domain.ExportFormat, name string, header []string, each func(write func(values ...any) error) error) error {
+	switch format {
+	case domain.ExportFormatCSV:
+		c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+	case domain.ExportFormatXLSX:
+		c.Set(fiber.HeaderContentType, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	default:
+		return domain.ErrInvalidExportFormat
+	}
+
+	filename := fmt.Sprintf("%s-%s.%s", name, time.Now().Format("20060102-150405"), format)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+
+	ctx := c.UserContext()
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		// the status is already sent at this point, failures can only be logged
+		if err := writeExport(w, format, name, header, each); err != nil {
+			xlogger.Ctx(ctx).Error().Err(err).Str("export", name).Msg("export failed")
+		}
+	})
+
+	return nil
+}
+
+func writeExport(w *bufio.Writer, format domain.ExportFormat, name string, header []string, each func(write func(values ...any) error) error) error {
+	rw, err := NewRowWriter(format, w, name)
+	if err != nil {
+		return err
+	}
+
+	headerRow := make([]any, len(header))
+	for i, column := range header {
+		headerRow[i] = column
+	}
+	if err := rw.WriteRow(headerRow); err != nil {
+		return err
+	}
+
+	if err := each(func(values ...any) error {
+		return rw.WriteRow(values)
+	}); err != nil {
+		return err
+	}
+
+	if err := rw.Close(); err != nil {
+		return err
+	}
+
+	return w.Flush()
+}
@@ -0,0 +1,63 @@
+package utilities
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+	"time"
+)
+
+func TestCsvRowWriter(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []any
+		want   []string
+	}{
+		{
+			name:   "text",
+			values: []any{"Dune", "Frank Herbert"},
+			want:   []string{"Dune", "Frank Herbert"},
+		},
+		{
+			name:   "formula text is escaped",
+			values: []any{"=1+1", "@cmd", "+62 812"},
+			want:   []string{"'=1+1", "'@cmd", "'+62 812"},
+		},
+		{
+			name:   "negative numbers are kept",
+			values: []any{-5, -1.5},
+			want:   []string{"-5", "-1.5"},
+		},
+		{
+			name:   "times and nil",
+			values: []any{time.Date(2024, 3, 14, 9, 0, 0, 0, time.UTC), time.Time{}, nil},
+			want:   []string{"2024-03-14T09:00:00Z", "", ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w := &csvRowWriter{w: csv.NewWriter(&buf)}
+			if err := w.WriteRow(tt.values); err != nil {
+				t.Fatal(err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := csv.NewReader(&buf).Read()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d cells, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("cell %d = %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
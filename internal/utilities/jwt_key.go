@@ -0,0 +1,165 @@
+package utilities
+
+import (
+	"book-store/internal/domain"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// jwtKey is a key tokens are verified with and, when private is set, signed with
+type jwtKey struct {
+	id      string
+	method  jwt.SigningMethod
+	private crypto.Signer
+	public  crypto.PublicKey
+	jwk     domain.JsonWebKey
+}
+
+// parsePrivateKey reads a base64 encoded PEM RSA, ECDSA or Ed25519 private key in PKCS #1, SEC 1 or PKCS #8
+func parsePrivateKey(encoded string) (*jwtKey, error) {
+	block, err := decodePem(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	var key any
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+
+	jwtKey, err := newJwtKey(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+	jwtKey.private = signer
+
+	return jwtKey, nil
+}
+
+// parsePublicKey reads a base64 encoded PEM RSA, ECDSA or Ed25519 public key in PKIX or PKCS #1
+func parsePublicKey(encoded string) (*jwtKey, error) {
+	block, err := decodePem(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	var key any
+	if block.Type == "RSA PUBLIC KEY" {
+		key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	} else {
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+
+	return newJwtKey(key)
+}
+
+func decodePem(encoded string) (*pem.Block, error) {
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode key: %w", err)
+	}
+
+	block, _ := pem.Decode(decoded)
+	if block == nil {
+		return nil, errors.New("could not decode key: no PEM block found")
+	}
+
+	return block, nil
+}
+
+// newJwtKey picks the signing method of the key and identifies it by its RFC 7638 thumbprint, so every instance
+// derives the same kid from the same key without configuring one
+func newJwtKey(public crypto.PublicKey) (*jwtKey, error) {
+	var (
+		method jwt.SigningMethod
+		jwk    domain.JsonWebKey
+		// members are the required members of the JWK in lexicographic order, as the thumbprint hashes them
+		members string
+	)
+
+	switch key := public.(type) {
+	case *rsa.PublicKey:
+		method = jwt.SigningMethodRS256
+		jwk = domain.JsonWebKey{
+			Kty: "RSA",
+			N:   encodeSegment(key.N.Bytes()),
+			E:   encodeSegment(big.NewInt(int64(key.E)).Bytes()),
+		}
+		members = fmt.Sprintf(`{"e":"%s","kty":"RSA","n":"%s"}`, jwk.E, jwk.N)
+	case *ecdsa.PublicKey:
+		switch key.Curve {
+		case elliptic.P256():
+			method = jwt.SigningMethodES256
+		case elliptic.P384():
+			method = jwt.SigningMethodES384
+		case elliptic.P521():
+			method = jwt.SigningMethodES512
+		default:
+			return nil, fmt.Errorf("unsupported elliptic curve %s", key.Curve.Params().Name)
+		}
+
+		// coordinates are left padded to the size of the curve, the uncompressed point has them one after another
+		ecdhKey, err := key.ECDH()
+		if err != nil {
+			return nil, fmt.Errorf("invalid elliptic curve key: %w", err)
+		}
+		point := ecdhKey.Bytes()[1:]
+		size := len(point) / 2
+		jwk = domain.JsonWebKey{
+			Kty: "EC",
+			Crv: key.Curve.Params().Name,
+			X:   encodeSegment(point[:size]),
+			Y:   encodeSegment(point[size:]),
+		}
+		members = fmt.Sprintf(`{"crv":"%s","kty":"EC","x":"%s","y":"%s"}`, jwk.Crv, jwk.X, jwk.Y)
+	case ed25519.PublicKey:
+		method = jwt.SigningMethodEdDSA
+		jwk = domain.JsonWebKey{
+			Kty: "OKP",
+			Crv: "Ed25519",
+			X:   encodeSegment(key),
+		}
+		members = fmt.Sprintf(`{"crv":"Ed25519","kty":"OKP","x":"%s"}`, jwk.X)
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", public)
+	}
+
+	thumbprint := sha256.Sum256([]byte(members))
+	jwk.Kid = encodeSegment(thumbprint[:])
+	jwk.Use = "sig"
+	jwk.Alg = method.Alg()
+
+	return &jwtKey{id: jwk.Kid, method: method, public: public, jwk: jwk}, nil
+}
+
+func encodeSegment(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
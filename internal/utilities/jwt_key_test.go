@@ -0,0 +1,100 @@
+package utilities
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"testing"
+)
+
+func decodeSegment(t *testing.T, segment string) []byte {
+	t.Helper()
+	b, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// thumbprint hashes the members marshaled by encoding/json, which sorts the keys of maps as RFC 7638 orders them
+func thumbprint(t *testing.T, members map[string]string) string {
+	t.Helper()
+	b, err := json.Marshal(members)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(b)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func TestNewJwtKeyKid(t *testing.T) {
+	// the RSA key of RFC 7638 section 3.1 and the Ed25519 key of RFC 8037 appendix A.3
+	rsaKey := &rsa.PublicKey{
+		N: new(big.Int).SetBytes(decodeSegment(t, "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw")),
+		E: 65537,
+	}
+	edKey := ed25519.PublicKey(decodeSegment(t, "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"))
+
+	// a P-256 point whose x starts with a zero byte, it has to keep its padding
+	x, y := paddedP256Point(t)
+	ecKey := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+
+	tests := []struct {
+		name    string
+		key     crypto.PublicKey
+		wantKid string
+		wantAlg string
+	}{
+		{name: "RSA", key: rsaKey, wantKid: "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs", wantAlg: "RS256"},
+		{name: "Ed25519", key: edKey, wantKid: "kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k", wantAlg: "EdDSA"},
+		{name: "ECDSA P-256", key: ecKey, wantKid: thumbprint(t, map[string]string{
+			"crv": "P-256",
+			"kty": "EC",
+			"x":   base64.RawURLEncoding.EncodeToString(x),
+			"y":   base64.RawURLEncoding.EncodeToString(y),
+		}), wantAlg: "ES256"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := newJwtKey(tt.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if key.id != tt.wantKid || key.jwk.Kid != tt.wantKid {
+				t.Errorf("kid = %q, want %q", key.id, tt.wantKid)
+			}
+			if key.jwk.Alg != tt.wantAlg {
+				t.Errorf("alg = %q, want %q", key.jwk.Alg, tt.wantAlg)
+			}
+		})
+	}
+}
+
+// paddedP256Point is the first public key of the scalars 1, 2, ... with a zero first byte of x
+func paddedP256Point(t *testing.T) ([]byte, []byte) {
+	t.Helper()
+
+	scalar := make([]byte, 32)
+	for i := 1; i < 1<<16; i++ {
+		big.NewInt(int64(i)).FillBytes(scalar)
+		private, err := ecdh.P256().NewPrivateKey(scalar)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		point := private.PublicKey().Bytes()[1:]
+		if point[0] == 0 {
+			return point[:32], point[32:]
+		}
+	}
+	t.Fatal("no point with a zero first byte of x")
+	return nil, nil
+}
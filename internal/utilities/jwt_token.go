@@ -1,11 +1,11 @@
 package utilities
 
 import (
-	"encoding/base64"
-	"errors"
-	"fmt"
 	"book-store/internal/config"
 	"book-store/internal/domain"
+	"errors"
+	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -14,67 +14,69 @@ import (
 type JwtTokenService interface {
 	GenerateToken(payload *domain.User) (domain.Token, error)
 	VerifyToken(tokenString string) (jwt.MapClaims, error)
+	// KeySet is the public part of every key tokens are verified with, for other services to verify them too
+	KeySet() domain.JsonWebKeySet
 }
 
 type jwtTokenService struct {
 	cfg config.Config
+	// signingKey signs every new token, keys holds it and the verification keys by kid
+	signingKey *jwtKey
+	keys       map[string]*jwtKey
+	keySet     domain.JsonWebKeySet
 }
 
 // GenerateToken
 func (j *jwtTokenService) GenerateToken(payload *domain.User) (domain.Token, error) {
-	// Decode private key
-	decodedPrivateKey, err := base64.StdEncoding.DecodeString(j.cfg.JwtConfig.PrivateKey)
-	if err != nil {
-		return domain.Token{}, fmt.Errorf("could not decode key: %w", err)
-	}
-
-	// Parse decoded private key
-	key, err := jwt.ParseRSAPrivateKeyFromPEM(decodedPrivateKey)
-	if err != nil {
-		return domain.Token{}, fmt.Errorf("failed to parse decoded private key: %w", err)
-	}
+	now := time.Now().UTC()
 
 	// Claims is jwt payload
 	claims := domain.JwtTokenClaims{
 		RegisteredClaims: jwt.RegisteredClaims{
-			Issuer:    payload.Name,
-			IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
-			ExpiresAt: jwt.NewNumericDate(time.Now().UTC().Add(time.Duration(j.cfg.JwtConfig.ExpiresIn))),
+			Issuer:    j.cfg.JwtConfig.Issuer,
+			Subject:   strconv.FormatUint(uint64(payload.ID), 10),
+			Audience:  jwt.ClaimStrings{j.cfg.JwtConfig.Audience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(j.cfg.JwtConfig.ExpiresIn)),
 		},
-		UserName: payload.Name,
-		RoleName: payload.Role.Name,
+		UserName:           payload.Name,
+		RoleName:           payload.Role.Name,
+		MustChangePassword: payload.MustChangePassword,
+		// users of roles requiring two-factor authentication only get to set it up until they did
+		TwoFactorSetupRequired: j.cfg.TwoFactor.Requires(payload.Role.Name) && !payload.TwoFactorEnabled,
 	}
 
-	// Sign token
-	token, err := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(key)
+	// Sign token, kid tells verifiers which key to check it with
+	token := jwt.NewWithClaims(j.signingKey.method, claims)
+	token.Header["kid"] = j.signingKey.id
+
+	signed, err := token.SignedString(j.signingKey.private)
 	if err != nil {
 		return domain.Token{}, fmt.Errorf("failed to sign token: %w", err)
 	}
 
-	return domain.Token{Token: token}, nil
+	return domain.Token{Token: signed}, nil
 }
 
 // VerifyToken
 func (j *jwtTokenService) VerifyToken(tokenString string) (jwt.MapClaims, error) {
-	// Decode public key
-	decodedPublicKey, err := base64.StdEncoding.DecodeString(j.cfg.JwtConfig.PublicKey)
-	if err != nil {
-		return nil, fmt.Errorf("could not decode public key %w", err)
-	}
-
-	// Parse public key
-	key, err := jwt.ParseRSAPublicKeyFromPEM(decodedPublicKey)
-	if err != nil {
-		return nil, fmt.Errorf("validate: parse key: %w", err)
-	}
-
 	// Parse token
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := j.keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown key id: %q", kid)
+		}
+		// the algorithm of the header is only trusted when it is the one of the key
+		if token.Method.Alg() != key.method.Alg() {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		return key, nil
-	})
+		return key.public, nil
+	},
+		jwt.WithIssuer(j.cfg.JwtConfig.Issuer),
+		jwt.WithAudience(j.cfg.JwtConfig.Audience),
+		jwt.WithExpirationRequired(),
+	)
 
 	if err != nil {
 		return nil, fmt.Errorf("error parsing token: %w", err)
@@ -89,8 +91,38 @@ func (j *jwtTokenService) VerifyToken(tokenString string) (jwt.MapClaims, error)
 	return claims, nil
 }
 
-func NewJwtTokenService(cfg config.Config) JwtTokenService {
-	return &jwtTokenService{
-		cfg: cfg,
+// KeySet
+func (j *jwtTokenService) KeySet() domain.JsonWebKeySet {
+	return j.keySet
+}
+
+// NewJwtTokenService parses the keys once, it fails on keys that can't be used rather than on the first login
+func NewJwtTokenService(cfg config.Config) (JwtTokenService, error) {
+	signingKey, err := parsePrivateKey(cfg.JwtConfig.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("JWT_PRIVATE_KEY: %w", err)
+	}
+
+	j := &jwtTokenService{
+		cfg:        cfg,
+		signingKey: signingKey,
+		keys:       map[string]*jwtKey{signingKey.id: signingKey},
+		keySet:     domain.JsonWebKeySet{Keys: []domain.JsonWebKey{signingKey.jwk}},
 	}
+
+	for i, encoded := range cfg.JwtConfig.VerificationKeys {
+		key, err := parsePublicKey(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("JWT_VERIFICATION_KEYS[%d]: %w", i, err)
+		}
+		// the public key of the signing key may be listed already while it is rolled out
+		if _, ok := j.keys[key.id]; ok {
+			continue
+		}
+
+		j.keys[key.id] = key
+		j.keySet.Keys = append(j.keySet.Keys, key.jwk)
+	}
+
+	return j, nil
 }
@@ -0,0 +1,79 @@
+package utilities
+
+import (
+	"strings"
+	"unicode"
+)
+
+// phoneKeyLength is how many trailing digits identify a phone number,
+// enough to ignore country prefixes such as +62 versus a leading 0
+const phoneKeyLength = 10
+
+// NormalizeEmail lowercases the address and drops +tags, dots in gmail
+// addresses are removed as well since gmail ignores them
+func NormalizeEmail(email string) string {
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	local, host, found := strings.Cut(email, "@")
+	if !found {
+		return email
+	}
+
+	local, _, _ = strings.Cut(local, "+")
+	if host == "gmail.com" || host == "googlemail.com" {
+		local = strings.ReplaceAll(local, ".", "")
+		host = "gmail.com"
+	}
+
+	return local + "@" + host
+}
+
+// NormalizePhone keeps the trailing digits of a phone number
+func NormalizePhone(phone string) string {
+	digits := strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, phone)
+
+	if len(digits) > phoneKeyLength {
+		digits = digits[len(digits)-phoneKeyLength:]
+	}
+
+	return digits
+}
+
+// NormalizeName lowercases a name and collapses its whitespace
+func NormalizeName(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), " ")
+}
+
+// Similarity returns how alike two strings are from 0 to 1, based on the Levenshtein distance
+func Similarity(a string, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 1
+	}
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return 1 - float64(prev[len(rb)])/float64(longest)
+}
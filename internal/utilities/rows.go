@@ -0,0 +1,23 @@
+package utilities
+
+import "gorm.io/gorm"
+
+// RequireRows turns a write by id that touched no row into gorm.ErrRecordNotFound. Mysql doesn't count
+// rows updated to the values they already had, so a miss is confirmed with a lookup before reporting it
+func RequireRows(db *gorm.DB, result *gorm.DB, model any, id uint) error {
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		return nil
+	}
+
+	var count int64
+	if err := db.Model(model).Where("id = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
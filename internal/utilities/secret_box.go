@@ -0,0 +1,69 @@
+package utilities
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// SecretBoxKeySize is the size of the AES-256 key of the secret box
+const SecretBoxKeySize = 32
+
+var errSealedSecret = errors.New("sealed secret is malformed or was sealed with another key")
+
+// SecretBox encrypts secrets that have to be read back, unlike passwords and tokens which are only hashed. Each
+// secret is sealed for a context, e.g. the row it is stored in, so it can't be copied to another one
+type SecretBox struct {
+	aead cipher.AEAD
+}
+
+// Seal is the base64 encoded nonce and AES-GCM ciphertext of the secret
+func (s *SecretBox) Seal(secret []byte, context string) (string, error) {
+	nonce := make([]byte, s.aead.NonceSize(), s.aead.NonceSize()+len(secret)+s.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := s.aead.Seal(nonce, nonce, secret, []byte(context))
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Open is the secret Seal sealed for the context
+func (s *SecretBox) Open(sealed string, context string) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil || len(data) < s.aead.NonceSize() {
+		return nil, errSealedSecret
+	}
+
+	nonce, ciphertext := data[:s.aead.NonceSize()], data[s.aead.NonceSize():]
+	secret, err := s.aead.Open(nil, nonce, ciphertext, []byte(context))
+	if err != nil {
+		return nil, errSealedSecret
+	}
+	return secret, nil
+}
+
+// NewSecretBox takes the base64 encoded key
+func NewSecretBox(key string) (*SecretBox, error) {
+	raw, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, fmt.Errorf("secret box key is not base64 encoded: %w", err)
+	}
+	if len(raw) != SecretBoxKeySize {
+		return nil, fmt.Errorf("secret box key is %d bytes long, want %d", len(raw), SecretBoxKeySize)
+	}
+
+	block, err := aes.NewCipher(raw)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SecretBox{aead: aead}, nil
+}
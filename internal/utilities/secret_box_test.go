@@ -0,0 +1,86 @@
+package utilities
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestNewSecretBox(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		wantErr bool
+	}{
+		{name: "32 bytes", key: base64.StdEncoding.EncodeToString(make([]byte, 32))},
+		{name: "16 bytes", key: base64.StdEncoding.EncodeToString(make([]byte, 16)), wantErr: true},
+		{name: "not base64", key: strings.Repeat("!", 44), wantErr: true},
+		{name: "empty", key: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewSecretBox(tt.key); (err != nil) != tt.wantErr {
+				t.Errorf("NewSecretBox() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSecretBoxOpen(t *testing.T) {
+	key := make([]byte, SecretBoxKeySize)
+	for i := range key {
+		key[i] = byte(i)
+	}
+	box, err := NewSecretBox(base64.StdEncoding.EncodeToString(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherBox, err := NewSecretBox(base64.StdEncoding.EncodeToString(make([]byte, SecretBoxKeySize)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secret := []byte("12345678901234567890")
+	sealed, err := box.Seal(secret, "two_factor:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sealed) != 64 {
+		t.Errorf("len(Seal()) = %d, want 64", len(sealed))
+	}
+	if resealed, _ := box.Seal(secret, "two_factor:1"); resealed == sealed {
+		t.Error("Seal() reused the nonce")
+	}
+
+	tampered := []byte(sealed)
+	tampered[len(tampered)/2] ^= 'A' ^ 'B'
+
+	tests := []struct {
+		name    string
+		box     *SecretBox
+		sealed  string
+		context string
+		wantErr bool
+	}{
+		{name: "same key and context", box: box, sealed: sealed, context: "two_factor:1"},
+		{name: "other context", box: box, sealed: sealed, context: "two_factor:2", wantErr: true},
+		{name: "other key", box: otherBox, sealed: sealed, context: "two_factor:1", wantErr: true},
+		{name: "tampered", box: box, sealed: string(tampered), context: "two_factor:1", wantErr: true},
+		{name: "plaintext", box: box, sealed: "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", context: "two_factor:1", wantErr: true},
+		{name: "shorter than a nonce", box: box, sealed: "AAAA", context: "two_factor:1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.box.Open(tt.sealed, tt.context)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Open() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !bytes.Equal(got, secret) {
+				t.Errorf("Open() = %q, want %q", got, secret)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package utilities
+
+import (
+	"book-store/internal/domain"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// Scoped is db for ctx, unscoped when the request asked for soft-deleted records too
+func Scoped(ctx context.Context, db *gorm.DB) *gorm.DB {
+	db = db.WithContext(ctx)
+	if domain.IncludesDeleted(ctx) {
+		return db.Unscoped()
+	}
+	return db
+}
+
+// Unscoped is a preload condition keeping soft-deleted associations, e.g. books of past transactions
+func Unscoped(db *gorm.DB) *gorm.DB {
+	return db.Unscoped()
+}
+
+// Restore clears deleted_at of the row with id. It returns domain.ErrNotDeleted when the row isn't deleted
+// and gorm.ErrRecordNotFound when there is no row at all
+func Restore(db *gorm.DB, model any, id uint) error {
+	result := db.Unscoped().Model(model).Where("id = ? AND deleted_at IS NOT NULL", id).Update("deleted_at", nil)
+	if result.Error != nil || result.RowsAffected > 0 {
+		return result.Error
+	}
+
+	var count int64
+	if err := db.Unscoped().Model(model).Where("id = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return domain.ErrNotDeleted
+}
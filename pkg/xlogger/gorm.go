@@ -0,0 +1,64 @@
+package xlogger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+	gormLogger "gorm.io/gorm/logger"
+)
+
+// GormLogger writes queries to the logger of their context, debug normally, warn when slower than
+// SlowThreshold and error when they fail. Bound values are never logged, only placeholders
+type GormLogger struct {
+	SlowThreshold time.Duration
+}
+
+// LogMode is a no-op, the level of the zerolog logger decides what is written
+func (g GormLogger) LogMode(gormLogger.LogLevel) gormLogger.Interface {
+	return g
+}
+
+func (g GormLogger) Info(ctx context.Context, msg string, data ...any) {
+	Ctx(ctx).Info().Msg(fmt.Sprintf(msg, data...))
+}
+
+func (g GormLogger) Warn(ctx context.Context, msg string, data ...any) {
+	Ctx(ctx).Warn().Msg(fmt.Sprintf(msg, data...))
+}
+
+func (g GormLogger) Error(ctx context.Context, msg string, data ...any) {
+	Ctx(ctx).Error().Msg(fmt.Sprintf(msg, data...))
+}
+
+func (g GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	elapsed := time.Since(begin)
+	logger := Ctx(ctx)
+
+	var event *zerolog.Event
+	msg := "query"
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		event, msg = logger.Error().Err(err), "query failed"
+	case g.SlowThreshold > 0 && elapsed > g.SlowThreshold:
+		event, msg = logger.Warn().Dur("threshold", g.SlowThreshold), "slow query"
+	default:
+		event = logger.Debug()
+	}
+
+	// building the statement is skipped when the level drops the line anyway
+	if !event.Enabled() {
+		return
+	}
+
+	sql, rows := fc()
+	event.Dur("elapsed", elapsed).Int64("rows", rows).Str("sql", sql).Msg(msg)
+}
+
+// ParamsFilter drops the bound values so passwords and tokens in queries don't reach the log
+func (g GormLogger) ParamsFilter(_ context.Context, sql string, _ ...any) (string, []any) {
+	return sql, nil
+}
@@ -0,0 +1,114 @@
+package xlogger
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces the values of sensitive fields
+const Redacted = "[REDACTED]"
+
+// sensitiveKeys are matched case-insensitively against any part of a field name
+var sensitiveKeys = []string{"password", "token", "secret", "authorization", "cookie", "otp"}
+
+var bearerPattern = regexp.MustCompile(`(?i)bearer\s+[\w\-.~+/]+=*`)
+
+// redactWriter masks sensitive fields of every line before writing it, including the ones nested in
+// request and response bodies logged as json strings
+type redactWriter struct {
+	out io.Writer
+}
+
+func (w *redactWriter) Write(p []byte) (int, error) {
+	if !mayBeSensitive(p) {
+		return w.out.Write(p)
+	}
+
+	var fields map[string]any
+	if err := decode(p, &fields); err != nil {
+		return w.out.Write(p)
+	}
+
+	line, err := json.Marshal(redact(fields))
+	if err != nil {
+		return w.out.Write(p)
+	}
+
+	// the caller only knows the length of what it wrote
+	if _, err := w.out.Write(append(line, '\n')); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// mayBeSensitive skips decoding the lines that can't contain anything to mask, which is most of them
+func mayBeSensitive(p []byte) bool {
+	lower := bytes.ToLower(p)
+	if bytes.Contains(lower, []byte("bearer")) {
+		return true
+	}
+	for _, key := range sensitiveKeys {
+		if bytes.Contains(lower, []byte(key)) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsSensitive reports whether the field name holds a secret, its value must never be written anywhere
+func IsSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+func redact(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			if IsSensitive(key) {
+				v[key] = Redacted
+				continue
+			}
+			v[key] = redact(field)
+		}
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = redact(item)
+		}
+		return v
+	case string:
+		return redactString(v)
+	default:
+		return v
+	}
+}
+
+// redactString masks bearer tokens and the fields of a json document embedded in the string
+func redactString(s string) string {
+	trimmed := strings.TrimSpace(s)
+	if strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+		var doc any
+		if err := decode([]byte(trimmed), &doc); err == nil {
+			if data, err := json.Marshal(redact(doc)); err == nil {
+				return string(data)
+			}
+		}
+	}
+	return bearerPattern.ReplaceAllString(s, "Bearer "+Redacted)
+}
+
+// decode keeps numbers as they were written, float64 would mangle large ids
+func decode(data []byte, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
@@ -2,23 +2,77 @@ package xlogger
 
 import (
 	"book-store/internal/config"
+	"context"
+	"fmt"
+	"io"
 	"os"
 	"time"
 
 	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	FormatConsole = "console"
+	FormatJSON    = "json"
 )
 
 var (
 	Logger *zerolog.Logger
 )
 
-func Setup(cfg config.Config) {
+// Setup builds the global logger, level and format default to debug on the console in development and info as json otherwise
+func Setup(cfg config.Config) error {
+	level, format := zerolog.InfoLevel, FormatJSON
 	if cfg.IsDevelopment {
-		l := zerolog.New(zerolog.ConsoleWriter{Out: os.Stdout, TimeFormat: time.RFC3339}).With().Timestamp().Logger()
-		l.Level(zerolog.DebugLevel)
-		Logger = &l
-		return
+		level, format = zerolog.DebugLevel, FormatConsole
+	}
+
+	if cfg.LogLevel != "" {
+		var err error
+		if level, err = zerolog.ParseLevel(cfg.LogLevel); err != nil {
+			return err
+		}
 	}
-	l := zerolog.New(os.Stderr).With().Timestamp().Logger()
+	if cfg.LogFormat != "" {
+		format = cfg.LogFormat
+	}
+
+	var out io.Writer
+	switch format {
+	case FormatConsole:
+		out = zerolog.ConsoleWriter{Out: os.Stdout, TimeFormat: time.RFC3339}
+	case FormatJSON:
+		out = os.Stderr
+	default:
+		return fmt.Errorf("unknown log format %q", format)
+	}
+
+	l := zerolog.New(&redactWriter{out: out}).Level(level).With().Timestamp().Logger().Hook(traceHook{})
 	Logger = &l
+	zerolog.DefaultContextLogger = Logger
+	return nil
+}
+
+// Ctx returns the request logger carried by ctx, or the global one, bound to ctx so lines get its trace ids
+func Ctx(ctx context.Context) *zerolog.Logger {
+	l := zerolog.Ctx(ctx).With().Ctx(ctx).Logger()
+	return &l
+}
+
+// With returns a copy of ctx whose logger has the fields added, later Ctx calls on it include them
+func With(ctx context.Context, fields func(c zerolog.Context) zerolog.Context) context.Context {
+	l := fields(zerolog.Ctx(ctx).With()).Logger()
+	return l.WithContext(ctx)
+}
+
+// traceHook adds the ids of the span in the event context, events get one with Ctx or a logger built With().Ctx
+type traceHook struct{}
+
+func (traceHook) Run(e *zerolog.Event, _ zerolog.Level, _ string) {
+	spanContext := trace.SpanContextFromContext(e.GetCtx())
+	if !spanContext.IsValid() {
+		return
+	}
+	e.Str("trace_id", spanContext.TraceID().String()).Str("span_id", spanContext.SpanID().String())
 }
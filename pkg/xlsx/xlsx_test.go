@@ -0,0 +1,28 @@
+package xlsx
+
+import "testing"
+
+func TestEscapeFormula(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{text: "", want: ""},
+		{text: "Dune", want: "Dune"},
+		{text: "=HYPERLINK(\"http://x\")", want: "'=HYPERLINK(\"http://x\")"},
+		{text: "+1+1", want: "'+1+1"},
+		{text: "-2+3", want: "'-2+3"},
+		{text: "@SUM(A1)", want: "'@SUM(A1)"},
+		{text: "\t=1", want: "'\t=1"},
+		{text: "\r=1", want: "'\r=1"},
+		{text: "a=1", want: "a=1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := EscapeFormula(tt.text); got != tt.want {
+				t.Errorf("EscapeFormula(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}